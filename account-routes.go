@@ -1,7 +1,7 @@
 package main
 
 func AccountRoutes(root *Root) {
-	root.PostAuthorized(
+	root.PostIdempotent(
 		"/api/account/add-party",
 		"VIEW_EDIT_PARTY",
 		root.account.AddPartyHandler)
@@ -36,12 +36,27 @@ func AccountRoutes(root *Root) {
 		"VIEW_EDIT_PARTY",
 		root.account.DeleteCustomerHandler)
 
+	root.GetAuthorized(
+		"/api/account/view-supplier",
+		"VIEW_EDIT_PARTY",
+		root.account.ViewSupplierHandler)
+
+	root.PostAuthorized(
+		"/api/account/update-supplier",
+		"VIEW_EDIT_PARTY",
+		root.account.UpdateSupplierHandler)
+
+	root.PostAuthorized(
+		"/api/account/delete-supplier",
+		"VIEW_EDIT_PARTY",
+		root.account.DeleteSupplierHandler)
+
 	root.GetAuthorized(
 		"/api/account/query-simple-person",
 		"VIEW_EDIT_PARTY",
 		root.account.QuerySimplePersonHandler)
 
-	root.PostAuthorized(
+	root.PostIdempotent(
 		"/api/account/add-user-login",
 		"VIEW_EDIT_PARTY",
 		root.account.AddUserLogin)
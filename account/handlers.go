@@ -33,6 +33,10 @@ type AddPartyRequest struct {
 	BirthDate    string `json:"birthDate"`
 	GenderId     int16  `json:"genderId"`
 	CustomerName string `json:"customerName"`
+	SupplierName string `json:"supplierName"`
+	Phone        string `json:"phone"`
+	Email        string `json:"email"`
+	Address      string `json:"address"`
 }
 
 func (root *Root) addPersonHandler(
@@ -82,6 +86,33 @@ func (root *Root) addCustomerHandler(
 	return basic.ReturnOk(w)
 }
 
+func (root *Root) addSupplierHandler(
+	w http.ResponseWriter,
+	ctx context.Context, tx *sqlx.Tx,
+	request AddPartyRequest, id uuid.UUID,
+) error {
+
+	s := Supplier{
+		Id:      id,
+		Name:    request.SupplierName,
+		Phone:   request.Phone,
+		Email:   request.Email,
+		Address: request.Address,
+	}
+
+	err := root.repo.InsertSupplier(ctx, tx, s)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
+
 func (root *Root) AddPartyHandler(
 	w http.ResponseWriter, r *http.Request) error {
 
@@ -112,6 +143,9 @@ func (root *Root) AddPartyHandler(
 	if request.PartyTypeId == 2 {
 		return root.addCustomerHandler(w, ctx, tx, request, id)
 	}
+	if request.PartyTypeId == 3 {
+		return root.addSupplierHandler(w, ctx, tx, request, id)
+	}
 
 	return errors.New("PartyTypeId not supported")
 }
@@ -384,6 +418,96 @@ func (root *Root) DeleteCustomerHandler(
 	return json.NewEncoder(w).Encode(res)
 }
 
+func (root *Root) ViewSupplierHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	sortedByQuery := query.Get("sortedBy")
+	sortedBy := "created_at"
+	if sortedByQuery == "name" {
+		sortedBy = "name"
+	} else if sortedByQuery == "updatedAt" {
+		sortedBy = "updated_at"
+	}
+
+	sortOrderQuery := query.Get("sortOrder")
+	sortOrder := "desc"
+	if sortOrderQuery == "asc" {
+		sortOrder = "asc"
+	}
+
+	count, supplierList, err := root.repo.ViewSupplier(
+		ctx, page, pageSize, sortedBy, sortOrder)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		Count        int              `json:"count"`
+		SupplierList []ClientSupplier `json:"supplierList"`
+	}
+
+	response := Response{
+		Count:        count,
+		SupplierList: supplierList,
+	}
+	return json.NewEncoder(w).Encode(response)
+}
+
+func (root *Root) UpdateSupplierHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	supplier := ClientSupplier{}
+	err := json.NewDecoder(r.Body).Decode(&supplier)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.UpdateSupplier(ctx, supplier)
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) DeleteSupplierHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Id uuid.UUID `json:"id"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.DeleteSupplier(ctx, req.Id)
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
+
 func (root *Root) QuerySimplePersonHandler(
 	w http.ResponseWriter, r *http.Request) error {
 
@@ -28,6 +28,14 @@ type Customer struct {
 	Description string    `json:"description" db:"description"`
 }
 
+type Supplier struct {
+	Id      uuid.UUID `json:"id" db:"id"`
+	Name    string    `json:"name" db:"name"`
+	Phone   string    `json:"phone" db:"phone"`
+	Email   string    `json:"email" db:"email"`
+	Address string    `json:"address" db:"address"`
+}
+
 type ClientPerson struct {
 	Id          uuid.UUID `json:"id" db:"id"`
 	FirstName   string    `json:"firstName" db:"first_name"`
@@ -48,6 +56,17 @@ type ClientCustomer struct {
 	Description string    `json:"description" db:"description"`
 }
 
+type ClientSupplier struct {
+	Id          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Phone       string    `json:"phone" db:"phone"`
+	Email       string    `json:"email" db:"email"`
+	Address     string    `json:"address" db:"address"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+	Description string    `json:"description" db:"description"`
+}
+
 type SimplePerson struct {
 	Id         uuid.UUID `json:"id" db:"id"`
 	FirstName  string    `json:"firstName" db:"first_name"`
@@ -153,6 +153,19 @@ func (repo *Repo) InsertCustomer(ctx context.Context,
 	return err
 }
 
+func (repo *Repo) InsertSupplier(ctx context.Context,
+	tx *sqlx.Tx, supplier Supplier) error {
+
+	log.Println("InsertSupplier", supplier.Id, supplier.Name)
+
+	_, err := tx.NamedExecContext(ctx,
+		`insert into supplier(id, name, phone, email, address)
+        values (:id, :name, :phone, :email, :address)`,
+		supplier)
+
+	return err
+}
+
 func (repo *Repo) ViewPerson(ctx context.Context,
 	page uint, pageSize uint,
 	sortedBy, sortOrder string) (uint, []ClientPerson, error) {
@@ -350,6 +363,88 @@ func (repo *Repo) DeleteCustomer(ctx context.Context, id uuid.UUID) error {
 	return tx.Commit()
 }
 
+func (repo *Repo) ViewSupplier(ctx context.Context,
+	page int, pageSize int,
+	sortedBy, sortOrder string,
+) (int, []ClientSupplier, error) {
+
+	log.Println("ViewSupplier", page, pageSize, sortedBy, sortOrder)
+
+	var count int = 0
+	result := make([]ClientSupplier, 0)
+
+	err := repo.db.GetContext(ctx, &count, `select count(*) from supplier`)
+	if err != nil {
+		return count, result, err
+	}
+
+	query := fmt.Sprintf(
+		`select s.id, s.name, s.phone, s.email, s.address,
+        s.created_at, s.updated_at,
+        p.description
+        from supplier s
+        inner join party p on p.id = s.id
+        order by s.%s %s
+        limit ? offset ?`,
+		sortedBy, sortOrder)
+
+	err = repo.db.SelectContext(ctx, &result,
+		repo.db.Rebind(query), pageSize, page*pageSize)
+	return count, result, err
+}
+
+func (repo *Repo) UpdateSupplier(
+	ctx context.Context, supplier ClientSupplier) error {
+
+	log.Println("UpdateSupplier", supplier.Id,
+		supplier.Description, supplier.Name)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `update supplier set name = :name, phone = :phone,
+        email = :email, address = :address where id = :id`
+	_, err = tx.NamedExecContext(ctx, query, supplier)
+	if err != nil {
+		return err
+	}
+
+	query = `update party set description = :description where id = :id`
+	_, err = tx.NamedExecContext(ctx, query, supplier)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *Repo) DeleteSupplier(ctx context.Context, id uuid.UUID) error {
+	log.Println("DeleteSupplier", id)
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "delete from supplier where id = ?"
+	_, err = tx.Exec(repo.db.Rebind(query), id)
+	if err != nil {
+		return err
+	}
+
+	query = "delete from party where id = ?"
+	_, err = tx.Exec(repo.db.Rebind(query), id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func (repo *Repo) SelectSimplePersonWithFullName(
 	ctx context.Context, fullName string,
 ) ([]SimplePerson, error) {
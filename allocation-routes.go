@@ -0,0 +1,17 @@
+package main
+
+func AllocationRoutes(root *Root) {
+	root.GetAuthenticated(
+		"/api/allocation/view-strategy",
+		root.allocation.ViewStrategyHandler)
+
+	root.PostAuthorized(
+		"/api/allocation/update-warehouse-strategy",
+		"VIEW_EDIT_FACILITY",
+		root.allocation.UpdateWarehouseStrategyHandler)
+
+	root.PostAuthorized(
+		"/api/allocation/update-product-strategy",
+		"VIEW_EDIT_PRODUCT",
+		root.allocation.UpdateProductStrategyHandler)
+}
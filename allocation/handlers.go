@@ -0,0 +1,101 @@
+package allocation
+
+import (
+	"baseweb/basic"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type Root struct {
+	repo *Repo
+}
+
+func InitRoot(repo *Repo) *Root {
+	return &Root{
+		repo: repo,
+	}
+}
+
+var bodyError = errors.New("body constraints violation")
+
+func (root *Root) ViewStrategyHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	strategies, err := root.repo.SelectStrategy(ctx)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		StrategyList []Strategy `json:"strategyList"`
+	}
+
+	res := Response{
+		StrategyList: strategies,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) UpdateWarehouseStrategyHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		WarehouseId uuid.UUID `json:"warehouseId"`
+		StrategyId  int16     `json:"strategyId"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	if !ValidStrategy(req.StrategyId) {
+		return bodyError
+	}
+
+	err = root.repo.UpdateWarehouseStrategy(ctx, req.WarehouseId, req.StrategyId)
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) UpdateProductStrategyHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	// a null strategyId removes the override,
+	// the product then follows its warehouse
+	type Request struct {
+		ProductId  int64  `json:"productId"`
+		StrategyId *int16 `json:"strategyId"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	if req.StrategyId != nil && !ValidStrategy(*req.StrategyId) {
+		return bodyError
+	}
+
+	err = root.repo.UpdateProductStrategy(ctx, req.ProductId, req.StrategyId)
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
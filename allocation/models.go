@@ -0,0 +1,43 @@
+package allocation
+
+import (
+	"baseweb/basic"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	FifoStrategy       int16 = 1
+	FefoStrategy       int16 = 2
+	LifoStrategy       int16 = 3
+	LowestCostStrategy int16 = 4
+)
+
+type Strategy struct {
+	Id   int16  `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+}
+
+type Candidate struct {
+	InventoryItemId int64            `json:"inventoryItemId" db:"id"`
+	LocationId      *int             `json:"locationId" db:"location_id"`
+	LocationCode    basic.NullString `json:"locationCode" db:"location_code"`
+	QuantityOnHand  decimal.Decimal  `json:"quantityOnHand" db:"quantity_on_hand"`
+	UnitCost        decimal.Decimal  `json:"unitCost" db:"unit_cost"`
+	CurrencyUomId   string           `json:"currencyUomId" db:"currency_uom_id"`
+	LotNumber       basic.NullString `json:"lotNumber" db:"lot_number"`
+	ExpiryDate      basic.NullTime   `json:"expiryDate" db:"expiry_date"`
+	CreatedAt       time.Time        `json:"createdAt" db:"created_at"`
+}
+
+type Allocation struct {
+	InventoryItemId int64            `json:"inventoryItemId"`
+	LocationId      *int             `json:"locationId"`
+	LocationCode    basic.NullString `json:"locationCode"`
+	Quantity        decimal.Decimal  `json:"quantity"`
+	UnitCost        decimal.Decimal  `json:"unitCost"`
+	CurrencyUomId   string           `json:"currencyUomId"`
+	LotNumber       basic.NullString `json:"lotNumber"`
+	ExpiryDate      basic.NullTime   `json:"expiryDate"`
+}
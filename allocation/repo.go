@@ -0,0 +1,134 @@
+package allocation
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func InitRepo(db *sqlx.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+var ErrStrategy = errors.New("unknown allocation strategy")
+
+func (repo *Repo) SelectStrategy(ctx context.Context) ([]Strategy, error) {
+	log.Println("SelectStrategy")
+
+	result := make([]Strategy, 0)
+	query := `select id, name from allocation_strategy order by id`
+	err := repo.db.SelectContext(ctx, &result, query)
+	return result, err
+}
+
+func (repo *Repo) UpdateWarehouseStrategy(
+	ctx context.Context, warehouseId uuid.UUID, strategyId int16) error {
+
+	log.Println("UpdateWarehouseStrategy", warehouseId, strategyId)
+
+	query := repo.db.Rebind(`
+        update facility_warehouse set allocation_strategy_id = ?
+        where id = ?
+        `)
+	_, err := repo.db.ExecContext(ctx, query, strategyId, warehouseId)
+	return err
+}
+
+func (repo *Repo) UpdateProductStrategy(
+	ctx context.Context, productId int64, strategyId *int16) error {
+
+	log.Println("UpdateProductStrategy", productId, strategyId)
+
+	query := repo.db.Rebind(`
+        update product set allocation_strategy_id = ?
+        where id = ?
+        `)
+	_, err := repo.db.ExecContext(ctx, query, strategyId, productId)
+	return err
+}
+
+// ResolveStrategy returns the strategy of the product when it has one,
+// otherwise the strategy of the warehouse.
+func ResolveStrategy(
+	ctx context.Context, q sqlx.ExtContext,
+	warehouseId uuid.UUID, productId int64) (int16, error) {
+
+	var strategyId int16
+	query := q.Rebind(`
+        select coalesce(p.allocation_strategy_id, fw.allocation_strategy_id)
+        from facility_warehouse fw, product p
+        where fw.id = ? and p.id = ?
+        `)
+	err := sqlx.GetContext(ctx, q, &strategyId, query, warehouseId, productId)
+	return strategyId, err
+}
+
+// SelectCandidate returns the items of a product on hand in a warehouse
+// in the order the strategy picks them, expired lots and quarantined
+// items are never picked. Inside a transaction that is going to take
+// stock out, forUpdate locks the rows.
+func SelectCandidate(
+	ctx context.Context, q sqlx.ExtContext,
+	warehouseId uuid.UUID, productId int64,
+	strategyId int16, forUpdate bool) ([]Candidate, error) {
+
+	log.Println("SelectCandidate", warehouseId, productId, strategyId)
+
+	result := make([]Candidate, 0)
+
+	orderBy, ok := orderByStrategy[strategyId]
+	if !ok {
+		return result, ErrStrategy
+	}
+
+	query := `select i.id, i.location_id, l.code as location_code,
+        i.quantity_on_hand, i.unit_cost, i.currency_uom_id,
+        i.lot_number, i.expiry_date, i.created_at
+        from inventory_item i
+            left join warehouse_location l on l.id = i.location_id
+        where i.product_id = ? and i.warehouse_id = ?
+        and i.quantity_on_hand > 0
+        and (i.expiry_date is null or i.expiry_date >= current_date)
+        and (l.id is null or not l.quarantine)
+        order by ` + orderBy
+	if forUpdate {
+		query += " for update of i"
+	}
+	query = q.Rebind(query)
+
+	err := sqlx.SelectContext(ctx, q, &result, query, productId, warehouseId)
+	return result, err
+}
+
+// AllocateProduct resolves the strategy for the product in the warehouse
+// and allocates quantity from its items.
+func AllocateProduct(
+	ctx context.Context, q sqlx.ExtContext,
+	warehouseId uuid.UUID, productId int64,
+	quantity decimal.Decimal, forUpdate bool) (
+	int16, []Allocation, decimal.Decimal, error) {
+
+	strategyId, err := ResolveStrategy(ctx, q, warehouseId, productId)
+	if err != nil {
+		return strategyId, nil, quantity, err
+	}
+
+	candidates, err := SelectCandidate(ctx, q,
+		warehouseId, productId, strategyId, forUpdate)
+	if err != nil {
+		return strategyId, nil, quantity, err
+	}
+
+	allocations, shortage := Allocate(candidates, quantity)
+	return strategyId, allocations, shortage, nil
+}
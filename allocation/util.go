@@ -0,0 +1,51 @@
+package allocation
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// orderByStrategy holds how each strategy ranks the items of a product,
+// the first items in this order are the first to leave the warehouse.
+var orderByStrategy = map[int16]string{
+	FifoStrategy:       "i.created_at, i.id",
+	FefoStrategy:       "i.expiry_date nulls last, i.created_at, i.id",
+	LifoStrategy:       "i.created_at desc, i.id desc",
+	LowestCostStrategy: "i.unit_cost, i.created_at, i.id",
+}
+
+func ValidStrategy(strategyId int16) bool {
+	_, ok := orderByStrategy[strategyId]
+	return ok
+}
+
+// Allocate takes quantity out of the candidates in their order and
+// returns the allocations along with the quantity that could not be covered.
+func Allocate(
+	candidates []Candidate,
+	quantity decimal.Decimal) ([]Allocation, decimal.Decimal) {
+
+	result := make([]Allocation, 0)
+	for _, c := range candidates {
+		if !quantity.IsPositive() {
+			break
+		}
+		if !c.QuantityOnHand.IsPositive() {
+			continue
+		}
+
+		allocated := decimal.Min(c.QuantityOnHand, quantity)
+
+		result = append(result, Allocation{
+			InventoryItemId: c.InventoryItemId,
+			LocationId:      c.LocationId,
+			LocationCode:    c.LocationCode,
+			Quantity:        allocated,
+			UnitCost:        c.UnitCost,
+			CurrencyUomId:   c.CurrencyUomId,
+			LotNumber:       c.LotNumber,
+			ExpiryDate:      c.ExpiryDate,
+		})
+		quantity = quantity.Sub(allocated)
+	}
+	return result, quantity
+}
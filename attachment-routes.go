@@ -0,0 +1,21 @@
+package main
+
+func AttachmentRoutes(root *Root) {
+	root.PostIdempotent(
+		"/api/attachment/upload-attachment",
+		"VIEW_EDIT_PRODUCT",
+		root.attachment.UploadAttachmentHandler)
+
+	root.GetAuthenticated(
+		"/api/attachment/view-attachment",
+		root.attachment.ViewAttachmentHandler)
+
+	root.GetAuthenticated(
+		"/api/attachment/get-attachment/{attachmentId}",
+		root.attachment.GetAttachmentHandler)
+
+	root.PostAuthorized(
+		"/api/attachment/delete-attachment",
+		"VIEW_EDIT_PRODUCT",
+		root.attachment.DeleteAttachmentHandler)
+}
@@ -0,0 +1,279 @@
+package attachment
+
+import (
+	"baseweb/basic"
+	"baseweb/security"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+type Root struct {
+	repo    *Repo
+	storage Storage
+}
+
+func InitRoot(repo *Repo, storage Storage) *Root {
+	return &Root{
+		repo:    repo,
+		storage: storage,
+	}
+}
+
+var ErrContentType = errors.New("unsupported content type")
+var ErrEntityNotFound = errors.New("entity not found")
+
+func (root *Root) UploadAttachmentHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+	r.Body = http.MaxBytesReader(w, r.Body, MAX_ATTACHMENT_SIZE+(1<<20))
+	err := r.ParseMultipartForm(1 << 20)
+	if err != nil {
+		var sizeErr *http.MaxBytesError
+		if errors.As(err, &sizeErr) || err == multipart.ErrMessageTooLarge {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		return nil
+	}
+
+	entityType := r.FormValue("entityType")
+	entityId := r.FormValue("entityId")
+
+	exists, err := root.repo.EntityExists(ctx, entityType, entityId)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrEntityNotFound
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if header.Size > MAX_ATTACHMENT_SIZE {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return nil
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(file, MAX_ATTACHMENT_SIZE+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > MAX_ATTACHMENT_SIZE {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return nil
+	}
+
+	// the content type sent by the client is not trusted
+	contentType := http.DetectContentType(data)
+	ext, ok := allowedContentTypes[contentType]
+	if !ok {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return nil
+	}
+
+	if isImage(contentType) {
+		err = checkImage(data)
+		if err == ErrImageSize {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return nil
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return nil
+		}
+	}
+
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return err
+	}
+
+	attachment := Attachment{
+		Id:          id,
+		EntityType:  entityType,
+		EntityId:    entityId,
+		FileName:    header.Filename,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		StorageKey:  fmt.Sprintf("%s/%s%s", entityType, id, ext),
+		CreatedBy:   userLogin.Id,
+	}
+
+	err = root.storage.Save(ctx, attachment.StorageKey, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	if isImage(contentType) {
+		thumbnail, err := makeThumbnail(data)
+		if err != nil {
+			log.Println("makeThumbnail", id, err)
+		} else {
+			key := fmt.Sprintf("%s/%s_thumb.png", entityType, id)
+			err = root.storage.Save(ctx, key, bytes.NewReader(thumbnail))
+			if err != nil {
+				root.storage.Remove(ctx, attachment.StorageKey)
+				return err
+			}
+			attachment.ThumbnailKey.Valid = true
+			attachment.ThumbnailKey.String = key
+		}
+	}
+
+	err = root.repo.InsertAttachment(ctx, attachment)
+	if err != nil {
+		root.storage.Remove(ctx, attachment.StorageKey)
+		if attachment.ThumbnailKey.Valid {
+			root.storage.Remove(ctx, attachment.ThumbnailKey.String)
+		}
+		return err
+	}
+
+	type Response struct {
+		Id uuid.UUID `json:"id"`
+	}
+
+	res := Response{
+		Id: id,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewAttachmentHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	entityType := query.Get("entityType")
+	if _, ok := entityTables[entityType]; !ok {
+		return ErrEntityType
+	}
+
+	attachments, err := root.repo.SelectAttachment(ctx,
+		entityType, query.Get("entityId"))
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		AttachmentList []ClientAttachment `json:"attachmentList"`
+	}
+
+	res := Response{
+		AttachmentList: attachments,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) GetAttachmentHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	id, err := uuid.Parse(vars["attachmentId"])
+	if err != nil {
+		return err
+	}
+
+	attachment, err := root.repo.GetAttachment(ctx, id)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	key := attachment.StorageKey
+	contentType := attachment.ContentType
+	if r.URL.Query().Get("thumbnail") == "true" {
+		if !attachment.ThumbnailKey.Valid {
+			w.WriteHeader(http.StatusNotFound)
+			return nil
+		}
+		key = attachment.ThumbnailKey.String
+		contentType = "image/png"
+	}
+
+	file, err := root.storage.Open(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	// non ASCII names are sent as filename* (RFC 6266)
+	disposition := mime.FormatMediaType("inline",
+		map[string]string{"filename": attachment.FileName})
+	if disposition == "" {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Disposition", disposition)
+	if key == attachment.StorageKey {
+		w.Header().Set("Content-Length",
+			strconv.FormatInt(attachment.Size, 10))
+	}
+
+	_, err = io.Copy(w, file)
+	return err
+}
+
+func (root *Root) DeleteAttachmentHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Id uuid.UUID `json:"id"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	attachment, err := root.repo.DeleteAttachment(ctx, req.Id)
+	if err != nil {
+		return err
+	}
+
+	err = root.storage.Remove(ctx, attachment.StorageKey)
+	if err != nil {
+		return err
+	}
+
+	if attachment.ThumbnailKey.Valid {
+		err = root.storage.Remove(ctx, attachment.ThumbnailKey.String)
+		if err != nil {
+			return err
+		}
+	}
+
+	return basic.ReturnOk(w)
+}
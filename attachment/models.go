@@ -0,0 +1,33 @@
+package attachment
+
+import (
+	"baseweb/basic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Attachment struct {
+	Id           uuid.UUID        `json:"id" db:"id"`
+	EntityType   string           `json:"entityType" db:"entity_type"`
+	EntityId     string           `json:"entityId" db:"entity_id"`
+	FileName     string           `json:"fileName" db:"file_name"`
+	ContentType  string           `json:"contentType" db:"content_type"`
+	Size         int64            `json:"size" db:"size"`
+	StorageKey   string           `json:"-" db:"storage_key"`
+	ThumbnailKey basic.NullString `json:"-" db:"thumbnail_key"`
+	CreatedBy    uuid.UUID        `json:"-" db:"created_by_user_login_id"`
+}
+
+type ClientAttachment struct {
+	Id           uuid.UUID `json:"id" db:"id"`
+	EntityType   string    `json:"entityType" db:"entity_type"`
+	EntityId     string    `json:"entityId" db:"entity_id"`
+	FileName     string    `json:"fileName" db:"file_name"`
+	ContentType  string    `json:"contentType" db:"content_type"`
+	Size         int64     `json:"size" db:"size"`
+	HasThumbnail bool      `json:"hasThumbnail" db:"has_thumbnail"`
+	CreatedBy    string    `json:"createdBy" db:"created_by"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+}
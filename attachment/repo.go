@@ -0,0 +1,123 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func InitRepo(db *sqlx.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+var ErrEntityType = errors.New("unsupported entity type")
+
+func (repo *Repo) EntityExists(ctx context.Context,
+	entityType, entityId string) (bool, error) {
+
+	log.Println("EntityExists", entityType, entityId)
+
+	table, ok := entityTables[entityType]
+	if !ok {
+		return false, ErrEntityType
+	}
+
+	var count int
+	query := fmt.Sprintf(`select count(*) from %s where id::text = ?`, table)
+	query = repo.db.Rebind(query)
+	err := repo.db.GetContext(ctx, &count, query, entityId)
+
+	return count > 0, err
+}
+
+func (repo *Repo) InsertAttachment(
+	ctx context.Context, attachment Attachment) error {
+
+	log.Println("InsertAttachment", attachment.Id, attachment.EntityType,
+		attachment.EntityId, attachment.FileName,
+		attachment.ContentType, attachment.Size)
+
+	query := `insert into attachment(
+        id, entity_type, entity_id,
+        file_name, content_type, size,
+        storage_key, thumbnail_key,
+        created_by_user_login_id)
+        values (:id, :entity_type, :entity_id,
+        :file_name, :content_type, :size,
+        :storage_key, :thumbnail_key,
+        :created_by_user_login_id)`
+
+	_, err := repo.db.NamedExecContext(ctx, query, attachment)
+	return err
+}
+
+func (repo *Repo) SelectAttachment(ctx context.Context,
+	entityType, entityId string) ([]ClientAttachment, error) {
+
+	log.Println("SelectAttachment", entityType, entityId)
+
+	result := make([]ClientAttachment, 0)
+
+	query := repo.db.Rebind(`
+        select a.id, a.entity_type, a.entity_id,
+        a.file_name, a.content_type, a.size,
+        a.thumbnail_key is not null as has_thumbnail,
+        u.username as created_by,
+        a.created_at, a.updated_at
+        from attachment a
+            inner join user_login u on u.id = a.created_by_user_login_id
+        where a.entity_type = ? and a.entity_id = ?
+        order by a.created_at
+        `)
+	err := repo.db.SelectContext(ctx, &result, query, entityType, entityId)
+
+	return result, err
+}
+
+func (repo *Repo) GetAttachment(
+	ctx context.Context, id uuid.UUID) (Attachment, error) {
+
+	log.Println("GetAttachment", id)
+
+	attachment := Attachment{}
+
+	query := repo.db.Rebind(`
+        select id, entity_type, entity_id,
+        file_name, content_type, size,
+        storage_key, thumbnail_key,
+        created_by_user_login_id
+        from attachment where id = ?
+        `)
+	err := repo.db.GetContext(ctx, &attachment, query, id)
+
+	return attachment, err
+}
+
+func (repo *Repo) DeleteAttachment(
+	ctx context.Context, id uuid.UUID) (Attachment, error) {
+
+	log.Println("DeleteAttachment", id)
+
+	attachment := Attachment{}
+
+	query := repo.db.Rebind(`
+        delete from attachment where id = ?
+        returning id, entity_type, entity_id,
+        file_name, content_type, size,
+        storage_key, thumbnail_key,
+        created_by_user_login_id
+        `)
+	err := repo.db.GetContext(ctx, &attachment, query, id)
+
+	return attachment, err
+}
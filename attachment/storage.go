@@ -0,0 +1,66 @@
+package attachment
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+type Storage interface {
+	Save(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Remove(ctx context.Context, key string) error
+}
+
+type LocalStorage struct {
+	dir string
+}
+
+func NewLocalStorage(dir string) *LocalStorage {
+	return &LocalStorage{
+		dir: dir,
+	}
+}
+
+func (s *LocalStorage) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(filepath.Clean("/"+key)))
+}
+
+func (s *LocalStorage) Save(
+	ctx context.Context, key string, r io.Reader) error {
+
+	path := s.path(key)
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+
+	return f.Close()
+}
+
+func (s *LocalStorage) Open(
+	ctx context.Context, key string) (io.ReadCloser, error) {
+
+	return os.Open(s.path(key))
+}
+
+func (s *LocalStorage) Remove(ctx context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package attachment
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+
+	_ "image/gif"
+	_ "image/jpeg"
+)
+
+const MAX_ATTACHMENT_SIZE = 10 << 20
+
+const THUMBNAIL_SIZE = 200
+
+// MAX_IMAGE_PIXELS bounds the decoded size of an uploaded image, a small
+// file can claim dimensions that take gigabytes to decode.
+const MAX_IMAGE_PIXELS = 5000 * 5000
+
+var ErrImageSize = errors.New("image dimensions too large")
+
+var entityTables = map[string]string{
+	"product": "product",
+}
+
+var allowedContentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"application/pdf": ".pdf",
+}
+
+func isImage(contentType string) bool {
+	return contentType == "image/jpeg" ||
+		contentType == "image/png" ||
+		contentType == "image/gif"
+}
+
+// checkImage reads only the image header and rejects dimensions above
+// MAX_IMAGE_PIXELS.
+func checkImage(data []byte) error {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if config.Width <= 0 || config.Height <= 0 ||
+		config.Width > MAX_IMAGE_PIXELS/config.Height {
+		return ErrImageSize
+	}
+	return nil
+}
+
+// makeThumbnail scales the image down so that its longest side is at
+// most THUMBNAIL_SIZE, averaging the source pixels covered by each
+// destination pixel.
+func makeThumbnail(data []byte) ([]byte, error) {
+	err := checkImage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+
+	thumbWidth, thumbHeight := width, height
+	if width > THUMBNAIL_SIZE || height > THUMBNAIL_SIZE {
+		if width >= height {
+			thumbWidth = THUMBNAIL_SIZE
+			thumbHeight = height * THUMBNAIL_SIZE / width
+		} else {
+			thumbHeight = THUMBNAIL_SIZE
+			thumbWidth = width * THUMBNAIL_SIZE / height
+		}
+	}
+	if thumbWidth < 1 {
+		thumbWidth = 1
+	}
+	if thumbHeight < 1 {
+		thumbHeight = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	for y := 0; y < thumbHeight; y++ {
+		y0 := bounds.Min.Y + y*height/thumbHeight
+		y1 := bounds.Min.Y + (y+1)*height/thumbHeight
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < thumbWidth; x++ {
+			x0 := bounds.Min.X + x*width/thumbWidth
+			x1 := bounds.Min.X + (x+1)*width/thumbWidth
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(b / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+
+	buf := bytes.Buffer{}
+	err = png.Encode(&buf, dst)
+	return buf.Bytes(), err
+}
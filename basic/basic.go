@@ -1,8 +1,10 @@
 package basic
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 )
@@ -19,6 +21,30 @@ func ReturnOk(w http.ResponseWriter) error {
 	return json.NewEncoder(w).Encode(okResponse)
 }
 
+// ReturnConflict answers with 409 Conflict when err is one of conflicts,
+// errors that come from the state of the data rather than from a
+// failure. Any other error is handed back to the caller.
+func ReturnConflict(
+	w http.ResponseWriter, err error, conflicts ...error) error {
+
+	conflict := false
+	for _, e := range conflicts {
+		if errors.Is(err, e) {
+			conflict = true
+		}
+	}
+	if !conflict {
+		return err
+	}
+
+	type Response struct {
+		Error string `json:"error"`
+	}
+
+	w.WriteHeader(http.StatusConflict)
+	return json.NewEncoder(w).Encode(Response{Error: err.Error()})
+}
+
 type Handler func(http.ResponseWriter, *http.Request) error
 
 type NullString struct {
@@ -88,3 +114,50 @@ func LowerBound(array []int, value int) int {
 
 	return first
 }
+
+// RunEvery calls f right away and then every interval until ctx is
+// done.
+func RunEvery(ctx context.Context, interval time.Duration, f func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		f()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ParseTime accepts either a full RFC3339 time or a plain date meaning
+// the start of that day, an empty value gives def.
+func ParseTime(value string, def time.Time) (time.Time, error) {
+	if value == "" {
+		return def, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// ParseAsOf is ParseTime for the end of a period, a plain date means the
+// end of that day so the whole day is included.
+func ParseAsOf(value string, def time.Time) (time.Time, error) {
+	if value == "" {
+		return def, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return day, err
+	}
+	return day.AddDate(0, 0, 1), nil
+}
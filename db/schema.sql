@@ -33,6 +33,20 @@ BEGIN
 END
 $$ LANGUAGE 'plpgsql';
 
+CREATE OR REPLACE FUNCTION append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END
+$$ LANGUAGE 'plpgsql';
+
+DROP TABLE IF EXISTS idempotency_key;
+DROP TABLE IF EXISTS standing_order_occurrence;
+DROP TABLE IF EXISTS standing_order_item;
+DROP TABLE IF EXISTS standing_order_day;
+DROP TABLE IF EXISTS standing_order;
+DROP TABLE IF EXISTS attachment;
+
 DROP TABLE IF EXISTS salesman_checkin_history;
 DROP TABLE IF EXISTS sales_route_detail;
 DROP TABLE IF EXISTS sales_route_planning_period;
@@ -41,21 +55,62 @@ DROP TABLE IF EXISTS sales_route_config;
 DROP TABLE IF EXISTS day_of_week;
 DROP TABLE IF EXISTS salesman;
 
+DROP TABLE IF EXISTS inventory_transaction;
+DROP TABLE IF EXISTS inventory_transaction_type;
 DROP TABLE IF EXISTS inventory_item_detail;
-
+DROP TABLE IF EXISTS replenishment_suggestion;
+DROP TABLE IF EXISTS reorder_setting;
+DROP TABLE IF EXISTS inventory_adjustment;
+DROP TABLE IF EXISTS cycle_count_item;
+DROP TABLE IF EXISTS cycle_count;
+DROP TABLE IF EXISTS cycle_count_status;
+DROP TABLE IF EXISTS adjustment_reason;
+DROP TABLE IF EXISTS transfer_order_item_detail;
+DROP TABLE IF EXISTS transfer_order_item;
+DROP TABLE IF EXISTS transfer_order;
+DROP TABLE IF EXISTS transfer_order_status;
+
+DROP TABLE IF EXISTS payment;
+DROP TABLE IF EXISTS payment_method;
+DROP TABLE IF EXISTS invoice_item;
+DROP TABLE IF EXISTS invoice;
+DROP TABLE IF EXISTS invoice_sequence;
+DROP TABLE IF EXISTS invoice_status;
+DROP TABLE IF EXISTS customer_credit;
+DROP TABLE IF EXISTS return_order_item;
+DROP TABLE IF EXISTS return_order;
+DROP TABLE IF EXISTS return_order_status;
+DROP TABLE IF EXISTS stock_reservation;
+DROP TABLE IF EXISTS reservation_status;
+DROP TABLE IF EXISTS sale_order_promotion;
 DROP TABLE IF EXISTS sale_order_item;
+DROP TABLE IF EXISTS sale_order_status_history;
 DROP TABLE IF EXISTS sale_order;
 DROP TABLE IF EXISTS sale_order_status;
 
+DROP TABLE IF EXISTS promotion;
+DROP TABLE IF EXISTS promotion_type;
+
 DROP TABLE IF EXISTS warehouse_product_statistics;
 DROP TABLE IF EXISTS inventory_item;
+DROP TABLE IF EXISTS goods_receipt;
+DROP TABLE IF EXISTS purchase_order_item;
+DROP TABLE IF EXISTS purchase_order;
+DROP TABLE IF EXISTS purchase_order_status;
+DROP TABLE IF EXISTS warehouse_location;
+DROP TABLE IF EXISTS location_type;
 DROP TABLE IF EXISTS facility_customer;
 DROP TABLE IF EXISTS facility_warehouse;
 DROP TABLE IF EXISTS facility;
 DROP TABLE IF EXISTS facility_type;
 
+DROP TABLE IF EXISTS product_barcode;
 DROP TABLE IF EXISTS product_price;
 DROP TABLE IF EXISTS product;
+DROP TABLE IF EXISTS product_status;
+DROP TABLE IF EXISTS allocation_strategy;
+DROP TABLE IF EXISTS tax_rate;
+DROP TABLE IF EXISTS tax_category;
 
 DROP TABLE IF EXISTS security_group_permission;
 DROP TABLE IF EXISTS user_login_security_group;
@@ -63,6 +118,7 @@ DROP TABLE IF EXISTS security_permission;
 DROP TABLE IF EXISTS security_group;
 DROP TABLE IF EXISTS user_login;
 
+DROP TABLE IF EXISTS supplier;
 DROP TABLE IF EXISTS customer;
 DROP TABLE IF EXISTS person;
 DROP TABLE IF EXISTS gender;
@@ -149,6 +205,19 @@ CREATE TRIGGER customer_name_tsvector
 CREATE INDEX idx_customer_name_tsvector ON customer 
     USING gin(name_tsvector);
 
+CREATE TABLE supplier(
+    id UUID PRIMARY KEY REFERENCES party(id),
+    name VARCHAR NOT NULL,
+    phone VARCHAR NOT NULL DEFAULT '',
+    email VARCHAR NOT NULL DEFAULT '',
+    address VARCHAR NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER supplier_updated_at BEFORE UPDATE ON
+    supplier FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
 CREATE TABLE user_login(
     id UUID PRIMARY KEY DEFAULT uuid_generate_v1(),
     username VARCHAR NOT NULL UNIQUE,
@@ -187,6 +256,44 @@ CREATE TABLE security_group_permission(
     PRIMARY KEY (security_group_id, security_permission_id)
 );
 
+CREATE TABLE tax_category(
+    id SMALLINT PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE tax_rate(
+    id SERIAL PRIMARY KEY,
+    tax_category_id SMALLINT NOT NULL REFERENCES tax_category(id),
+    rate DECIMAL NOT NULL,
+
+    created_by_user_login_id UUID NOT NULL REFERENCES user_login(id),
+
+    effective_from TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expired_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER tax_rate_updated_at BEFORE UPDATE ON
+    tax_rate FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
+ALTER TABLE tax_rate
+    ADD CONSTRAINT rate_non_neg CHECK (rate >= 0);
+
+CREATE TABLE allocation_strategy(
+    id SMALLINT PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE product_status(
+    id SMALLINT PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
 CREATE TABLE product(
     id SERIAL PRIMARY KEY,
     name VARCHAR NOT NULL UNIQUE,
@@ -198,6 +305,10 @@ CREATE TABLE product(
 
     unit_uom_id VARCHAR NOT NULL REFERENCES unit_uom(id),
 
+    tax_category_id SMALLINT NOT NULL REFERENCES tax_category(id) DEFAULT 1,
+    product_status_id SMALLINT NOT NULL REFERENCES product_status(id) DEFAULT 2,
+    allocation_strategy_id SMALLINT REFERENCES allocation_strategy(id),
+
     created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
     updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
@@ -224,6 +335,15 @@ CREATE TABLE product_price(
 CREATE TRIGGER product_price_updated_at BEFORE UPDATE ON
     product_price FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
 
+CREATE TABLE product_barcode(
+    code VARCHAR PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES product(id),
+    created_by_user_login_id UUID NOT NULL REFERENCES user_login(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_product_barcode_product ON product_barcode(product_id);
+
 
 CREATE TABLE facility_type(
     id SMALLINT PRIMARY KEY,
@@ -246,6 +366,8 @@ CREATE TRIGGER facility_updated_at BEFORE UPDATE ON
 
 CREATE TABLE facility_warehouse(
     id UUID PRIMARY KEY REFERENCES facility(id),
+    allocation_strategy_id SMALLINT NOT NULL
+        REFERENCES allocation_strategy(id) DEFAULT 1,
 
     created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
     updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
@@ -254,6 +376,32 @@ CREATE TABLE facility_warehouse(
 CREATE TRIGGER facility_warehouse_updated_at BEFORE UPDATE ON
     facility_warehouse FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
 
+CREATE TABLE location_type(
+    id SMALLINT PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE warehouse_location(
+    id SERIAL PRIMARY KEY,
+    warehouse_id UUID NOT NULL REFERENCES facility_warehouse(id),
+    parent_id INTEGER REFERENCES warehouse_location(id),
+    location_type_id SMALLINT NOT NULL REFERENCES location_type(id),
+
+    code VARCHAR NOT NULL,
+    name VARCHAR NOT NULL DEFAULT '',
+    capacity DECIMAL,
+    quarantine BOOL NOT NULL DEFAULT FALSE,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT u_warehouse_location_code UNIQUE (warehouse_id, code)
+);
+
+CREATE TRIGGER warehouse_location_updated_at BEFORE UPDATE ON
+    warehouse_location FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
 CREATE TABLE facility_customer(
     id UUID PRIMARY KEY REFERENCES facility(id),
     customer_id UUID NOT NULL REFERENCES customer(id),
@@ -268,6 +416,63 @@ CREATE TRIGGER facility_customer_updated_at BEFORE UPDATE ON
     facility_customer FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
 
 
+CREATE TABLE purchase_order_status(
+    id SMALLINT PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE purchase_order(
+    id BIGSERIAL PRIMARY KEY,
+    supplier_id UUID NOT NULL REFERENCES supplier(id),
+    warehouse_id UUID NOT NULL REFERENCES facility_warehouse(id),
+    currency_uom_id VARCHAR NOT NULL REFERENCES currency_uom(id),
+    expected_date DATE NOT NULL,
+
+    purchase_order_status_id SMALLINT NOT NULL
+        REFERENCES purchase_order_status(id),
+    created_by_user_login_id UUID NOT NULL REFERENCES user_login(id),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER purchase_order_updated_at BEFORE UPDATE ON
+    purchase_order FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
+CREATE TABLE purchase_order_item(
+    purchase_order_id BIGINT REFERENCES purchase_order(id),
+    purchase_order_seq SMALLINT,
+
+    product_id INTEGER NOT NULL REFERENCES product(id),
+    quantity DECIMAL NOT NULL CHECK (quantity > 0),
+    unit_cost DECIMAL NOT NULL,
+    expected_date DATE NOT NULL,
+
+    received_quantity DECIMAL NOT NULL DEFAULT 0,
+    completed_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT pk_purchase_order_item
+        PRIMARY KEY (purchase_order_id, purchase_order_seq),
+    CONSTRAINT purchase_order_item_received
+        CHECK (received_quantity <= quantity)
+);
+
+CREATE TRIGGER purchase_order_item_updated_at BEFORE UPDATE ON
+    purchase_order_item FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
+CREATE TABLE goods_receipt(
+    id BIGSERIAL PRIMARY KEY,
+    warehouse_id UUID NOT NULL REFERENCES facility_warehouse(id),
+    file_name VARCHAR NOT NULL DEFAULT '',
+    line_count INTEGER NOT NULL,
+    created_by_user_login_id UUID NOT NULL REFERENCES user_login(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
 CREATE TABLE inventory_item(
     id BIGSERIAL PRIMARY KEY,
     product_id INTEGER NOT NULL REFERENCES product(id),
@@ -277,14 +482,38 @@ CREATE TABLE inventory_item(
     quantity_on_hand DECIMAL NOT NULL,
     unit_cost DECIMAL NOT NULL,
     currency_uom_id VARCHAR NOT NULL REFERENCES currency_uom(id),
+    lot_number VARCHAR,
+    expiry_date DATE,
+
+    location_id INTEGER REFERENCES warehouse_location(id),
+
+    purchase_order_id BIGINT,
+    purchase_order_seq SMALLINT,
+    goods_receipt_id BIGINT REFERENCES goods_receipt(id),
+    return_order_id BIGINT,
+    return_order_seq SMALLINT,
 
     created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT fk_inventory_item_purchase_order_item
+        FOREIGN KEY (purchase_order_id, purchase_order_seq)
+        REFERENCES purchase_order_item(purchase_order_id, purchase_order_seq)
 );
 
 CREATE INDEX idx_inventory_item_export ON
     inventory_item(product_id, warehouse_id, quantity_on_hand);
 
+CREATE INDEX idx_inventory_item_location ON
+    inventory_item(location_id);
+
+CREATE INDEX idx_inventory_item_expiry ON
+    inventory_item(warehouse_id, expiry_date)
+    WHERE expiry_date IS NOT NULL;
+
+CREATE INDEX idx_inventory_item_lot ON
+    inventory_item(product_id, lot_number);
+
 CREATE TRIGGER inventory_item_updated_at BEFORE UPDATE ON
     inventory_item FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
 
@@ -296,6 +525,7 @@ CREATE TABLE warehouse_product_statistics(
     quantity_total DECIMAL NOT NULL,
     quantity_on_hand DECIMAL NOT NULL,
     quantity_available DECIMAL NOT NULL,
+    quantity_in_transit DECIMAL NOT NULL DEFAULT 0,
 
     created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
     updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
@@ -315,6 +545,43 @@ ALTER TABLE warehouse_product_statistics
 ALTER TABLE warehouse_product_statistics
     ADD CONSTRAINT quantity_available_non_neg CHECK (quantity_available >= 0);
 
+ALTER TABLE warehouse_product_statistics
+    ADD CONSTRAINT quantity_in_transit_non_neg CHECK (quantity_in_transit >= 0);
+
+CREATE TABLE promotion_type(
+    id SMALLINT PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE promotion(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR NOT NULL,
+    promotion_type_id SMALLINT NOT NULL REFERENCES promotion_type(id),
+
+    product_id INTEGER REFERENCES product(id),
+    customer_id UUID REFERENCES customer(id),
+    customer_store_id UUID REFERENCES facility_customer(id),
+
+    percentage DECIMAL NOT NULL DEFAULT 0,
+    amount DECIMAL NOT NULL DEFAULT 0,
+    currency_uom_id VARCHAR REFERENCES currency_uom(id),
+    buy_quantity DECIMAL NOT NULL DEFAULT 0,
+    get_quantity DECIMAL NOT NULL DEFAULT 0,
+    min_order_amount DECIMAL NOT NULL DEFAULT 0,
+
+    created_by_user_login_id UUID NOT NULL REFERENCES user_login(id),
+
+    effective_from TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expired_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER promotion_updated_at BEFORE UPDATE ON
+    promotion FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
 CREATE TABLE sale_order_status(
     id SMALLINT PRIMARY KEY,
     name VARCHAR NOT NULL UNIQUE,
@@ -332,6 +599,11 @@ CREATE TABLE sale_order(
 
     sale_order_status_id SMALLINT NOT NULL REFERENCES sale_order_status(id),
 
+    discount_amount DECIMAL NOT NULL DEFAULT 0,
+    net_amount DECIMAL NOT NULL DEFAULT 0,
+    tax_amount DECIMAL NOT NULL DEFAULT 0,
+    gross_amount DECIMAL NOT NULL DEFAULT 0,
+
     created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
     updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
@@ -339,6 +611,22 @@ CREATE TABLE sale_order(
 CREATE TRIGGER sale_order_updated_at BEFORE UPDATE ON
     sale_order FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
 
+CREATE TABLE sale_order_status_history(
+    id BIGSERIAL PRIMARY KEY,
+    sale_order_id BIGINT NOT NULL REFERENCES sale_order(id),
+    from_status_id SMALLINT REFERENCES sale_order_status(id),
+    to_status_id SMALLINT NOT NULL REFERENCES sale_order_status(id),
+    changed_by_user_login_id UUID REFERENCES user_login(id),
+    reason VARCHAR,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_sale_order_status_history_order ON
+    sale_order_status_history(sale_order_id, changed_at);
+
+CREATE TRIGGER sale_order_status_history_append_only BEFORE UPDATE OR DELETE ON
+    sale_order_status_history FOR EACH ROW EXECUTE PROCEDURE append_only();
+
 CREATE TABLE sale_order_item(
     sale_order_id BIGINT REFERENCES sale_order(id),
     sale_order_seq SMALLINT,
@@ -346,16 +634,80 @@ CREATE TABLE sale_order_item(
     product_price_id UUID NOT NULL REFERENCES product_price(id),
     quantity DECIMAL NOT NULL,
     exported BOOL NOT NULL DEFAULT FALSE,
+    shipped_quantity DECIMAL NOT NULL DEFAULT 0,
+    backorder_of_seq SMALLINT,
+    backorder_ready_at TIMESTAMPTZ,
+    removed_at TIMESTAMPTZ,
+
+    discount_amount DECIMAL NOT NULL DEFAULT 0,
+    tax_rate DECIMAL NOT NULL DEFAULT 0,
+    tax_amount DECIMAL NOT NULL DEFAULT 0,
 
     created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
     updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
 
-    CONSTRAINT pk_sale_order_item PRIMARY KEY (sale_order_id, sale_order_seq)
+    CONSTRAINT pk_sale_order_item PRIMARY KEY (sale_order_id, sale_order_seq),
+    CONSTRAINT fk_sale_order_item_backorder_of
+        FOREIGN KEY (sale_order_id, backorder_of_seq)
+        REFERENCES sale_order_item(sale_order_id, sale_order_seq),
+    CHECK (shipped_quantity <= quantity)
 );
 
 CREATE TRIGGER sale_order_item_updated_at BEFORE UPDATE ON
     sale_order_item FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
 
+CREATE TABLE sale_order_promotion(
+    id BIGSERIAL PRIMARY KEY,
+    sale_order_id BIGINT NOT NULL REFERENCES sale_order(id),
+    sale_order_seq SMALLINT,
+    promotion_id INTEGER NOT NULL REFERENCES promotion(id),
+    discount_amount DECIMAL NOT NULL,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT fk_sale_order_promotion_sale_order_item
+        FOREIGN KEY (sale_order_id, sale_order_seq)
+        REFERENCES sale_order_item(sale_order_id, sale_order_seq)
+);
+
+CREATE TABLE reservation_status(
+    id SMALLINT PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE stock_reservation(
+    sale_order_id BIGINT,
+    sale_order_seq SMALLINT,
+
+    warehouse_id UUID NOT NULL REFERENCES facility_warehouse(id),
+    product_id INTEGER NOT NULL REFERENCES product(id),
+    quantity DECIMAL NOT NULL CHECK (quantity > 0),
+
+    reservation_status_id SMALLINT NOT NULL
+        REFERENCES reservation_status(id),
+    expires_at TIMESTAMPTZ,
+    released_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT pk_stock_reservation
+        PRIMARY KEY (sale_order_id, sale_order_seq),
+    CONSTRAINT fk_stock_reservation_sale_order_item
+        FOREIGN KEY (sale_order_id, sale_order_seq)
+        REFERENCES sale_order_item(sale_order_id, sale_order_seq)
+);
+
+CREATE INDEX idx_stock_reservation_warehouse_product ON
+    stock_reservation(warehouse_id, product_id, reservation_status_id);
+
+CREATE INDEX idx_stock_reservation_expires ON
+    stock_reservation(expires_at) WHERE reservation_status_id = 1;
+
+CREATE TRIGGER stock_reservation_updated_at BEFORE UPDATE ON
+    stock_reservation FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
 CREATE TABLE inventory_item_detail(
     id BIGSERIAL PRIMARY KEY,
     inventory_item_id BIGINT NOT NULL REFERENCES inventory_item(id),
@@ -376,6 +728,385 @@ CREATE TABLE inventory_item_detail(
 CREATE TRIGGER inventory_item_detail_updated_at BEFORE UPDATE ON
     inventory_item_detail FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
 
+CREATE TABLE return_order_status(
+    id SMALLINT PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE return_order(
+    id BIGSERIAL PRIMARY KEY,
+    sale_order_id BIGINT NOT NULL REFERENCES sale_order(id),
+    customer_id UUID NOT NULL REFERENCES customer(id),
+    return_order_status_id SMALLINT NOT NULL
+        REFERENCES return_order_status(id),
+    reason VARCHAR NOT NULL DEFAULT '',
+    created_by_user_login_id UUID NOT NULL REFERENCES user_login(id),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER return_order_updated_at BEFORE UPDATE ON
+    return_order FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
+CREATE TABLE return_order_item(
+    return_order_id BIGINT REFERENCES return_order(id),
+    return_order_seq SMALLINT,
+
+    sale_order_id BIGINT NOT NULL,
+    sale_order_seq SMALLINT NOT NULL,
+    quantity DECIMAL NOT NULL CHECK (quantity > 0),
+    received_quantity DECIMAL NOT NULL DEFAULT 0,
+    unit_credit DECIMAL NOT NULL,
+    currency_uom_id VARCHAR NOT NULL REFERENCES currency_uom(id),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT pk_return_order_item
+        PRIMARY KEY (return_order_id, return_order_seq),
+    CONSTRAINT fk_return_order_item_sale_order_item
+        FOREIGN KEY (sale_order_id, sale_order_seq)
+        REFERENCES sale_order_item(sale_order_id, sale_order_seq),
+    CHECK (received_quantity <= quantity)
+);
+
+CREATE TRIGGER return_order_item_updated_at BEFORE UPDATE ON
+    return_order_item FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
+CREATE TABLE customer_credit(
+    id BIGSERIAL PRIMARY KEY,
+    customer_id UUID NOT NULL REFERENCES customer(id),
+    return_order_id BIGINT REFERENCES return_order(id),
+    amount DECIMAL NOT NULL,
+    currency_uom_id VARCHAR NOT NULL REFERENCES currency_uom(id),
+    created_by_user_login_id UUID REFERENCES user_login(id),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_customer_credit_customer ON customer_credit(customer_id);
+
+CREATE TABLE invoice_status(
+    id SMALLINT PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE invoice_sequence(
+    invoice_year SMALLINT PRIMARY KEY,
+    last_number INTEGER NOT NULL
+);
+
+CREATE TABLE invoice(
+    id BIGSERIAL PRIMARY KEY,
+    invoice_year SMALLINT NOT NULL,
+    invoice_number INTEGER NOT NULL,
+
+    sale_order_id BIGINT NOT NULL REFERENCES sale_order(id),
+    customer_id UUID NOT NULL REFERENCES customer(id),
+    currency_uom_id VARCHAR NOT NULL REFERENCES currency_uom(id),
+    invoice_status_id SMALLINT NOT NULL REFERENCES invoice_status(id),
+
+    discount_amount DECIMAL NOT NULL DEFAULT 0,
+    net_amount DECIMAL NOT NULL DEFAULT 0,
+    tax_amount DECIMAL NOT NULL DEFAULT 0,
+    gross_amount DECIMAL NOT NULL DEFAULT 0,
+    paid_amount DECIMAL NOT NULL DEFAULT 0,
+
+    issued_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    due_at TIMESTAMPTZ NOT NULL,
+    created_by_user_login_id UUID NOT NULL REFERENCES user_login(id),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT uq_invoice_number UNIQUE (invoice_year, invoice_number),
+    CHECK (paid_amount <= gross_amount)
+);
+
+CREATE INDEX idx_invoice_customer ON invoice(customer_id, due_at);
+
+CREATE INDEX idx_invoice_sale_order ON invoice(sale_order_id);
+
+CREATE TRIGGER invoice_updated_at BEFORE UPDATE ON
+    invoice FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
+CREATE TABLE invoice_item(
+    invoice_id BIGINT REFERENCES invoice(id),
+    invoice_seq SMALLINT,
+
+    sale_order_id BIGINT NOT NULL,
+    sale_order_seq SMALLINT NOT NULL,
+    product_price_id UUID NOT NULL REFERENCES product_price(id),
+    quantity DECIMAL NOT NULL CHECK (quantity > 0),
+    unit_price DECIMAL NOT NULL,
+
+    discount_amount DECIMAL NOT NULL DEFAULT 0,
+    net_amount DECIMAL NOT NULL,
+    tax_rate DECIMAL NOT NULL DEFAULT 0,
+    tax_amount DECIMAL NOT NULL DEFAULT 0,
+    gross_amount DECIMAL NOT NULL,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT pk_invoice_item PRIMARY KEY (invoice_id, invoice_seq),
+    CONSTRAINT fk_invoice_item_sale_order_item
+        FOREIGN KEY (sale_order_id, sale_order_seq)
+        REFERENCES sale_order_item(sale_order_id, sale_order_seq)
+);
+
+CREATE INDEX idx_invoice_item_sale_order_item ON
+    invoice_item(sale_order_id, sale_order_seq);
+
+CREATE TRIGGER invoice_item_append_only BEFORE UPDATE OR DELETE ON
+    invoice_item FOR EACH ROW EXECUTE PROCEDURE append_only();
+
+CREATE TABLE payment_method(
+    id SMALLINT PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE payment(
+    id BIGSERIAL PRIMARY KEY,
+    invoice_id BIGINT NOT NULL REFERENCES invoice(id),
+    payment_method_id SMALLINT NOT NULL REFERENCES payment_method(id),
+    amount DECIMAL NOT NULL CHECK (amount > 0),
+    reference VARCHAR NOT NULL DEFAULT '',
+    paid_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_by_user_login_id UUID NOT NULL REFERENCES user_login(id),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_payment_invoice ON payment(invoice_id, paid_at);
+
+CREATE TRIGGER payment_append_only BEFORE UPDATE OR DELETE ON
+    payment FOR EACH ROW EXECUTE PROCEDURE append_only();
+
+CREATE TABLE transfer_order_status(
+    id SMALLINT PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE transfer_order(
+    id BIGSERIAL PRIMARY KEY,
+    from_warehouse_id UUID NOT NULL REFERENCES facility_warehouse(id),
+    to_warehouse_id UUID NOT NULL REFERENCES facility_warehouse(id),
+    created_by_user_login_id UUID NOT NULL REFERENCES user_login(id),
+
+    transfer_order_status_id SMALLINT NOT NULL
+        REFERENCES transfer_order_status(id),
+
+    shipped_at TIMESTAMPTZ,
+    received_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT transfer_order_different_warehouse
+        CHECK (from_warehouse_id <> to_warehouse_id)
+);
+
+CREATE TRIGGER transfer_order_updated_at BEFORE UPDATE ON
+    transfer_order FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
+CREATE TABLE transfer_order_item(
+    transfer_order_id BIGINT REFERENCES transfer_order(id),
+    transfer_order_seq SMALLINT,
+
+    product_id INTEGER NOT NULL REFERENCES product(id),
+    quantity DECIMAL NOT NULL CHECK (quantity > 0),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT pk_transfer_order_item
+        PRIMARY KEY (transfer_order_id, transfer_order_seq)
+);
+
+CREATE TRIGGER transfer_order_item_updated_at BEFORE UPDATE ON
+    transfer_order_item FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
+CREATE TABLE transfer_order_item_detail(
+    id BIGSERIAL PRIMARY KEY,
+    transfer_order_id BIGINT NOT NULL,
+    transfer_order_seq SMALLINT NOT NULL,
+
+    inventory_item_id BIGINT NOT NULL REFERENCES inventory_item(id),
+    quantity DECIMAL NOT NULL,
+    unit_cost DECIMAL NOT NULL,
+    currency_uom_id VARCHAR NOT NULL REFERENCES currency_uom(id),
+
+    received_inventory_item_id BIGINT REFERENCES inventory_item(id),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT fk_transfer_order_item_detail_transfer_order_item
+        FOREIGN KEY (transfer_order_id, transfer_order_seq)
+        REFERENCES transfer_order_item(transfer_order_id, transfer_order_seq)
+);
+
+CREATE TRIGGER transfer_order_item_detail_updated_at BEFORE UPDATE ON
+    transfer_order_item_detail FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
+CREATE TABLE adjustment_reason(
+    id SMALLINT PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE cycle_count_status(
+    id SMALLINT PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE cycle_count(
+    id BIGSERIAL PRIMARY KEY,
+    warehouse_id UUID NOT NULL REFERENCES facility_warehouse(id),
+    location_id INTEGER REFERENCES warehouse_location(id),
+
+    cycle_count_status_id SMALLINT NOT NULL
+        REFERENCES cycle_count_status(id),
+
+    created_by_user_login_id UUID NOT NULL REFERENCES user_login(id),
+    approved_by_user_login_id UUID REFERENCES user_login(id),
+    posted_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER cycle_count_updated_at BEFORE UPDATE ON
+    cycle_count FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
+CREATE TABLE cycle_count_item(
+    cycle_count_id BIGINT REFERENCES cycle_count(id),
+    cycle_count_seq INTEGER,
+
+    inventory_item_id BIGINT NOT NULL REFERENCES inventory_item(id),
+    product_id INTEGER NOT NULL REFERENCES product(id),
+    location_id INTEGER REFERENCES warehouse_location(id),
+
+    system_quantity DECIMAL NOT NULL,
+    counted_quantity DECIMAL CHECK (counted_quantity >= 0),
+    adjustment_reason_id SMALLINT REFERENCES adjustment_reason(id),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT pk_cycle_count_item
+        PRIMARY KEY (cycle_count_id, cycle_count_seq)
+);
+
+CREATE TRIGGER cycle_count_item_updated_at BEFORE UPDATE ON
+    cycle_count_item FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
+CREATE TABLE inventory_adjustment(
+    id BIGSERIAL PRIMARY KEY,
+    inventory_item_id BIGINT NOT NULL REFERENCES inventory_item(id),
+    product_id INTEGER NOT NULL REFERENCES product(id),
+    warehouse_id UUID NOT NULL REFERENCES facility_warehouse(id),
+
+    quantity DECIMAL NOT NULL,
+    adjustment_reason_id SMALLINT NOT NULL REFERENCES adjustment_reason(id),
+    note VARCHAR NOT NULL DEFAULT '',
+
+    cycle_count_id BIGINT REFERENCES cycle_count(id),
+    created_by_user_login_id UUID NOT NULL REFERENCES user_login(id),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_inventory_adjustment_warehouse ON
+    inventory_adjustment(warehouse_id, created_at);
+
+CREATE TABLE inventory_transaction_type(
+    id SMALLINT PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE inventory_transaction(
+    id BIGSERIAL PRIMARY KEY,
+    inventory_transaction_type_id SMALLINT NOT NULL
+        REFERENCES inventory_transaction_type(id),
+
+    warehouse_id UUID NOT NULL REFERENCES facility_warehouse(id),
+    product_id INTEGER NOT NULL REFERENCES product(id),
+    inventory_item_id BIGINT REFERENCES inventory_item(id),
+
+    quantity_on_hand_diff DECIMAL NOT NULL DEFAULT 0,
+    quantity_available_diff DECIMAL NOT NULL DEFAULT 0,
+    quantity_in_transit_diff DECIMAL NOT NULL DEFAULT 0,
+
+    sale_order_id BIGINT,
+    sale_order_seq SMALLINT,
+    transfer_order_id BIGINT REFERENCES transfer_order(id),
+    purchase_order_id BIGINT,
+    purchase_order_seq SMALLINT,
+    inventory_adjustment_id BIGINT REFERENCES inventory_adjustment(id),
+
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT fk_inventory_transaction_sale_order_item
+        FOREIGN KEY (sale_order_id, sale_order_seq)
+        REFERENCES sale_order_item(sale_order_id, sale_order_seq),
+    CONSTRAINT fk_inventory_transaction_purchase_order_item
+        FOREIGN KEY (purchase_order_id, purchase_order_seq)
+        REFERENCES purchase_order_item(purchase_order_id, purchase_order_seq)
+);
+
+CREATE INDEX idx_inventory_transaction_warehouse_product ON
+    inventory_transaction(warehouse_id, product_id, occurred_at);
+
+CREATE INDEX idx_inventory_transaction_item ON
+    inventory_transaction(inventory_item_id);
+
+CREATE TRIGGER inventory_transaction_append_only BEFORE UPDATE OR DELETE ON
+    inventory_transaction FOR EACH ROW EXECUTE PROCEDURE append_only();
+
+CREATE TABLE reorder_setting(
+    warehouse_id UUID REFERENCES facility_warehouse(id),
+    product_id INTEGER REFERENCES product(id),
+
+    min_quantity DECIMAL NOT NULL DEFAULT 0,
+    max_quantity DECIMAL NOT NULL,
+    reorder_point DECIMAL,
+    lead_time_days INTEGER NOT NULL DEFAULT 7,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT pk_reorder_setting PRIMARY KEY (warehouse_id, product_id),
+    CONSTRAINT reorder_setting_min_max CHECK (min_quantity <= max_quantity)
+);
+
+CREATE TRIGGER reorder_setting_updated_at BEFORE UPDATE ON
+    reorder_setting FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
+CREATE TABLE replenishment_suggestion(
+    warehouse_id UUID REFERENCES facility_warehouse(id),
+    product_id INTEGER REFERENCES product(id),
+
+    quantity_available DECIMAL NOT NULL,
+    quantity_in_transit DECIMAL NOT NULL,
+    quantity_on_order DECIMAL NOT NULL,
+    daily_velocity DECIMAL NOT NULL,
+    reorder_point DECIMAL NOT NULL,
+    suggested_quantity DECIMAL NOT NULL,
+
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT pk_replenishment_suggestion
+        PRIMARY KEY (warehouse_id, product_id)
+);
+
 CREATE TABLE salesman(
     id UUID PRIMARY KEY REFERENCES user_login(id),
     created_by_user_login_id UUID NOT NULL REFERENCES user_login(id),
@@ -447,3 +1178,104 @@ CREATE TABLE salesman_checkin_history(
     created_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
+CREATE TABLE attachment(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v1(),
+    entity_type VARCHAR NOT NULL,
+    entity_id VARCHAR NOT NULL,
+
+    file_name VARCHAR NOT NULL,
+    content_type VARCHAR NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key VARCHAR NOT NULL,
+    thumbnail_key VARCHAR,
+
+    created_by_user_login_id UUID NOT NULL REFERENCES user_login(id),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_attachment_entity ON
+    attachment(entity_type, entity_id);
+
+CREATE TRIGGER attachment_updated_at BEFORE UPDATE ON
+    attachment FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
+CREATE TABLE idempotency_key(
+    user_login_id UUID REFERENCES user_login(id),
+    idempotency_key VARCHAR,
+
+    request_method VARCHAR NOT NULL,
+    request_path VARCHAR NOT NULL,
+    request_hash VARCHAR NOT NULL,
+
+    status_code SMALLINT,
+    response_body BYTEA,
+    completed_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT pk_idempotency_key
+        PRIMARY KEY (user_login_id, idempotency_key)
+);
+
+CREATE INDEX idx_idempotency_key_created ON idempotency_key(created_at);
+
+CREATE TABLE standing_order(
+    id SERIAL PRIMARY KEY,
+    customer_id UUID NOT NULL REFERENCES customer(id),
+    warehouse_id UUID NOT NULL REFERENCES facility_warehouse(id),
+    ship_to_address VARCHAR NOT NULL,
+    ship_to_facility_customer_id UUID REFERENCES facility_customer(id),
+
+    repeat_week SMALLINT NOT NULL CHECK (repeat_week > 0),
+    start_date DATE NOT NULL,
+    end_date DATE,
+    is_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    generated_through DATE,
+
+    created_by_user_login_id UUID NOT NULL REFERENCES user_login(id),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CHECK (end_date is null or start_date <= end_date)
+);
+
+CREATE TRIGGER standing_order_updated_at BEFORE UPDATE ON
+    standing_order FOR EACH ROW EXECUTE PROCEDURE updated_at_column();
+
+CREATE TABLE standing_order_day(
+    standing_order_id INT REFERENCES standing_order(id),
+    day SMALLINT REFERENCES day_of_week(day),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT pk_standing_order_day PRIMARY KEY (standing_order_id, day)
+);
+
+CREATE TABLE standing_order_item(
+    standing_order_id INT REFERENCES standing_order(id),
+    standing_order_seq SMALLINT,
+
+    product_id INTEGER NOT NULL REFERENCES product(id),
+    quantity DECIMAL NOT NULL CHECK (quantity > 0),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT pk_standing_order_item
+        PRIMARY KEY (standing_order_id, standing_order_seq)
+);
+
+CREATE TABLE standing_order_occurrence(
+    standing_order_id INT REFERENCES standing_order(id),
+    occurrence_date DATE,
+
+    sale_order_id BIGINT REFERENCES sale_order(id),
+    failure VARCHAR,
+    skipped_at TIMESTAMPTZ,
+    skipped_by_user_login_id UUID REFERENCES user_login(id),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT pk_standing_order_occurrence
+        PRIMARY KEY (standing_order_id, occurrence_date)
+);
@@ -1,7 +1,8 @@
 INSERT INTO party_type(id, name)
 VALUES
     (1, 'PERSON'),
-    (2, 'CUSTOMER');
+    (2, 'CUSTOMER'),
+    (3, 'SUPPLIER');
 
 INSERT INTO party(id, party_type_id, created_by_user_login_id, updated_by_user_login_id)
 VALUES
@@ -89,9 +90,44 @@ VALUES
     (1, 'WAREHOUSE'),
     (2, 'CUSTOMER_STORE');
 
+INSERT INTO location_type(id, name)
+VALUES
+    (1, 'ZONE'),
+    (2, 'AISLE'),
+    (3, 'RACK'),
+    (4, 'BIN');
+
 INSERT INTO currency_uom(id)
 VALUES ('vnd'), ('usd');
 
+INSERT INTO allocation_strategy(id, name)
+VALUES
+    (1, 'FIFO'),
+    (2, 'FEFO'),
+    (3, 'LIFO'),
+    (4, 'LOWEST_COST');
+
+INSERT INTO product_status(id, name)
+VALUES
+    (1, 'DRAFT'),
+    (2, 'ACTIVE'),
+    (3, 'DISCONTINUED'),
+    (4, 'OBSOLETE');
+
+INSERT INTO tax_category(id, name)
+VALUES
+    (1, 'STANDARD'),
+    (2, 'REDUCED'),
+    (3, 'ZERO_RATED'),
+    (4, 'EXEMPT');
+
+INSERT INTO tax_rate(tax_category_id, rate, created_by_user_login_id, effective_from)
+VALUES
+    (1, 10, 'e66c1e0c-59fb-11ea-b26b-14dda9bea6d7', '2020-01-01'),
+    (2, 5, 'e66c1e0c-59fb-11ea-b26b-14dda9bea6d7', '2020-01-01'),
+    (3, 0, 'e66c1e0c-59fb-11ea-b26b-14dda9bea6d7', '2020-01-01'),
+    (4, 0, 'e66c1e0c-59fb-11ea-b26b-14dda9bea6d7', '2020-01-01');
+
 INSERT INTO sale_order_status(id, name)
 VALUES
     (1, 'CREATED'),
@@ -100,5 +136,84 @@ VALUES
     (4, 'COMPLETED'),
     (5, 'CANCELED');
 
+INSERT INTO return_order_status(id, name)
+VALUES
+    (1, 'OPEN'),
+    (2, 'PARTIALLY_RECEIVED'),
+    (3, 'RECEIVED'),
+    (4, 'CANCELED');
+
+INSERT INTO invoice_status(id, name)
+VALUES
+    (1, 'ISSUED'),
+    (2, 'PARTIALLY_PAID'),
+    (3, 'PAID');
+
+INSERT INTO payment_method(id, name)
+VALUES
+    (1, 'CASH'),
+    (2, 'TRANSFER');
+
+INSERT INTO purchase_order_status(id, name)
+VALUES
+    (1, 'OPEN'),
+    (2, 'PARTIALLY_RECEIVED'),
+    (3, 'RECEIVED'),
+    (4, 'CANCELED');
+
+INSERT INTO transfer_order_status(id, name)
+VALUES
+    (1, 'DRAFT'),
+    (2, 'SHIPPED'),
+    (3, 'IN_TRANSIT'),
+    (4, 'RECEIVED'),
+    (5, 'CANCELED');
+
+INSERT INTO adjustment_reason(id, name)
+VALUES
+    (1, 'COUNT_DISCREPANCY'),
+    (2, 'DAMAGED'),
+    (3, 'EXPIRED'),
+    (4, 'LOST'),
+    (5, 'FOUND');
+
+INSERT INTO inventory_transaction_type(id, name)
+VALUES
+    (1, 'RECEIPT'),
+    (2, 'ORDER_PLACED'),
+    (3, 'ORDER_CANCELED'),
+    (4, 'EXPORT'),
+    (5, 'TRANSFER_SHIPPED'),
+    (6, 'TRANSFER_IN_TRANSIT'),
+    (7, 'TRANSFER_RECEIVED'),
+    (8, 'ADJUSTMENT'),
+    (9, 'RESERVATION_EXPIRED'),
+    (10, 'ORDER_CHANGED'),
+    (11, 'RETURN_RECEIVED'),
+    (12, 'QUARANTINE_RELEASED'),
+    (13, 'RETURN_RESTORED');
+
+INSERT INTO reservation_status(id, name)
+VALUES
+    (1, 'ACTIVE'),
+    (2, 'CONSUMED'),
+    (3, 'RELEASED'),
+    (4, 'EXPIRED');
+
+INSERT INTO cycle_count_status(id, name)
+VALUES
+    (1, 'OPEN'),
+    (2, 'SUBMITTED'),
+    (3, 'POSTED'),
+    (4, 'CANCELED');
+
+INSERT INTO promotion_type(id, name)
+VALUES
+    (1, 'PERCENTAGE'),
+    (2, 'FIXED_AMOUNT'),
+    (3, 'BUY_X_GET_Y'),
+    (4, 'ORDER_PERCENTAGE'),
+    (5, 'ORDER_FIXED_AMOUNT');
+
 INSERT INTO day_of_week(day)
 VALUES (0), (1), (2), (3), (4), (5), (6);
@@ -0,0 +1,18 @@
+package main
+
+func DocumentRoutes(root *Root) {
+	root.GetAuthorized(
+		"/api/document/view-delivery-note",
+		"EXPORT",
+		root.document.ViewDeliveryNoteHandler)
+
+	root.GetAuthorized(
+		"/api/document/view-pick-list",
+		"EXPORT",
+		root.document.ViewPickListHandler)
+
+	root.GetAuthorized(
+		"/api/document/view-invoice",
+		"VIEW_EDIT_ORDER",
+		root.document.ViewInvoiceHandler)
+}
@@ -0,0 +1,165 @@
+package document
+
+import (
+	"baseweb/export"
+	"baseweb/invoice"
+	"baseweb/order"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type Root struct {
+	orderRepo   *order.Repo
+	exportRepo  *export.Repo
+	invoiceRepo *invoice.Repo
+	templates   *Templates
+	renderer    Renderer
+}
+
+func InitRoot(
+	orderRepo *order.Repo,
+	exportRepo *export.Repo,
+	invoiceRepo *invoice.Repo,
+	templates *Templates,
+	renderer Renderer) *Root {
+
+	return &Root{
+		orderRepo:   orderRepo,
+		exportRepo:  exportRepo,
+		invoiceRepo: invoiceRepo,
+		templates:   templates,
+		renderer:    renderer,
+	}
+}
+
+var queryError = errors.New("query constraints violation")
+
+// write renders the template name with data as PDF, or as HTML when
+// asked with format=html. Nothing is written until the document is
+// complete, so a failed render still answers with an error.
+func (root *Root) write(
+	w http.ResponseWriter, r *http.Request,
+	name, fileName string, data interface{}) error {
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "pdf"
+	}
+	if format != "pdf" && format != "html" {
+		return queryError
+	}
+
+	var html bytes.Buffer
+	err := root.templates.Execute(&html, name, data)
+	if err != nil {
+		return err
+	}
+
+	if format == "html" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, err = html.WriteTo(w)
+		return err
+	}
+
+	var pdf bytes.Buffer
+	err = root.renderer.Render(r.Context(), &html, &pdf)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("inline; filename=%s", strconv.Quote(fileName+".pdf")))
+	w.Header().Set("Content-Length", strconv.Itoa(pdf.Len()))
+	_, err = pdf.WriteTo(w)
+	return err
+}
+
+func (root *Root) ViewDeliveryNoteHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	saleOrderId, err := strconv.ParseInt(query.Get("saleOrderId"), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	saleOrder, items, err := root.orderRepo.GetSaleOrder(ctx, saleOrderId)
+	if err != nil {
+		return err
+	}
+
+	data := DeliveryNote{
+		Order:     saleOrder,
+		Items:     items,
+		PrintedAt: time.Now(),
+	}
+
+	return root.write(w, r, DeliveryNoteTemplate,
+		fmt.Sprintf("delivery-note-%d", saleOrderId), data)
+}
+
+func (root *Root) ViewPickListHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	saleOrderId, err := strconv.ParseInt(query.Get("saleOrderId"), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	saleOrder, _, err := root.orderRepo.GetSaleOrder(ctx, saleOrderId)
+	if err != nil {
+		return err
+	}
+
+	lines, err := root.exportRepo.ViewPickList(ctx, saleOrderId)
+	if err != nil {
+		return err
+	}
+
+	data := PickList{
+		Order:     saleOrder,
+		Lines:     lines,
+		PrintedAt: time.Now(),
+	}
+
+	return root.write(w, r, PickListTemplate,
+		fmt.Sprintf("pick-list-%d", saleOrderId), data)
+}
+
+func (root *Root) ViewInvoiceHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	id, err := strconv.ParseInt(query.Get("id"), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	inv, items, payments, err := root.invoiceRepo.GetInvoice(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	data := Invoice{
+		Invoice:    inv,
+		Items:      items,
+		Payments:   payments,
+		OpenAmount: inv.GrossAmount.Sub(inv.PaidAmount),
+		PrintedAt:  time.Now(),
+	}
+
+	return root.write(w, r, InvoiceTemplate,
+		fmt.Sprintf("invoice-%d", id), data)
+}
@@ -0,0 +1,36 @@
+package document
+
+import (
+	"baseweb/export"
+	"baseweb/invoice"
+	"baseweb/order"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DeliveryNoteTemplate = "delivery-note.html"
+	PickListTemplate     = "pick-list.html"
+	InvoiceTemplate      = "invoice.html"
+)
+
+type DeliveryNote struct {
+	Order     order.SaleOrder
+	Items     []order.SaleOrderItem
+	PrintedAt time.Time
+}
+
+type PickList struct {
+	Order     order.SaleOrder
+	Lines     []export.PickLine
+	PrintedAt time.Time
+}
+
+type Invoice struct {
+	Invoice    invoice.ClientInvoice
+	Items      []invoice.InvoiceItem
+	Payments   []invoice.Payment
+	OpenAmount decimal.Decimal
+	PrintedAt  time.Time
+}
@@ -0,0 +1,45 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// Renderer turns a rendered HTML document into PDF.
+type Renderer interface {
+	Render(ctx context.Context, html io.Reader, pdf io.Writer) error
+}
+
+// Wkhtmltopdf renders through the wkhtmltopdf command. The HTML is read
+// as UTF-8, so Vietnamese text comes out right as long as the fonts the
+// templates ask for are installed.
+type Wkhtmltopdf struct {
+	path string
+}
+
+func NewWkhtmltopdf(path string) *Wkhtmltopdf {
+	return &Wkhtmltopdf{
+		path: path,
+	}
+}
+
+func (r *Wkhtmltopdf) Render(
+	ctx context.Context, html io.Reader, pdf io.Writer) error {
+
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, r.path,
+		"--quiet", "--encoding", "utf-8", "--page-size", "A4", "-", "-")
+	cmd.Stdin = html
+	cmd.Stdout = pdf
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("wkhtmltopdf: %w: %s", err, stderr.String())
+	}
+	return nil
+}
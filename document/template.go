@@ -0,0 +1,75 @@
+package document
+
+import (
+	"html/template"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Templates are read from dir on every render, so a template changed on
+// disk is picked up without a restart. Every document is executed
+// through base.html, which defines the page around its content.
+type Templates struct {
+	dir string
+}
+
+func NewTemplates(dir string) *Templates {
+	return &Templates{
+		dir: dir,
+	}
+}
+
+var funcs = template.FuncMap{
+	"amount":   amount,
+	"quantity": quantity,
+	"date":     date,
+	"ordinal":  ordinal,
+}
+
+func (t *Templates) Execute(w io.Writer, name string, data interface{}) error {
+	tmpl, err := template.New("base.html").Funcs(funcs).ParseFiles(
+		filepath.Join(t.dir, "base.html"),
+		filepath.Join(t.dir, name))
+	if err != nil {
+		return err
+	}
+	return tmpl.ExecuteTemplate(w, "base", data)
+}
+
+// amount shows money with two decimals and its thousands grouped.
+func amount(d decimal.Decimal) string {
+	s := d.StringFixed(2)
+
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+
+	whole, fraction := s[:len(s)-3], s[len(s)-3:]
+	var b strings.Builder
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+
+	return sign + b.String() + fraction
+}
+
+func quantity(d decimal.Decimal) string {
+	return d.String()
+}
+
+func date(t time.Time) string {
+	return t.Format("02/01/2006")
+}
+
+// ordinal numbers rows from one for people reading the document.
+func ordinal(i int) int {
+	return i + 1
+}
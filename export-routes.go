@@ -25,9 +25,33 @@ func ExportRoutes(root *Root) {
 		root.order.ViewSingleSaleOrderHandler,
 	)
 
+	root.GetAuthorized(
+		"/api/export/preview-export-sale-order-item",
+		"EXPORT",
+		root.export.PreviewExportSaleOrderItemHandler,
+	)
+
+	root.GetAuthorized(
+		"/api/export/view-pick-list",
+		"EXPORT",
+		root.export.ViewPickListHandler,
+	)
+
 	root.PostAuthorized(
 		"/api/export/complete-sales-order",
 		"EXPORT",
 		root.export.CompleteSalesOrderHandler,
 	)
+
+	root.GetAuthorized(
+		"/api/export/view-backorder",
+		"EXPORT",
+		root.export.ViewBackorderHandler,
+	)
+
+	root.PostAuthorized(
+		"/api/export/fulfill-backorder",
+		"EXPORT",
+		root.export.FulfillBackorderHandler,
+	)
 }
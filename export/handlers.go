@@ -3,12 +3,20 @@ package export
 import (
 	"baseweb/basic"
 	"baseweb/order"
+	"baseweb/product"
+	"baseweb/security"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
+var bodyError = errors.New("body constraints violation")
+
 type Root struct {
 	repo *Repo
 }
@@ -19,15 +27,32 @@ func InitRoot(repo *Repo) *Root {
 	}
 }
 
+// returnExportError answers asking for more than the line holds with
+// 400 Bad Request and stock or order state getting in the way with 409
+// Conflict. Any other error is handed back to the caller.
+func returnExportError(w http.ResponseWriter, err error) error {
+	if errors.Is(err, ErrOverShipment) {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil
+	}
+	if errors.Is(err, ErrShortage) || errors.Is(err, ErrExported) {
+		return basic.ReturnConflict(w, err, ErrShortage, ErrExported)
+	}
+	return order.ReturnConflict(w, err)
+}
+
 func (root *Root) ExportSaleOrderItemHandler(
 	w http.ResponseWriter, r *http.Request) error {
 
 	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
 
 	type Request struct {
-		SaleOrderId   int64     `json:"saleOrderId" db:"sale_order_id"`
-		SaleOrderSeq  int       `json:"saleOrderSeq" db:"sale_order_seq"`
-		EffectiveFrom time.Time `json:"effectiveFrom" db:"effective_from"`
+		SaleOrderId   int64            `json:"saleOrderId" db:"sale_order_id"`
+		SaleOrderSeq  int              `json:"saleOrderSeq" db:"sale_order_seq"`
+		Quantity      *decimal.Decimal `json:"quantity"`
+		EffectiveFrom time.Time        `json:"effectiveFrom" db:"effective_from"`
+		Barcode       string           `json:"barcode"`
 	}
 
 	req := Request{}
@@ -36,13 +61,97 @@ func (root *Root) ExportSaleOrderItemHandler(
 		return err
 	}
 
-	err = root.repo.ExportSaleOrderItem(ctx,
-		req.SaleOrderId, req.SaleOrderSeq, req.EffectiveFrom)
+	if req.Quantity != nil && !req.Quantity.IsPositive() {
+		return bodyError
+	}
+
+	if req.EffectiveFrom.IsZero() {
+		req.EffectiveFrom = time.Now()
+	}
+
+	if req.Barcode != "" {
+		req.SaleOrderSeq, err = root.repo.FindSaleOrderSeqByBarcode(ctx,
+			req.SaleOrderId, req.Barcode)
+		if err == product.ErrBarcode {
+			w.WriteHeader(http.StatusBadRequest)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := root.repo.ExportSaleOrderItem(ctx,
+		req.SaleOrderId, req.SaleOrderSeq, req.Quantity,
+		req.EffectiveFrom, userLogin.Id)
+	if err != nil {
+		return returnExportError(w, err)
+	}
+
+	return json.NewEncoder(w).Encode(result)
+}
+
+func (root *Root) PreviewExportSaleOrderItemHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	saleOrderId, err := strconv.ParseInt(query.Get("saleOrderId"), 10, 64)
 	if err != nil {
 		return err
 	}
 
-	return basic.ReturnOk(w)
+	var saleOrderSeq int
+	barcode := query.Get("barcode")
+	if barcode != "" {
+		saleOrderSeq, err = root.repo.FindSaleOrderSeqByBarcode(ctx,
+			saleOrderId, barcode)
+	} else {
+		saleOrderSeq, err = strconv.Atoi(query.Get("saleOrderSeq"))
+	}
+	if err == product.ErrBarcode {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	preview, err := root.repo.PreviewExportSaleOrderItem(ctx,
+		saleOrderId, saleOrderSeq)
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(preview)
+}
+
+func (root *Root) ViewPickListHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	saleOrderId, err := strconv.ParseInt(query.Get("saleOrderId"), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	lines, err := root.repo.ViewPickList(ctx, saleOrderId)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		PickLineList []PickLine `json:"pickLineList"`
+	}
+
+	res := Response{
+		PickLineList: lines,
+	}
+
+	return json.NewEncoder(w).Encode(res)
 }
 
 func (root *Root) ViewExportableSalesOrderHandler(
@@ -99,6 +208,7 @@ func (root *Root) CompleteSalesOrderHandler(
 	w http.ResponseWriter, r *http.Request) error {
 
 	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
 
 	type Request struct {
 		Id int64 `json:"id"`
@@ -109,9 +219,9 @@ func (root *Root) CompleteSalesOrderHandler(
 		return err
 	}
 
-	err = root.repo.CompleteSalesOrder(ctx, req.Id)
+	err = root.repo.CompleteSalesOrder(ctx, req.Id, userLogin.Id)
 	if err != nil {
-		return err
+		return order.ReturnConflict(w, err)
 	}
 
 	return basic.ReturnOk(w)
@@ -166,3 +276,88 @@ func (root *Root) ViewCompletedSalesOrderHandler(
 
 	return json.NewEncoder(w).Encode(res)
 }
+
+func (root *Root) ViewBackorderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	var warehouseId *uuid.UUID
+	if query.Get("warehouseId") != "" {
+		id, err := uuid.Parse(query.Get("warehouseId"))
+		if err != nil {
+			return err
+		}
+		warehouseId = &id
+	}
+
+	ready := query.Get("ready") == "true"
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	count, backorders, err := root.repo.ViewBackorder(ctx,
+		warehouseId, ready, page, pageSize)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		BackorderCount int         `json:"backorderCount"`
+		BackorderList  []Backorder `json:"backorderList"`
+	}
+
+	res := Response{
+		BackorderCount: count,
+		BackorderList:  backorders,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) FulfillBackorderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+	type Request struct {
+		WarehouseId   uuid.UUID `json:"warehouseId"`
+		ProductId     *int64    `json:"productId"`
+		EffectiveFrom time.Time `json:"effectiveFrom"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	if req.EffectiveFrom.IsZero() {
+		req.EffectiveFrom = time.Now()
+	}
+
+	results, err := root.repo.FulfillBackorder(ctx,
+		req.WarehouseId, req.ProductId, req.EffectiveFrom, userLogin.Id)
+	if err != nil {
+		return returnExportError(w, err)
+	}
+
+	type Response struct {
+		ExportList []ExportResult `json:"exportList"`
+	}
+
+	res := Response{
+		ExportList: results,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
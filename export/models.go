@@ -1,6 +1,8 @@
 package export
 
 import (
+	"baseweb/allocation"
+	"baseweb/basic"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,3 +19,56 @@ type InventoryItemDetail struct {
 	CreatedAt        time.Time       `json:"createdAt" db:"created_at"`
 	UpdatedAt        time.Time       `json:"updatedAt" db:"updated_at"`
 }
+
+type Pick struct {
+	InventoryItemId int64            `json:"inventoryItemId"`
+	LocationId      *int             `json:"locationId"`
+	LocationCode    basic.NullString `json:"locationCode"`
+	LotNumber       basic.NullString `json:"lotNumber"`
+	ExpiryDate      basic.NullTime   `json:"expiryDate"`
+	Quantity        decimal.Decimal  `json:"quantity"`
+}
+
+type PickLine struct {
+	SaleOrderSeq int             `json:"saleOrderSeq" db:"sale_order_seq"`
+	ProductId    int64           `json:"productId" db:"product_id"`
+	ProductName  string          `json:"productName" db:"product_name"`
+	WarehouseId  uuid.UUID       `json:"warehouseId" db:"original_warehouse_id"`
+	Quantity     decimal.Decimal `json:"quantity" db:"quantity"`
+	Shortage     decimal.Decimal `json:"shortage" db:"-"`
+	PickList     []Pick          `json:"pickList" db:"-"`
+}
+
+type ExportPreview struct {
+	SaleOrderId    int64                   `json:"saleOrderId"`
+	SaleOrderSeq   int                     `json:"saleOrderSeq"`
+	ProductId      int64                   `json:"productId"`
+	WarehouseId    uuid.UUID               `json:"warehouseId"`
+	Quantity       decimal.Decimal         `json:"quantity"`
+	StrategyId     int16                   `json:"strategyId"`
+	AllocationList []allocation.Allocation `json:"allocationList"`
+	Shortage       decimal.Decimal         `json:"shortage"`
+}
+
+type ExportResult struct {
+	SaleOrderId       int64           `json:"saleOrderId"`
+	SaleOrderSeq      int             `json:"saleOrderSeq"`
+	ShippedQuantity   decimal.Decimal `json:"shippedQuantity"`
+	BackorderSeq      *int            `json:"backorderSeq"`
+	BackorderQuantity decimal.Decimal `json:"backorderQuantity"`
+}
+
+type Backorder struct {
+	SaleOrderId    int64           `json:"saleOrderId" db:"sale_order_id"`
+	SaleOrderSeq   int             `json:"saleOrderSeq" db:"sale_order_seq"`
+	BackorderOfSeq int             `json:"backorderOfSeq" db:"backorder_of_seq"`
+	WarehouseId    uuid.UUID       `json:"warehouseId" db:"original_warehouse_id"`
+	WarehouseName  string          `json:"warehouseName" db:"warehouse_name"`
+	Customer       string          `json:"customer" db:"customer"`
+	ProductId      int64           `json:"productId" db:"product_id"`
+	ProductName    string          `json:"productName" db:"product_name"`
+	Quantity       decimal.Decimal `json:"quantity" db:"quantity"`
+	QuantityOnHand decimal.Decimal `json:"quantityOnHand" db:"quantity_on_hand"`
+	ReadyAt        *time.Time      `json:"readyAt" db:"backorder_ready_at"`
+	CreatedAt      time.Time       `json:"createdAt" db:"created_at"`
+}
@@ -1,7 +1,11 @@
 package export
 
 import (
+	"baseweb/allocation"
+	"baseweb/ledger"
 	"baseweb/order"
+	"baseweb/product"
+	"baseweb/reservation"
 	"context"
 	"database/sql"
 	"errors"
@@ -26,73 +30,115 @@ func InitRepo(db *sqlx.DB) *Repo {
 
 var ErrExported = errors.New("sale_order_item exported")
 
+var ErrShortage = errors.New("not enough unexpired stock on hand")
+
+var ErrOverShipment = errors.New("quantity exceeds sale_order_item quantity")
+
+// ErrOpenItems keeps an order with items, backorders among them, still
+// to ship from being completed.
+var ErrOpenItems = fmt.Errorf("%w: sale order has items left to export",
+	order.ErrStatusTransition)
+
+// ExportSaleOrderItem ships quantity of an order item, all of it when
+// quantity is nil. Whatever the stock on hand cannot cover is left on
+// a backorder line.
 func (repo *Repo) ExportSaleOrderItem(
 	ctx context.Context, saleOrderId int64,
-	saleOrderSeq int, effectiveFrom time.Time) error {
+	saleOrderSeq int, quantity *decimal.Decimal,
+	effectiveFrom time.Time,
+	userLoginId uuid.UUID) (ExportResult, error) {
 
 	log.Println("ExportSaleOrderItem", saleOrderId,
-		saleOrderSeq, effectiveFrom)
+		saleOrderSeq, quantity, effectiveFrom)
 
 	tx, err := repo.db.BeginTxx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelRepeatableRead,
 	})
 	if err != nil {
-		return err
+		return ExportResult{}, err
 	}
 	defer tx.Rollback()
 
+	result, err := exportItem(ctx, tx, saleOrderId, saleOrderSeq,
+		quantity, effectiveFrom, userLoginId)
+	if err != nil {
+		return result, err
+	}
+
+	return result, tx.Commit()
+}
+
+// exportItem takes the item out of stock as far as the stock on hand
+// allows and marks it exported with the quantity shipped. The rest of
+// the ordered quantity moves to a new backorder line of the same order,
+// which keeps the reservation for it.
+func exportItem(
+	ctx context.Context, tx *sqlx.Tx,
+	saleOrderId int64, saleOrderSeq int,
+	quantity *decimal.Decimal, effectiveFrom time.Time,
+	userLoginId uuid.UUID) (ExportResult, error) {
+
+	result := ExportResult{
+		SaleOrderId:  saleOrderId,
+		SaleOrderSeq: saleOrderSeq,
+	}
+
+	// effectiveFrom may be backdated, reservations are released and
+	// expire counting from now
+	now := time.Now()
+
+	// checked first so a rejected export touches no stock
+	err := order.ChangeStatus(ctx, tx, order.StatusChange{
+		SaleOrderId: saleOrderId,
+		StatusId:    order.ShippingStatus,
+		UserLoginId: &userLoginId,
+		ChangedAt:   now,
+	})
+	if err != nil {
+		return result, err
+	}
+
 	exported := false
-	query := repo.db.Rebind(`
+	query := tx.Rebind(`
         select exported from sale_order_item
         where sale_order_id = ? and sale_order_seq = ?
+            and removed_at is null
         `)
 	err = tx.GetContext(ctx, &exported, query,
 		saleOrderId, saleOrderSeq)
 	if err != nil {
-		return err
+		return result, err
 	}
 
 	if exported {
-		return ErrExported
-	}
-
-	type OrderItemInfo struct {
-		ProductId   int64           `db:"product_id"`
-		WarehouseId uuid.UUID       `db:"original_warehouse_id"`
-		Quantity    decimal.Decimal `db:"quantity"`
+		return result, ErrExported
 	}
-	info := OrderItemInfo{}
 
-	query = repo.db.Rebind(`
-        select pp.product_id, o.original_warehouse_id, oi.quantity
-        from product_price pp
-        inner join sale_order_item oi on oi.product_price_id = pp.id
-        inner join sale_order o on o.id = oi.sale_order_id
-        where oi.sale_order_id = ? and oi.sale_order_seq = ?
-        `)
-	err = tx.GetContext(ctx, &info, query, saleOrderId, saleOrderSeq)
+	info, err := getOrderItemInfo(ctx, tx, saleOrderId, saleOrderSeq)
 	if err != nil {
-		return err
+		return result, err
 	}
 
-	type InventoryItem struct {
-		Id             int64           `db:"id"`
-		QuantityOnHand decimal.Decimal `db:"quantity_on_hand"`
+	wanted := info.Quantity
+	if quantity != nil {
+		if quantity.GreaterThan(info.Quantity) {
+			return result, ErrOverShipment
+		}
+		wanted = *quantity
 	}
-	items := make([]InventoryItem, 0)
-	query = repo.db.Rebind(`
-        select id, quantity_on_hand
-        from inventory_item
-        where product_id = ? and warehouse_id = ?
-        and quantity_on_hand > 0
-        `)
-	err = tx.SelectContext(ctx, &items, query,
-		info.ProductId, info.WarehouseId)
+
+	_, allocations, shortage, err := allocation.AllocateProduct(ctx, tx,
+		info.WarehouseId, info.ProductId, wanted, true)
 	if err != nil {
-		return err
+		return result, err
 	}
 
-	query = repo.db.Rebind(`
+	shipped := wanted.Sub(shortage)
+	if !shipped.IsPositive() {
+		return result, ErrShortage
+	}
+
+	query = tx.Rebind(`
         insert into inventory_item_detail(
         inventory_item_id, exported_quantity,
         effective_from,
@@ -100,75 +146,422 @@ func (repo *Repo) ExportSaleOrderItem(
         values(?, ?, ?, ?, ?)
         `)
 
-	updateQuery := repo.db.Rebind(`
+	updateQuery := tx.Rebind(`
         update inventory_item
         set quantity_on_hand = quantity_on_hand - ?
         where id = ?
         `)
 
-	quantity := info.Quantity
-	for _, item := range items {
-		// minimum
-		var exportedQuantity decimal.Decimal
-		if quantity.GreaterThan(item.QuantityOnHand) {
-			exportedQuantity = item.QuantityOnHand
-		} else {
-			exportedQuantity = quantity
-		}
-
+	for _, a := range allocations {
 		_, err = tx.ExecContext(ctx, query,
-			item.Id, exportedQuantity,
+			a.InventoryItemId, a.Quantity,
 			effectiveFrom,
 			saleOrderId, saleOrderSeq,
 		)
 		if err != nil {
-			return err
+			return result, err
 		}
 
-		_, err = tx.ExecContext(ctx, updateQuery, exportedQuantity, item.Id)
+		_, err = tx.ExecContext(ctx, updateQuery,
+			a.Quantity, a.InventoryItemId)
 		if err != nil {
-			return err
+			return result, err
 		}
 
-		quantity = quantity.Sub(exportedQuantity)
-		if quantity.Equal(decimal.Zero) {
-			break
+		inventoryItemId := a.InventoryItemId
+		err = ledger.Record(ctx, tx, ledger.Transaction{
+			TypeId:             ledger.ExportType,
+			WarehouseId:        info.WarehouseId,
+			ProductId:          info.ProductId,
+			InventoryItemId:    &inventoryItemId,
+			QuantityOnHandDiff: a.Quantity.Neg(),
+			SaleOrderId:        &saleOrderId,
+			SaleOrderSeq:       &saleOrderSeq,
+			OccurredAt:         effectiveFrom,
+		})
+		if err != nil {
+			return result, err
 		}
 	}
 
-	query = repo.db.Rebind(`
+	query = tx.Rebind(`
         update warehouse_product_statistics
         set quantity_on_hand = quantity_on_hand - ?
         where warehouse_id = ? and product_id = ?
         `)
 	_, err = tx.ExecContext(ctx, query,
-		info.Quantity, info.WarehouseId, info.ProductId)
+		shipped, info.WarehouseId, info.ProductId)
 	if err != nil {
-		return err
+		return result, err
 	}
 
-	query = repo.db.Rebind(`
+	query = tx.Rebind(`
         update sale_order_item
-        set exported = TRUE
+        set exported = TRUE, shipped_quantity = ?
         where sale_order_id = ? and sale_order_seq = ?
         `)
 	_, err = tx.ExecContext(ctx, query,
-		saleOrderId, saleOrderSeq)
+		shipped, saleOrderId, saleOrderSeq)
 	if err != nil {
-		return err
+		return result, err
+	}
+	result.ShippedQuantity = shipped
+
+	remainder := info.Quantity.Sub(shipped)
+	if remainder.IsPositive() {
+		// the reservation shrinks to what shipped and the backorder
+		// line reserves the rest, which leaves available as it was
+		err = reservation.Resize(ctx, tx,
+			saleOrderId, saleOrderSeq, shipped, now)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	err = reservation.Consume(ctx, tx,
+		saleOrderId, saleOrderSeq, now)
+	if err != nil {
+		return result, err
+	}
+
+	if !remainder.IsPositive() {
+		return result, nil
+	}
+
+	var backorderSeq int
+	query = tx.Rebind(`
+        insert into sale_order_item(
+        sale_order_id, sale_order_seq,
+        product_price_id, quantity, backorder_of_seq)
+        select ?, max(sale_order_seq) + 1, ?, ?, ?
+        from sale_order_item where sale_order_id = ?
+        returning sale_order_seq
+        `)
+	err = tx.GetContext(ctx, &backorderSeq, query,
+		saleOrderId, info.ProductPriceId, remainder, saleOrderSeq,
+		saleOrderId)
+	if err != nil {
+		return result, err
+	}
+	result.BackorderSeq = &backorderSeq
+	result.BackorderQuantity = remainder
+
+	err = reservation.Reserve(ctx, tx, reservation.Reservation{
+		SaleOrderId:  saleOrderId,
+		SaleOrderSeq: backorderSeq,
+		WarehouseId:  info.WarehouseId,
+		ProductId:    info.ProductId,
+		Quantity:     remainder,
+	}, now)
+	if err != nil {
+		return result, err
+	}
+
+	return result, reservation.Hold(ctx, tx, saleOrderId)
+}
+
+// ReadyBackorder flags the open backorder lines of a product in a
+// warehouse that stock has arrived for, so they can be picked up with
+// FulfillBackorder. A line keeps the time of the first arrival.
+func ReadyBackorder(
+	ctx context.Context, tx *sqlx.Tx,
+	warehouseId uuid.UUID, productId int64, now time.Time) error {
+
+	log.Println("ReadyBackorder", warehouseId, productId)
+
+	query := tx.Rebind(`
+        update sale_order_item oi
+        set backorder_ready_at = ?
+        from sale_order o, product_price pp
+        where o.id = oi.sale_order_id and pp.id = oi.product_price_id
+            and oi.backorder_of_seq is not null
+            and not oi.exported and oi.removed_at is null
+            and oi.backorder_ready_at is null
+            and o.sale_order_status_id = ?
+            and o.original_warehouse_id = ? and pp.product_id = ?
+        `)
+	_, err := tx.ExecContext(ctx, query,
+		now, order.ShippingStatus, warehouseId, productId)
+	return err
+}
+
+func (repo *Repo) ViewBackorder(
+	ctx context.Context,
+	warehouseId *uuid.UUID, ready bool,
+	page, pageSize int) (int, []Backorder, error) {
+
+	log.Println("ViewBackorder", warehouseId, ready, page, pageSize)
+
+	var count int
+	result := make([]Backorder, 0)
+
+	query := repo.db.Rebind(`
+        select count(*)
+        from sale_order_item oi
+            inner join sale_order o on o.id = oi.sale_order_id
+        where oi.backorder_of_seq is not null
+            and not oi.exported and oi.removed_at is null
+            and o.sale_order_status_id = ?
+            and (cast(? as uuid) is null or o.original_warehouse_id = ?)
+            and (not ? or oi.backorder_ready_at is not null)
+        `)
+	err := repo.db.GetContext(ctx, &count, query,
+		order.ShippingStatus, warehouseId, warehouseId, ready)
+	if err != nil {
+		return count, result, err
 	}
 
 	query = repo.db.Rebind(`
-        update sale_order
-        set sale_order_status_id = 3
-        where id = ?
+        select oi.sale_order_id, oi.sale_order_seq, oi.backorder_of_seq,
+        o.original_warehouse_id, fw.name as warehouse_name,
+        c.name as customer, pp.product_id, p.name as product_name,
+        oi.quantity,
+        coalesce(s.quantity_on_hand, 0) as quantity_on_hand,
+        oi.backorder_ready_at, oi.created_at
+        from sale_order_item oi
+            inner join sale_order o on o.id = oi.sale_order_id
+            inner join customer c on c.id = o.customer_id
+            inner join facility fw on fw.id = o.original_warehouse_id
+            inner join product_price pp on pp.id = oi.product_price_id
+            inner join product p on p.id = pp.product_id
+            left join warehouse_product_statistics s
+                on s.warehouse_id = o.original_warehouse_id
+                    and s.product_id = pp.product_id
+        where oi.backorder_of_seq is not null
+            and not oi.exported and oi.removed_at is null
+            and o.sale_order_status_id = ?
+            and (cast(? as uuid) is null or o.original_warehouse_id = ?)
+            and (not ? or oi.backorder_ready_at is not null)
+        order by oi.created_at, oi.sale_order_id, oi.sale_order_seq
+        offset ? limit ?
         `)
-	_, err = tx.ExecContext(ctx, query, saleOrderId)
+	err = repo.db.SelectContext(ctx, &result, query,
+		order.ShippingStatus, warehouseId, warehouseId, ready,
+		page*pageSize, pageSize)
+
+	return count, result, err
+}
+
+// FulfillBackorder exports the open backorder lines of a warehouse,
+// oldest first, as far as the stock on hand goes. Lines of a product
+// that has run out are left for later, a line only partly covered
+// leaves a new backorder line behind it.
+func (repo *Repo) FulfillBackorder(
+	ctx context.Context,
+	warehouseId uuid.UUID, productId *int64,
+	effectiveFrom time.Time,
+	userLoginId uuid.UUID) ([]ExportResult, error) {
+
+	log.Println("FulfillBackorder", warehouseId, productId, effectiveFrom)
+
+	results := make([]ExportResult, 0)
+
+	tx, err := repo.db.BeginTxx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+	})
 	if err != nil {
-		return err
+		return results, err
 	}
+	defer tx.Rollback()
 
-	return tx.Commit()
+	type Line struct {
+		SaleOrderId  int64 `db:"sale_order_id"`
+		SaleOrderSeq int   `db:"sale_order_seq"`
+		ProductId    int64 `db:"product_id"`
+	}
+	lines := make([]Line, 0)
+
+	query := repo.db.Rebind(`
+        select oi.sale_order_id, oi.sale_order_seq, pp.product_id
+        from sale_order_item oi
+            inner join sale_order o on o.id = oi.sale_order_id
+            inner join product_price pp on pp.id = oi.product_price_id
+        where oi.backorder_of_seq is not null
+            and not oi.exported and oi.removed_at is null
+            and o.sale_order_status_id = ?
+            and o.original_warehouse_id = ?
+            and (cast(? as integer) is null or pp.product_id = ?)
+        order by oi.created_at, oi.sale_order_id, oi.sale_order_seq
+        `)
+	err = tx.SelectContext(ctx, &lines, query,
+		order.ShippingStatus, warehouseId, productId, productId)
+	if err != nil {
+		return results, err
+	}
+
+	soldOut := make(map[int64]bool)
+	for _, line := range lines {
+		if soldOut[line.ProductId] {
+			continue
+		}
+
+		result, err := exportItem(ctx, tx,
+			line.SaleOrderId, line.SaleOrderSeq, nil,
+			effectiveFrom, userLoginId)
+		if err == ErrShortage {
+			soldOut[line.ProductId] = true
+			continue
+		}
+		if err != nil {
+			return results, err
+		}
+		if result.BackorderSeq != nil {
+			soldOut[line.ProductId] = true
+		}
+
+		results = append(results, result)
+	}
+
+	return results, tx.Commit()
+}
+
+type orderItemInfo struct {
+	ProductId      int64           `db:"product_id"`
+	ProductPriceId uuid.UUID       `db:"product_price_id"`
+	WarehouseId    uuid.UUID       `db:"original_warehouse_id"`
+	Quantity       decimal.Decimal `db:"quantity"`
+}
+
+func getOrderItemInfo(
+	ctx context.Context, q sqlx.ExtContext,
+	saleOrderId int64, saleOrderSeq int) (orderItemInfo, error) {
+
+	info := orderItemInfo{}
+	query := q.Rebind(`
+        select pp.product_id, oi.product_price_id,
+        o.original_warehouse_id, oi.quantity
+        from product_price pp
+        inner join sale_order_item oi on oi.product_price_id = pp.id
+        inner join sale_order o on o.id = oi.sale_order_id
+        where oi.sale_order_id = ? and oi.sale_order_seq = ?
+            and oi.removed_at is null
+        `)
+	err := sqlx.GetContext(ctx, q, &info, query, saleOrderId, saleOrderSeq)
+	return info, err
+}
+
+func (repo *Repo) PreviewExportSaleOrderItem(
+	ctx context.Context,
+	saleOrderId int64, saleOrderSeq int) (ExportPreview, error) {
+
+	log.Println("PreviewExportSaleOrderItem", saleOrderId, saleOrderSeq)
+
+	preview := ExportPreview{
+		SaleOrderId:  saleOrderId,
+		SaleOrderSeq: saleOrderSeq,
+	}
+
+	info, err := getOrderItemInfo(ctx, repo.db, saleOrderId, saleOrderSeq)
+	if err != nil {
+		return preview, err
+	}
+
+	preview.ProductId = info.ProductId
+	preview.WarehouseId = info.WarehouseId
+	preview.Quantity = info.Quantity
+	preview.StrategyId, preview.AllocationList, preview.Shortage, err =
+		allocation.AllocateProduct(ctx, repo.db,
+			info.WarehouseId, info.ProductId, info.Quantity, false)
+
+	return preview, err
+}
+
+func (repo *Repo) ViewPickList(
+	ctx context.Context, saleOrderId int64) ([]PickLine, error) {
+
+	log.Println("ViewPickList", saleOrderId)
+
+	lines := make([]PickLine, 0)
+	query := repo.db.Rebind(`
+        select oi.sale_order_seq, pp.product_id, p.name as product_name,
+        o.original_warehouse_id, oi.quantity
+        from sale_order_item oi
+            inner join sale_order o on o.id = oi.sale_order_id
+            inner join product_price pp on pp.id = oi.product_price_id
+            inner join product p on p.id = pp.product_id
+        where oi.sale_order_id = ? and not oi.exported
+            and oi.removed_at is null
+        order by oi.sale_order_seq
+        `)
+	err := repo.db.SelectContext(ctx, &lines, query, saleOrderId)
+	if err != nil {
+		return lines, err
+	}
+
+	// lines of the same product share the same items,
+	// what an earlier line picks is no longer there for the next one
+	candidateCache := make(map[int64][]allocation.Candidate)
+
+	for i := range lines {
+		line := &lines[i]
+		candidates, ok := candidateCache[line.ProductId]
+		if !ok {
+			strategyId, err := allocation.ResolveStrategy(ctx, repo.db,
+				line.WarehouseId, line.ProductId)
+			if err != nil {
+				return lines, err
+			}
+			candidates, err = allocation.SelectCandidate(ctx, repo.db,
+				line.WarehouseId, line.ProductId, strategyId, false)
+			if err != nil {
+				return lines, err
+			}
+		}
+
+		allocations, shortage := allocation.Allocate(candidates, line.Quantity)
+
+		picked := make(map[int64]decimal.Decimal)
+		line.PickList = make([]Pick, 0)
+		for _, a := range allocations {
+			picked[a.InventoryItemId] = a.Quantity
+			line.PickList = append(line.PickList, Pick{
+				InventoryItemId: a.InventoryItemId,
+				LocationId:      a.LocationId,
+				LocationCode:    a.LocationCode,
+				LotNumber:       a.LotNumber,
+				ExpiryDate:      a.ExpiryDate,
+				Quantity:        a.Quantity,
+			})
+		}
+		line.Shortage = shortage
+
+		for j := range candidates {
+			c := &candidates[j]
+			c.QuantityOnHand = c.QuantityOnHand.Sub(picked[c.InventoryItemId])
+		}
+		candidateCache[line.ProductId] = candidates
+	}
+
+	return lines, nil
+}
+
+func (repo *Repo) FindSaleOrderSeqByBarcode(
+	ctx context.Context, saleOrderId int64, barcode string) (int, error) {
+
+	log.Println("FindSaleOrderSeqByBarcode", saleOrderId, barcode)
+
+	var seq int
+
+	code, ok := product.NormalizeGtin(barcode)
+	if !ok {
+		return seq, product.ErrBarcode
+	}
+
+	query := repo.db.Rebind(`
+        select oi.sale_order_seq
+        from sale_order_item oi
+            inner join product_price pp on pp.id = oi.product_price_id
+            inner join product_barcode b on b.product_id = pp.product_id
+        where oi.sale_order_id = ? and b.code = ?
+            and oi.removed_at is null
+        order by oi.exported, oi.sale_order_seq
+        limit 1
+        `)
+	err := repo.db.GetContext(ctx, &seq, query, saleOrderId, code)
+	if err == sql.ErrNoRows {
+		return seq, product.ErrBarcode
+	}
+	return seq, err
 }
 
 func (repo *Repo) ViewExportableSalesOrder(
@@ -185,9 +578,10 @@ func (repo *Repo) ViewExportableSalesOrder(
 
 	countQuery := repo.db.Rebind(`
             select count(*) from sale_order
-            where sale_order_status_id = 2 or sale_order_status_id = 3
+            where sale_order_status_id = ? or sale_order_status_id = ?
             `)
-	err = repo.db.GetContext(ctx, &count, countQuery)
+	err = repo.db.GetContext(ctx, &count, countQuery,
+		order.AcceptedStatus, order.ShippingStatus)
 	if err != nil {
 		return count, orders, err
 	}
@@ -207,28 +601,54 @@ func (repo *Repo) ViewExportableSalesOrder(
                 select f.id, f.name from facility f
                 inner join facility_customer fc on fc.id = f.id
             ) fc on fc.id = o.ship_to_facility_customer_id
-        where sale_order_status_id = 2 or sale_order_status_id = 3
+        where sale_order_status_id = ? or sale_order_status_id = ?
         order by o.%s %s
         offset ? limit ?`
 	query = fmt.Sprintf(query, sortedBy, sortOrder)
 	query = repo.db.Rebind(query)
 
 	err = repo.db.SelectContext(ctx, &orders, query,
+		order.AcceptedStatus, order.ShippingStatus,
 		page*pageSize, pageSize)
 
 	return count, orders, err
 }
 
-func (repo *Repo) CompleteSalesOrder(ctx context.Context, id int64) error {
+func (repo *Repo) CompleteSalesOrder(
+	ctx context.Context, id int64, userLoginId uuid.UUID) error {
+
 	log.Println("CompleteSalesOrder", id)
 
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var openCount int
 	query := repo.db.Rebind(`
-        update sale_order
-        set sale_order_status_id = 4
-        where id = ? and sale_order_status_id = 3
+        select count(*) from sale_order_item
+        where sale_order_id = ? and not exported and removed_at is null
         `)
-	_, err := repo.db.ExecContext(ctx, query, id)
-	return err
+	err = tx.GetContext(ctx, &openCount, query, id)
+	if err != nil {
+		return err
+	}
+	if openCount > 0 {
+		return ErrOpenItems
+	}
+
+	err = order.ChangeStatus(ctx, tx, order.StatusChange{
+		SaleOrderId: id,
+		StatusId:    order.CompletedStatus,
+		UserLoginId: &userLoginId,
+		ChangedAt:   time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (repo *Repo) ViewCompletedSalesOrder(
@@ -245,9 +665,9 @@ func (repo *Repo) ViewCompletedSalesOrder(
 
 	countQuery := repo.db.Rebind(`
             select count(*) from sale_order
-            where sale_order_status_id = 4
+            where sale_order_status_id = ?
             `)
-	err = repo.db.GetContext(ctx, &count, countQuery)
+	err = repo.db.GetContext(ctx, &count, countQuery, order.CompletedStatus)
 	if err != nil {
 		return count, orders, err
 	}
@@ -267,14 +687,14 @@ func (repo *Repo) ViewCompletedSalesOrder(
                 select f.id, f.name from facility f
                 inner join facility_customer fc on fc.id = f.id
             ) fc on fc.id = o.ship_to_facility_customer_id
-        where sale_order_status_id = 4
+        where sale_order_status_id = ?
         order by o.%s %s
         offset ? limit ?`
 	query = fmt.Sprintf(query, sortedBy, sortOrder)
 	query = repo.db.Rebind(query)
 
 	err = repo.db.SelectContext(ctx, &orders, query,
-		page*pageSize, pageSize)
+		order.CompletedStatus, page*pageSize, pageSize)
 
 	return count, orders, err
 }
@@ -1,7 +1,7 @@
 package main
 
 func FacilityRoutes(root *Root) {
-	root.PostAuthorized(
+	root.PostIdempotent(
 		"/api/facility/add-warehouse",
 		"VIEW_EDIT_FACILITY",
 		root.facility.AddWarehouseHandler)
@@ -36,7 +36,7 @@ func FacilityRoutes(root *Root) {
 		"VIEW_EDIT_FACILITY",
 		root.facility.QuerySimpleCustomerHandler)
 
-	root.PostAuthorized(
+	root.PostIdempotent(
 		"/api/facility/add-customer-store",
 		"VIEW_EDIT_FACILITY",
 		root.facility.AddCustomerStoreHandler)
@@ -0,0 +1,20 @@
+package idempotency
+
+import (
+	"baseweb/basic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Key struct {
+	UserLoginId   uuid.UUID      `db:"user_login_id"`
+	Key           string         `db:"idempotency_key"`
+	RequestMethod string         `db:"request_method"`
+	RequestPath   string         `db:"request_path"`
+	RequestHash   string         `db:"request_hash"`
+	StatusCode    *int16         `db:"status_code"`
+	ResponseBody  []byte         `db:"response_body"`
+	CompletedAt   basic.NullTime `db:"completed_at"`
+	CreatedAt     time.Time      `db:"created_at"`
+}
@@ -0,0 +1,138 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func InitRepo(db *sqlx.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+// Claim stores key for a request about to run. When the key was already
+// used the stored one is returned with claimed false, whether that
+// request finished or is still running. A key older than
+// IDEMPOTENCY_TTL is forgotten and can be claimed again.
+func (repo *Repo) Claim(
+	ctx context.Context, key Key, now time.Time) (Key, bool, error) {
+
+	log.Println("Claim", key.UserLoginId, key.Key,
+		key.RequestMethod, key.RequestPath)
+
+	query := repo.db.Rebind(`
+        delete from idempotency_key
+        where user_login_id = ? and idempotency_key = ?
+            and created_at < ?
+        `)
+	_, err := repo.db.ExecContext(ctx, query,
+		key.UserLoginId, key.Key, now.Add(-IDEMPOTENCY_TTL))
+	if err != nil {
+		return key, false, err
+	}
+
+	insertQuery := repo.db.Rebind(`
+        insert into idempotency_key(
+        user_login_id, idempotency_key,
+        request_method, request_path, request_hash, created_at)
+        values (?, ?, ?, ?, ?, ?)
+        on conflict do nothing
+        `)
+
+	selectQuery := repo.db.Rebind(`
+        select user_login_id, idempotency_key,
+        request_method, request_path, request_hash,
+        status_code, response_body, completed_at, created_at
+        from idempotency_key
+        where user_login_id = ? and idempotency_key = ?
+        `)
+
+	// a request that failed gives its key back, so the stored one can
+	// be gone by the time it is read
+	for {
+		res, err := repo.db.ExecContext(ctx, insertQuery,
+			key.UserLoginId, key.Key,
+			key.RequestMethod, key.RequestPath, key.RequestHash, now)
+		if err != nil {
+			return key, false, err
+		}
+
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			return key, false, err
+		}
+		if inserted > 0 {
+			key.CreatedAt = now
+			return key, true, nil
+		}
+
+		stored := Key{}
+		err = repo.db.GetContext(ctx, &stored, selectQuery,
+			key.UserLoginId, key.Key)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		return stored, false, err
+	}
+}
+
+// Complete keeps the response of a claimed request for its retries.
+func (repo *Repo) Complete(
+	ctx context.Context,
+	userLoginId uuid.UUID, key string,
+	statusCode int, body []byte) error {
+
+	log.Println("Complete", userLoginId, key, statusCode)
+
+	query := repo.db.Rebind(`
+        update idempotency_key
+        set status_code = ?, response_body = ?, completed_at = now()
+        where user_login_id = ? and idempotency_key = ?
+        `)
+	_, err := repo.db.ExecContext(ctx, query,
+		statusCode, body, userLoginId, key)
+
+	return err
+}
+
+// Release gives back the key of a request that failed, so a retry runs
+// it again.
+func (repo *Repo) Release(
+	ctx context.Context, userLoginId uuid.UUID, key string) error {
+
+	log.Println("Release", userLoginId, key)
+
+	query := repo.db.Rebind(`
+        delete from idempotency_key
+        where user_login_id = ? and idempotency_key = ?
+            and completed_at is null
+        `)
+	_, err := repo.db.ExecContext(ctx, query, userLoginId, key)
+
+	return err
+}
+
+func (repo *Repo) PurgeKey(ctx context.Context, before time.Time) (int64, error) {
+	log.Println("PurgeKey", before)
+
+	query := repo.db.Rebind(`
+        delete from idempotency_key where created_at < ?
+        `)
+	res, err := repo.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
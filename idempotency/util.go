@@ -0,0 +1,168 @@
+package idempotency
+
+import (
+	"baseweb/basic"
+	"baseweb/security"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+)
+
+// IDEMPOTENCY_TTL is how long a response is kept for retries of the same
+// request. A request that never finished holds its key as long.
+const IDEMPOTENCY_TTL = 24 * time.Hour
+
+const MAX_KEY_LENGTH = 255
+
+// MAX_REQUEST_SIZE bounds the body read for the request hash, above the
+// limits the create endpoints set for themselves.
+const MAX_REQUEST_SIZE = 16 << 20
+
+// COMPLETE_ATTEMPTS is how many times storing a response is tried before
+// the key is left held until it expires.
+const COMPLETE_ATTEMPTS = 3
+
+const COMPLETE_RETRY_DELAY = 200 * time.Millisecond
+
+const KEY_HEADER = "Idempotency-Key"
+
+const REPLAYED_HEADER = "Idempotent-Replayed"
+
+// recorder passes a response through while keeping a copy of it.
+type recorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func returnError(w http.ResponseWriter, statusCode int, msg string) error {
+	type Response struct {
+		Error string `json:"error"`
+	}
+
+	w.WriteHeader(statusCode)
+	return json.NewEncoder(w).Encode(Response{Error: msg})
+}
+
+// Idempotent runs handler once per Idempotency-Key of a user. A retry
+// with the same key gets the response of the first request back, a
+// retry while the first is still running gets 409 Conflict and a key
+// reused for a different request gets 422. Requests without the header
+// run as usual.
+//
+// The response is stored after handler has committed, so a crash in
+// between leaves the key held until it expires rather than running the
+// request twice.
+func Idempotent(repo *Repo, handler basic.Handler) basic.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		keyValue := r.Header.Get(KEY_HEADER)
+		if keyValue == "" {
+			return handler(w, r)
+		}
+		if len(keyValue) > MAX_KEY_LENGTH {
+			return returnError(w, http.StatusBadRequest,
+				"Idempotency-Key is too long")
+		}
+
+		ctx := r.Context()
+		userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+		body, err := ioutil.ReadAll(
+			http.MaxBytesReader(w, r.Body, MAX_REQUEST_SIZE))
+		if err != nil {
+			return err
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.Sum256(body)
+		key := Key{
+			UserLoginId:   userLogin.Id,
+			Key:           keyValue,
+			RequestMethod: r.Method,
+			RequestPath:   r.URL.Path,
+			RequestHash:   hex.EncodeToString(hash[:]),
+		}
+
+		stored, claimed, err := repo.Claim(ctx, key, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if !claimed {
+			if stored.RequestMethod != key.RequestMethod ||
+				stored.RequestPath != key.RequestPath ||
+				stored.RequestHash != key.RequestHash {
+				return returnError(w, http.StatusUnprocessableEntity,
+					"Idempotency-Key was used for a different request")
+			}
+			if !stored.CompletedAt.Valid || stored.StatusCode == nil {
+				return returnError(w, http.StatusConflict,
+					"request with this Idempotency-Key is still running")
+			}
+
+			w.Header().Set(REPLAYED_HEADER, "true")
+			w.WriteHeader(int(*stored.StatusCode))
+			_, err = w.Write(stored.ResponseBody)
+			return err
+		}
+
+		rec := &recorder{ResponseWriter: w, statusCode: http.StatusOK}
+		err = handler(rec, r)
+
+		// the request may be canceled by now, its key must still be
+		// settled
+		if err != nil {
+			releaseErr := repo.Release(context.Background(),
+				key.UserLoginId, key.Key)
+			if releaseErr != nil {
+				log.Println("[ERROR] idempotency release", releaseErr)
+			}
+			return err
+		}
+
+		// the handler has done its work, so the key stays held even when
+		// its response cannot be stored: a retry then gets 409 until the
+		// key expires instead of running the request a second time
+		for attempt := 1; ; attempt++ {
+			err = repo.Complete(context.Background(),
+				key.UserLoginId, key.Key, rec.statusCode, rec.body.Bytes())
+			if err == nil {
+				break
+			}
+			log.Println("[ERROR] idempotency complete", attempt, err)
+			if attempt == COMPLETE_ATTEMPTS {
+				break
+			}
+			time.Sleep(COMPLETE_RETRY_DELAY)
+		}
+		return nil
+	}
+}
+
+// RunJob forgets expired keys every interval until ctx is done.
+func RunJob(ctx context.Context, repo *Repo, interval time.Duration) {
+	basic.RunEvery(ctx, interval, func() {
+		count, err := repo.PurgeKey(ctx, time.Now().Add(-IDEMPOTENCY_TTL))
+		if err != nil {
+			log.Println("[ERROR] idempotency job", err)
+		} else if count > 0 {
+			log.Println("idempotency job purged", count, "keys")
+		}
+	})
+}
@@ -6,11 +6,16 @@ func ImportRoutes(root *Root) {
 		"IMPORT",
 		root.importProduct.ViewProductByWarehouseHandler)
 
-	root.PostAuthorized(
+	root.PostIdempotent(
 		"/api/import/add-inventory-item",
 		"IMPORT",
 		root.importProduct.AddInventoryItemHandler)
 
+	root.PostIdempotent(
+		"/api/import/add-goods-receipt",
+		"IMPORT",
+		root.importProduct.AddGoodsReceiptHandler)
+
 	root.GetAuthorized(
 		"/api/import/view-inventory-by-warehouse",
 		"IMPORT",
@@ -20,4 +25,9 @@ func ImportRoutes(root *Root) {
 		"/api/import/view-inventory-by-product",
 		"IMPORT",
 		root.importProduct.ViewInventoryByProductHandler)
+
+	root.GetAuthorized(
+		"/api/import/view-near-expiry",
+		"IMPORT",
+		root.importProduct.ViewNearExpiryHandler)
 }
@@ -1,10 +1,15 @@
 package importProduct
 
 import (
+	"baseweb/security"
 	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,6 +25,12 @@ func InitRoot(repo *Repo) *Root {
 	}
 }
 
+var bodyError = errors.New("body constraints violation")
+
+const MAX_RECEIPT_SIZE = 10 << 20
+
+const MAX_RECEIPT_LINES = 5000
+
 func (root *Root) ViewProductByWarehouseHandler(
 	w http.ResponseWriter, r *http.Request) error {
 
@@ -96,6 +107,14 @@ func (root *Root) AddInventoryItemHandler(
 		return err
 	}
 
+	// Receipts against a purchase order, a goods receipt document or a
+	// return have their own endpoints, which keep those documents in step.
+	item.PurchaseOrderId = nil
+	item.PurchaseOrderSeq = nil
+	item.GoodsReceiptId = nil
+	item.ReturnOrderId = nil
+	item.ReturnOrderSeq = nil
+
 	err = root.repo.InsertInventoryItem(ctx, item)
 	if err != nil {
 		return err
@@ -141,6 +160,8 @@ func (root *Root) ViewInventoryByWarehouseHandler(
 		sortedBy = "updated_at"
 	} else if sortedByQuery == "name" {
 		sortedBy = "name"
+	} else if sortedByQuery == "expiryDate" {
+		sortedBy = "expiry_date"
 	}
 
 	sortOrder := "desc"
@@ -204,6 +225,8 @@ func (root *Root) ViewInventoryByProductHandler(
 	sortedByQuery := query.Get("sortedBy")
 	if sortedByQuery == "updatedAt" {
 		sortedBy = "updated_at"
+	} else if sortedByQuery == "expiryDate" {
+		sortedBy = "expiry_date"
 	}
 
 	sortOrder := "desc"
@@ -234,3 +257,151 @@ func (root *Root) ViewInventoryByProductHandler(
 	return json.NewEncoder(w).Encode(res)
 
 }
+
+func (root *Root) ViewNearExpiryHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	query := r.URL.Query()
+	warehouseId, err := uuid.Parse(query.Get("warehouseId"))
+	if err != nil {
+		return err
+	}
+
+	days, err := strconv.Atoi(query.Get("days"))
+	if err != nil || days < 0 {
+		days = 30
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	count, items, err := root.repo.ViewNearExpiry(
+		ctx, warehouseId, days, page, pageSize)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		InventoryCount int              `json:"inventoryCount"`
+		InventoryList  []NearExpiryItem `json:"inventoryList"`
+	}
+
+	res := Response{
+		InventoryCount: count,
+		InventoryList:  items,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+// AddGoodsReceiptHandler takes the lines either as JSON or as a CSV or
+// XLSX file uploaded in the "file" field of a multipart form.
+func (root *Root) AddGoodsReceiptHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+	var warehouseId uuid.UUID
+	var fileName string
+	var lines []ReceiptLine
+	lineErrors := make([]LineError, 0)
+
+	contentType := r.Header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "multipart/form-data") {
+		r.Body = http.MaxBytesReader(w, r.Body, MAX_RECEIPT_SIZE+(1<<20))
+		err := r.ParseMultipartForm(1 << 20)
+		if err != nil {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return nil
+		}
+
+		warehouseId, err = uuid.Parse(r.FormValue("warehouseId"))
+		if err != nil {
+			return err
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		data, err := ioutil.ReadAll(io.LimitReader(file, MAX_RECEIPT_SIZE+1))
+		if err != nil {
+			return err
+		}
+		if len(data) > MAX_RECEIPT_SIZE {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return nil
+		}
+
+		rows, err := readSheet(data)
+		if err == ErrSheetSize {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return nil
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return nil
+		}
+
+		fileName = header.Filename
+		lines, lineErrors = parseReceiptRows(rows)
+	} else {
+		type Request struct {
+			WarehouseId uuid.UUID     `json:"warehouseId"`
+			Lines       []ReceiptLine `json:"lines"`
+		}
+
+		req := Request{}
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return err
+		}
+
+		warehouseId = req.WarehouseId
+		lines = req.Lines
+		for i := range lines {
+			lines[i].Line = i + 1
+		}
+	}
+
+	if len(lines)+len(lineErrors) == 0 ||
+		len(lines) > MAX_RECEIPT_LINES {
+		return bodyError
+	}
+
+	id, lineErrors, err := root.repo.InsertGoodsReceipt(ctx,
+		warehouseId, fileName, lines, lineErrors, userLogin.Id)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		Id        int64       `json:"id"`
+		LineCount int         `json:"lineCount"`
+		ErrorList []LineError `json:"errorList"`
+	}
+
+	res := Response{
+		Id:        id,
+		LineCount: len(lines),
+		ErrorList: lineErrors,
+	}
+
+	if len(lineErrors) > 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
@@ -19,16 +19,27 @@ type Product struct {
 }
 
 type InventoryItem struct {
-	Id             int64           `json:"id" db:"id"`
-	ProductId      int64           `json:"productId" db:"product_id"`
-	ProductName    string          `json:"productName" db:"product_name"`
-	WarehouseId    uuid.UUID       `json:"warehouseId" db:"warehouse_id"`
-	Quantity       decimal.Decimal `json:"quantity" db:"quantity"`
-	QuantityOnHand decimal.Decimal `json:"quantityOnHand" db:"quantity_on_hand"`
-	UnitCost       decimal.Decimal `json:"unitCost" db:"unit_cost"`
-	CurrencyUomId  string          `json:"currencyUomId" db:"currency_uom_id"`
-	CreatedAt      time.Time       `json:"createdAt" db:"created_at"`
-	UpdatedAt      time.Time       `json:"updatedAt" db:"updated_at"`
+	Id               int64            `json:"id" db:"id"`
+	ProductId        int64            `json:"productId" db:"product_id"`
+	ProductName      string           `json:"productName" db:"product_name"`
+	Barcode          string           `json:"barcode,omitempty" db:"-"`
+	WarehouseId      uuid.UUID        `json:"warehouseId" db:"warehouse_id"`
+	LocationId       *int             `json:"locationId" db:"location_id"`
+	LocationCode     basic.NullString `json:"locationCode" db:"location_code"`
+	Quantity         decimal.Decimal  `json:"quantity" db:"quantity"`
+	QuantityOnHand   decimal.Decimal  `json:"quantityOnHand" db:"quantity_on_hand"`
+	UnitCost         decimal.Decimal  `json:"unitCost" db:"unit_cost"`
+	CurrencyUomId    string           `json:"currencyUomId" db:"currency_uom_id"`
+	LotNumber        basic.NullString `json:"lotNumber" db:"lot_number"`
+	ExpiryDate       basic.NullTime   `json:"expiryDate" db:"expiry_date"`
+	PurchaseOrderId  *int64           `json:"purchaseOrderId" db:"purchase_order_id"`
+	PurchaseOrderSeq *int16           `json:"purchaseOrderSeq" db:"purchase_order_seq"`
+	GoodsReceiptId   *int64           `json:"goodsReceiptId" db:"goods_receipt_id"`
+	ReturnOrderId    *int64           `json:"returnOrderId" db:"return_order_id"`
+	ReturnOrderSeq   *int16           `json:"returnOrderSeq" db:"return_order_seq"`
+	TransferOrderId  *int64           `json:"transferOrderId" db:"-"`
+	CreatedAt        time.Time        `json:"createdAt" db:"created_at"`
+	UpdatedAt        time.Time        `json:"updatedAt" db:"updated_at"`
 }
 
 type WarehouseProductStatistics struct {
@@ -38,6 +49,40 @@ type WarehouseProductStatistics struct {
 	QuantityTotal      decimal.Decimal `json:"quantityTotal" db:"quantity_total"`
 	QuantityOnHand     decimal.Decimal `json:"quantityOnHand" db:"quantity_on_hand"`
 	QuantityAvailable  decimal.Decimal `json:"quantityAvailable" db:"quantity_available"`
+	QuantityInTransit  decimal.Decimal `json:"quantityInTransit" db:"quantity_in_transit"`
 	CreatedAt          time.Time       `json:"createdAt" db:"created_at"`
 	UpdatedAt          time.Time       `json:"updatedAt" db:"updated_at"`
 }
+
+type NearExpiryItem struct {
+	Id             int64            `json:"id" db:"id"`
+	ProductId      int64            `json:"productId" db:"product_id"`
+	ProductName    string           `json:"productName" db:"product_name"`
+	LocationId     *int             `json:"locationId" db:"location_id"`
+	LocationCode   basic.NullString `json:"locationCode" db:"location_code"`
+	LotNumber      basic.NullString `json:"lotNumber" db:"lot_number"`
+	ExpiryDate     time.Time        `json:"expiryDate" db:"expiry_date"`
+	DaysLeft       int              `json:"daysLeft" db:"days_left"`
+	QuantityOnHand decimal.Decimal  `json:"quantityOnHand" db:"quantity_on_hand"`
+	UnitCost       decimal.Decimal  `json:"unitCost" db:"unit_cost"`
+	CurrencyUomId  string           `json:"currencyUomId" db:"currency_uom_id"`
+}
+
+// ReceiptLine is one line of a goods receipt. Line is where it came
+// from, the row of the file or the position in the JSON array.
+type ReceiptLine struct {
+	Line          int              `json:"line"`
+	ProductId     int64            `json:"productId"`
+	Barcode       string           `json:"barcode"`
+	Quantity      decimal.Decimal  `json:"quantity"`
+	UnitCost      decimal.Decimal  `json:"unitCost"`
+	CurrencyUomId string           `json:"currencyUomId"`
+	LocationId    *int             `json:"locationId"`
+	LotNumber     basic.NullString `json:"lotNumber"`
+	ExpiryDate    basic.NullTime   `json:"expiryDate"`
+}
+
+type LineError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
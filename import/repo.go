@@ -1,13 +1,19 @@
 package importProduct
 
 import (
+	"baseweb/export"
+	"baseweb/ledger"
+	"baseweb/location"
+	"baseweb/product"
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
 )
 
 type Repo struct {
@@ -99,17 +105,224 @@ func (repo *Repo) InsertInventoryItem(
 	}
 	defer tx.Rollback()
 
+	if item.Barcode != "" {
+		item.ProductId, err = product.ResolveBarcode(ctx, tx, item.Barcode)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = ReceiveInventoryItem(ctx, tx, item)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// InsertGoodsReceipt receives every line of a goods receipt in one
+// transaction. Each line runs under a savepoint so a bad line does not
+// stop the others from being checked. When any line fails, including
+// those in invalid which could not even be read, nothing is kept and
+// the errors are returned instead.
+func (repo *Repo) InsertGoodsReceipt(
+	ctx context.Context,
+	warehouseId uuid.UUID, fileName string,
+	lines []ReceiptLine, invalid []LineError,
+	userLoginId uuid.UUID) (int64, []LineError, error) {
+
+	log.Println("InsertGoodsReceipt", warehouseId, fileName,
+		len(lines), len(invalid))
+
+	var id int64
+	lineErrors := append(make([]LineError, 0), invalid...)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return id, lineErrors, err
+	}
+	defer tx.Rollback()
+
+	query := repo.db.Rebind(`
+        insert into goods_receipt(
+        warehouse_id, file_name, line_count, created_by_user_login_id)
+        values (?, ?, ?, ?) returning id
+        `)
+	err = tx.GetContext(ctx, &id, query,
+		warehouseId, fileName, len(lines), userLoginId)
+	if err != nil {
+		return id, lineErrors, err
+	}
+
+	productQuery := repo.db.Rebind(
+		`select exists(select 1 from product where id = ?)`)
+	currencyQuery := repo.db.Rebind(
+		`select exists(select 1 from currency_uom where id = ?)`)
+
+	for _, line := range lines {
+		fail := func(message string) {
+			lineErrors = append(lineErrors, LineError{
+				Line: line.Line, Message: message})
+		}
+
+		if !line.Quantity.IsPositive() {
+			fail("quantity must be positive")
+			continue
+		}
+		if line.UnitCost.IsNegative() {
+			fail("unitCost must not be negative")
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, `savepoint goods_receipt_line`)
+		if err != nil {
+			return id, lineErrors, err
+		}
+
+		message, err := receiveLine(ctx, tx, id, warehouseId, line,
+			productQuery, currencyQuery)
+		if err != nil {
+			return id, lineErrors, err
+		}
+
+		if message != "" {
+			fail(message)
+			_, err = tx.ExecContext(ctx,
+				`rollback to savepoint goods_receipt_line`)
+		} else {
+			_, err = tx.ExecContext(ctx,
+				`release savepoint goods_receipt_line`)
+		}
+		if err != nil {
+			return id, lineErrors, err
+		}
+	}
+
+	if len(lineErrors) > 0 {
+		sort.Slice(lineErrors, func(i, j int) bool {
+			return lineErrors[i].Line < lineErrors[j].Line
+		})
+		return 0, lineErrors, nil
+	}
+
+	return id, lineErrors, tx.Commit()
+}
+
+// receiveLine gives back a message for what is wrong with line, or
+// receives it when nothing is.
+func receiveLine(
+	ctx context.Context, tx *sqlx.Tx,
+	goodsReceiptId int64, warehouseId uuid.UUID, line ReceiptLine,
+	productQuery, currencyQuery string) (string, error) {
+
+	var err error
+	if line.Barcode != "" {
+		line.ProductId, err = product.ResolveBarcode(ctx, tx, line.Barcode)
+		if err == product.ErrBarcode {
+			return "unknown barcode " + line.Barcode, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	exists := false
+	err = tx.GetContext(ctx, &exists, productQuery, line.ProductId)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return fmt.Sprintf("unknown product %d", line.ProductId), nil
+	}
+
+	err = tx.GetContext(ctx, &exists, currencyQuery, line.CurrencyUomId)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "unknown currency " + line.CurrencyUomId, nil
+	}
+
+	_, err = ReceiveInventoryItem(ctx, tx, InventoryItem{
+		ProductId:      line.ProductId,
+		WarehouseId:    warehouseId,
+		LocationId:     line.LocationId,
+		Quantity:       line.Quantity,
+		UnitCost:       line.UnitCost,
+		CurrencyUomId:  line.CurrencyUomId,
+		LotNumber:      line.LotNumber,
+		ExpiryDate:     line.ExpiryDate,
+		GoodsReceiptId: &goodsReceiptId,
+	})
+	if err == location.ErrNotBin {
+		return fmt.Sprintf("location %d is not a bin of the warehouse",
+			*line.LocationId), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return "", nil
+}
+
+// ReceiveInventoryItem puts a new item into a bin of its warehouse
+// and adds it to the warehouse statistics, returning the item id. An
+// item put into a quarantine bin is on hand but not available.
+func ReceiveInventoryItem(
+	ctx context.Context, tx *sqlx.Tx, item InventoryItem) (int64, error) {
+
+	log.Println("ReceiveInventoryItem", item.ProductId, item.WarehouseId,
+		item.LocationId, item.Quantity)
+
+	var id int64
+
+	if item.LocationId != nil {
+		err := location.ValidateBin(ctx, tx, item.WarehouseId, *item.LocationId)
+		if err != nil {
+			return id, err
+		}
+	} else {
+		suggestions, err := location.SuggestPutaway(ctx, tx,
+			item.WarehouseId, item.ProductId, item.Quantity)
+		if err != nil {
+			return id, err
+		}
+		if len(suggestions) > 0 {
+			item.LocationId = &suggestions[0].LocationId
+		}
+	}
+
 	item.QuantityOnHand = item.Quantity
 
+	quarantine, err := location.IsQuarantine(ctx, tx, item.LocationId)
+	if err != nil {
+		return id, err
+	}
+	available := item.Quantity
+	if quarantine {
+		available = decimal.Zero
+	}
+
 	query := `insert into inventory_item(product_id,
-        warehouse_id, quantity, quantity_on_hand,
-        unit_cost, currency_uom_id)
-    values (:product_id, :warehouse_id,
+        warehouse_id, location_id, quantity, quantity_on_hand,
+        unit_cost, currency_uom_id, lot_number, expiry_date,
+        purchase_order_id, purchase_order_seq, goods_receipt_id,
+        return_order_id, return_order_seq)
+    values (:product_id, :warehouse_id, :location_id,
         :quantity, :quantity_on_hand,
-        :unit_cost, :currency_uom_id)`
-	_, err = tx.NamedExecContext(ctx, query, item)
+        :unit_cost, :currency_uom_id, :lot_number, :expiry_date,
+        :purchase_order_id, :purchase_order_seq, :goods_receipt_id,
+        :return_order_id, :return_order_seq)
+    returning id`
+	stmt, err := tx.PrepareNamedContext(ctx, query)
 	if err != nil {
-		return err
+		return id, err
+	}
+	defer stmt.Close()
+
+	err = stmt.GetContext(ctx, &id, item)
+	if err != nil {
+		return id, err
 	}
 
 	stat := WarehouseProductStatistics{
@@ -117,7 +330,7 @@ func (repo *Repo) InsertInventoryItem(
 		ProductId:         item.ProductId,
 		QuantityTotal:     item.Quantity,
 		QuantityOnHand:    item.Quantity,
-		QuantityAvailable: item.Quantity,
+		QuantityAvailable: available,
 	}
 	query = `insert into warehouse_product_statistics(
         warehouse_id, product_id, inventory_item_count,
@@ -137,10 +350,42 @@ func (repo *Repo) InsertInventoryItem(
                 warehouse_product_statistics.quantity_available + :quantity_available`
 	_, err = tx.NamedExecContext(ctx, query, stat)
 	if err != nil {
-		return err
+		return id, err
 	}
 
-	return tx.Commit()
+	if available.IsPositive() {
+		err = export.ReadyBackorder(ctx, tx,
+			item.WarehouseId, item.ProductId, time.Now())
+		if err != nil {
+			return id, err
+		}
+	}
+
+	// goods of a transfer order arrive out of transit, the caller
+	// takes them off the in transit statistics
+	typeId := ledger.ReceiptType
+	inTransit := decimal.Zero
+	if item.ReturnOrderId != nil {
+		typeId = ledger.ReturnReceivedType
+	} else if item.TransferOrderId != nil {
+		typeId = ledger.TransferReceivedType
+		inTransit = item.Quantity.Neg()
+	}
+
+	err = ledger.Record(ctx, tx, ledger.Transaction{
+		TypeId:                typeId,
+		WarehouseId:           item.WarehouseId,
+		ProductId:             item.ProductId,
+		InventoryItemId:       &id,
+		QuantityOnHandDiff:    item.Quantity,
+		QuantityAvailableDiff: available,
+		QuantityInTransitDiff: inTransit,
+		PurchaseOrderId:       item.PurchaseOrderId,
+		PurchaseOrderSeq:      item.PurchaseOrderSeq,
+		TransferOrderId:       item.TransferOrderId,
+	})
+
+	return id, err
 }
 
 func (repo *Repo) SelectProductByWarehouse(
@@ -189,12 +434,15 @@ func (repo *Repo) ViewInventoryItemByWarehouse(
 
 	query = `select i.id, i.product_id,
         p.name as product_name,
-        i.warehouse_id, i.quantity, i.unit_cost,
-        i.currency_uom_id,
+        i.warehouse_id, i.location_id, l.code as location_code,
+        i.quantity, i.unit_cost,
+        i.currency_uom_id, i.lot_number, i.expiry_date,
         i.created_at, i.updated_at
         from inventory_item i 
         inner join product p
             on p.id = i.product_id
+        left join warehouse_location l
+            on l.id = i.location_id
         where i.warehouse_id = ?
         order by i.%s %s
         limit ? offset ?`
@@ -233,13 +481,16 @@ func (repo *Repo) ViewInventoryItemByProduct(
 
 	query = `select i.id, i.product_id,
         p.name as product_name,
-        i.warehouse_id, i.quantity, i.unit_cost,
-        i.currency_uom_id,
+        i.warehouse_id, i.location_id, l.code as location_code,
+        i.quantity, i.unit_cost,
+        i.currency_uom_id, i.lot_number, i.expiry_date,
         i.created_at, i.updated_at
         from inventory_item i 
         inner join product p
             on p.id = i.product_id
-        where i.warehouse_id = ? and product_id = ?
+        left join warehouse_location l
+            on l.id = i.location_id
+        where i.warehouse_id = ? and i.product_id = ?
         order by i.%s %s
         limit ? offset ?`
 	query = fmt.Sprintf(query, sortedBy, sortOrder)
@@ -250,3 +501,45 @@ func (repo *Repo) ViewInventoryItemByProduct(
 
 	return count, result, err
 }
+
+// ViewNearExpiry lists the items still on hand that expire within days,
+// already expired items come first with a negative days_left.
+func (repo *Repo) ViewNearExpiry(
+	ctx context.Context,
+	warehouseId uuid.UUID,
+	days, page, pageSize int) (int, []NearExpiryItem, error) {
+
+	log.Println("ViewNearExpiry", warehouseId, days, page, pageSize)
+
+	var count int
+	result := make([]NearExpiryItem, 0)
+
+	query := repo.db.Rebind(`
+        select count(*) from inventory_item
+        where warehouse_id = ? and quantity_on_hand > 0
+            and expiry_date < current_date + cast(? as integer) + 1
+        `)
+	err := repo.db.GetContext(ctx, &count, query, warehouseId, days)
+	if err != nil {
+		return count, result, err
+	}
+
+	query = repo.db.Rebind(`
+        select i.id, i.product_id, p.name as product_name,
+        i.location_id, l.code as location_code,
+        i.lot_number, i.expiry_date,
+        i.expiry_date - current_date as days_left,
+        i.quantity_on_hand, i.unit_cost, i.currency_uom_id
+        from inventory_item i
+            inner join product p on p.id = i.product_id
+            left join warehouse_location l on l.id = i.location_id
+        where i.warehouse_id = ? and i.quantity_on_hand > 0
+            and i.expiry_date < current_date + cast(? as integer) + 1
+        order by i.expiry_date, i.id
+        limit ? offset ?
+        `)
+	err = repo.db.SelectContext(ctx, &result, query,
+		warehouseId, days, pageSize, page*pageSize)
+
+	return count, result, err
+}
@@ -0,0 +1,380 @@
+package importProduct
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrSheetFormat = errors.New("unsupported sheet format")
+
+var ErrSheetSize = errors.New("sheet too large")
+
+// MAX_SHEET_COLUMNS is the widest row read from a spreadsheet, receipt
+// lines need far fewer columns.
+const MAX_SHEET_COLUMNS = 64
+
+// MAX_SHEET_ENTRY_SIZE caps how far a single file inside an XLSX archive
+// is decompressed.
+const MAX_SHEET_ENTRY_SIZE = 32 << 20
+
+// readSheet turns an uploaded CSV or XLSX file into rows of cells.
+// XLSX files are zip archives, which is how they are told apart.
+func readSheet(data []byte) ([][]string, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return readXlsx(data)
+	}
+
+	if !strings.HasPrefix(http.DetectContentType(data), "text/") {
+		return nil, ErrSheetFormat
+	}
+
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data,
+		[]byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	return r.ReadAll()
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		Id     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Id string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.R) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, r := range t.R {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Ref   int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// sizeLimitReader fails with ErrSheetSize once more than n bytes are read.
+type sizeLimitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		return 0, ErrSheetSize
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+func readZipXml(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return ErrSheetFormat
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return xml.NewDecoder(&sizeLimitReader{
+		r: rc, n: MAX_SHEET_ENTRY_SIZE}).Decode(v)
+}
+
+// readXlsx reads the first worksheet of a workbook. Only what a
+// spreadsheet needs to hold receipt lines is understood: shared,
+// inline and plain values.
+func readXlsx(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*zip.File)
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	sheetName := "xl/worksheets/sheet1.xml"
+	workbook := xlsxWorkbook{}
+	rels := xlsxRelationships{}
+	if readZipXml(files, "xl/workbook.xml", &workbook) == nil &&
+		readZipXml(files, "xl/_rels/workbook.xml.rels", &rels) == nil &&
+		len(workbook.Sheets) > 0 {
+
+		for _, rel := range rels.Relationships {
+			if rel.Id != workbook.Sheets[0].Id {
+				continue
+			}
+			if strings.HasPrefix(rel.Target, "/") {
+				sheetName = strings.TrimPrefix(rel.Target, "/")
+			} else {
+				sheetName = path.Join("xl", rel.Target)
+			}
+		}
+	}
+
+	shared := xlsxSharedStrings{}
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		err = readZipXml(files, "xl/sharedStrings.xml", &shared)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sheet := xlsxWorksheet{}
+	err = readZipXml(files, sheetName, &sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		if row.Ref > MAX_RECEIPT_LINES+1 {
+			return nil, ErrSheetSize
+		}
+		// empty rows are left out of the file, put them back so
+		// row numbers in the error report match the spreadsheet
+		for row.Ref > len(rows)+1 {
+			rows = append(rows, []string{})
+		}
+
+		cells := make([]string, 0, len(row.Cells))
+		for _, c := range row.Cells {
+			column := columnIndex(c.Ref)
+			if column < 0 {
+				column = len(cells)
+			}
+			if column >= MAX_SHEET_COLUMNS {
+				return nil, ErrSheetSize
+			}
+			for len(cells) <= column {
+				cells = append(cells, "")
+			}
+
+			switch c.Type {
+			case "s":
+				i, err := strconv.Atoi(c.Value)
+				if err != nil || i < 0 || i >= len(shared.Items) {
+					return nil, ErrSheetFormat
+				}
+				cells[column] = shared.Items[i].String()
+			case "inlineStr":
+				cells[column] = c.Inline.String()
+			default:
+				cells[column] = c.Value
+			}
+		}
+		rows = append(rows, cells)
+		if len(rows) > MAX_RECEIPT_LINES+1 {
+			return nil, ErrSheetSize
+		}
+	}
+
+	return rows, nil
+}
+
+// columnIndex gives the zero based column of a cell reference like
+// "C12", or -1 when there is none. Columns past MAX_SHEET_COLUMNS all
+// give MAX_SHEET_COLUMNS.
+func columnIndex(ref string) int {
+	index := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		index = index*26 + int(ch-'A'+1)
+		if index > MAX_SHEET_COLUMNS {
+			return MAX_SHEET_COLUMNS
+		}
+	}
+	return index - 1
+}
+
+// receiptColumns maps the accepted header names, lower cased and without
+// spaces or underscores, to the receipt line field they fill.
+var receiptColumns = map[string]string{
+	"productid":     "productId",
+	"product":       "productId",
+	"barcode":       "barcode",
+	"gtin":          "barcode",
+	"quantity":      "quantity",
+	"qty":           "quantity",
+	"unitcost":      "unitCost",
+	"cost":          "unitCost",
+	"currencyuomid": "currencyUomId",
+	"currency":      "currencyUomId",
+	"locationid":    "locationId",
+	"lotnumber":     "lotNumber",
+	"lot":           "lotNumber",
+	"expirydate":    "expiryDate",
+	"expiry":        "expiryDate",
+}
+
+// excelEpoch is day zero of spreadsheet date serials.
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+func parseSheetDate(value string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02", value)
+	if err == nil {
+		return t, nil
+	}
+	serial, serialErr := strconv.ParseFloat(value, 64)
+	if serialErr != nil {
+		return t, err
+	}
+	return excelEpoch.AddDate(0, 0, int(serial)), nil
+}
+
+// parseReceiptRows reads the header row and then one receipt line per
+// row, skipping blank rows. Rows that cannot be read are reported with
+// their 1-based row number instead.
+func parseReceiptRows(rows [][]string) ([]ReceiptLine, []LineError) {
+	lines := make([]ReceiptLine, 0)
+	lineErrors := make([]LineError, 0)
+
+	if len(rows) == 0 {
+		lineErrors = append(lineErrors, LineError{
+			Line: 1, Message: "missing header row"})
+		return lines, lineErrors
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		key := strings.ToLower(strings.TrimSpace(name))
+		key = strings.NewReplacer(" ", "", "_", "").Replace(key)
+		if field, ok := receiptColumns[key]; ok {
+			columns[field] = i
+		}
+	}
+
+	_, hasProduct := columns["productId"]
+	_, hasBarcode := columns["barcode"]
+	_, hasQuantity := columns["quantity"]
+	if !(hasProduct || hasBarcode) || !hasQuantity {
+		lineErrors = append(lineErrors, LineError{
+			Line:    1,
+			Message: "header needs productId or barcode, and quantity"})
+		return lines, lineErrors
+	}
+
+	for r := 1; r < len(rows); r++ {
+		row := rows[r]
+		cell := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+
+		line := ReceiptLine{
+			Line:          r + 1,
+			Barcode:       cell("barcode"),
+			CurrencyUomId: cell("currencyUomId"),
+		}
+		fail := func(message string) {
+			lineErrors = append(lineErrors, LineError{
+				Line: line.Line, Message: message})
+		}
+
+		var err error
+		if value := cell("productId"); value != "" {
+			line.ProductId, err = strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				fail("invalid productId " + value)
+				continue
+			}
+		}
+
+		line.Quantity, err = decimal.NewFromString(cell("quantity"))
+		if err != nil {
+			fail("invalid quantity " + cell("quantity"))
+			continue
+		}
+
+		if value := cell("unitCost"); value != "" {
+			line.UnitCost, err = decimal.NewFromString(value)
+			if err != nil {
+				fail("invalid unitCost " + value)
+				continue
+			}
+		}
+
+		if value := cell("locationId"); value != "" {
+			locationId, err := strconv.Atoi(value)
+			if err != nil {
+				fail("invalid locationId " + value)
+				continue
+			}
+			line.LocationId = &locationId
+		}
+
+		if value := cell("lotNumber"); value != "" {
+			line.LotNumber.Valid = true
+			line.LotNumber.String = value
+		}
+
+		if value := cell("expiryDate"); value != "" {
+			line.ExpiryDate.Time, err = parseSheetDate(value)
+			if err != nil {
+				fail("invalid expiryDate " + value)
+				continue
+			}
+			line.ExpiryDate.Valid = true
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, lineErrors
+}
@@ -0,0 +1,263 @@
+package importProduct
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// makeXlsx zips the given entries into a workbook.
+func makeXlsx(t *testing.T, entries map[string]string) []byte {
+	t.Helper()
+
+	buf := bytes.Buffer{}
+	zw := zip.NewWriter(&buf)
+	for name, content := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = w.Write([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := zw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func worksheet(sheetData string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<sheetData>` + sheetData + `</sheetData></worksheet>`
+}
+
+func TestReadSheetCsv(t *testing.T) {
+	data := []byte("\xef\xbb\xbfproductId,quantity\n1, 5\n")
+
+	rows, err := readSheet(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{{"productId", "quantity"}, {"1", "5"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %v, want %v", rows, want)
+	}
+}
+
+func TestReadSheetBinary(t *testing.T) {
+	_, err := readSheet([]byte{0x00, 0x01, 0x02, 0xff})
+	if err != ErrSheetFormat {
+		t.Errorf("got %v, want %v", err, ErrSheetFormat)
+	}
+}
+
+func TestReadXlsx(t *testing.T) {
+	data := makeXlsx(t, map[string]string{
+		"xl/sharedStrings.xml": `<sst><si><t>productId</t></si>` +
+			`<si><r><t>quan</t></r><r><t>tity</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": worksheet(
+			`<row r="1"><c r="A1" t="s"><v>0</v></c>` +
+				`<c r="B1" t="s"><v>1</v></c></row>` +
+				`<row r="3"><c r="A3"><v>7</v></c>` +
+				`<c r="C3" t="inlineStr"><is><t>x</t></is></c></row>`),
+	})
+
+	rows, err := readSheet(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"productId", "quantity"},
+		{},
+		{"7", "", "x"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %q, want %q", rows, want)
+	}
+}
+
+func TestReadXlsxLimits(t *testing.T) {
+	manyRows := strings.Repeat(`<row><c><v>1</v></c></row>`,
+		MAX_RECEIPT_LINES+2)
+
+	tests := []struct {
+		name  string
+		sheet string
+		err   error
+	}{
+		{
+			name:  "last allowed row",
+			sheet: fmt.Sprintf(`<row r="%d"><c><v>1</v></c></row>`, MAX_RECEIPT_LINES+1),
+			err:   nil,
+		},
+		{
+			name:  "row number too large",
+			sheet: `<row r="2000000000"><c><v>1</v></c></row>`,
+			err:   ErrSheetSize,
+		},
+		{
+			name:  "column too large",
+			sheet: `<row r="1"><c r="XFDXFDXFD1"><v>1</v></c></row>`,
+			err:   ErrSheetSize,
+		},
+		{
+			name: "too many cells without reference",
+			sheet: `<row r="1">` +
+				strings.Repeat(`<c><v>1</v></c>`, MAX_SHEET_COLUMNS+1) +
+				`</row>`,
+			err: ErrSheetSize,
+		},
+		{
+			name:  "too many rows",
+			sheet: manyRows,
+			err:   ErrSheetSize,
+		},
+		{
+			name:  "decompressed too large",
+			sheet: strings.Repeat(" ", MAX_SHEET_ENTRY_SIZE),
+			err:   ErrSheetSize,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := makeXlsx(t, map[string]string{
+				"xl/worksheets/sheet1.xml": worksheet(tt.sheet),
+			})
+			_, err := readSheet(data)
+			if err != tt.err {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want int
+	}{
+		{"A1", 0},
+		{"C12", 2},
+		{"Z3", 25},
+		{"AA3", 26},
+		{"BL1", 63},
+		{"BM1", MAX_SHEET_COLUMNS},
+		{"XFDXFDXFDXFD1", MAX_SHEET_COLUMNS},
+		{"12", -1},
+		{"", -1},
+	}
+
+	for _, tt := range tests {
+		got := columnIndex(tt.ref)
+		if got != tt.want {
+			t.Errorf("columnIndex(%q) = %d, want %d", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestParseReceiptRows(t *testing.T) {
+	tests := []struct {
+		name   string
+		rows   [][]string
+		lines  []int
+		errors []LineError
+	}{
+		{
+			name:   "no rows",
+			rows:   [][]string{},
+			errors: []LineError{{Line: 1, Message: "missing header row"}},
+		},
+		{
+			name: "header without quantity",
+			rows: [][]string{{"productId", "cost"}, {"1", "2"}},
+			errors: []LineError{{Line: 1,
+				Message: "header needs productId or barcode, and quantity"}},
+		},
+		{
+			name:  "aliases and blank rows",
+			rows:  [][]string{{"GTIN", "Qty"}, {"4006381333931", "2"}, {" ", ""}, {"", "3"}},
+			lines: []int{2, 4},
+		},
+		{
+			name: "invalid cells",
+			rows: [][]string{
+				{"product_id", "quantity", "location id"},
+				{"x", "1", ""},
+				{"1", "one", ""},
+				{"1", "1", "bin"},
+				{"1", "1", "7"},
+			},
+			lines: []int{5},
+			errors: []LineError{
+				{Line: 2, Message: "invalid productId x"},
+				{Line: 3, Message: "invalid quantity one"},
+				{Line: 4, Message: "invalid locationId bin"},
+			},
+		},
+		{
+			name:   "short row",
+			rows:   [][]string{{"productId", "quantity"}, {"1"}},
+			errors: []LineError{{Line: 2, Message: "invalid quantity "}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, lineErrors := parseReceiptRows(tt.rows)
+
+			got := make([]int, 0)
+			for _, line := range lines {
+				got = append(got, line.Line)
+			}
+			want := tt.lines
+			if want == nil {
+				want = []int{}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("lines %v, want %v", got, want)
+			}
+
+			wantErrors := tt.errors
+			if wantErrors == nil {
+				wantErrors = []LineError{}
+			}
+			if !reflect.DeepEqual(lineErrors, wantErrors) {
+				t.Errorf("errors %v, want %v", lineErrors, wantErrors)
+			}
+		})
+	}
+}
+
+func TestParseReceiptRowsFields(t *testing.T) {
+	lines, lineErrors := parseReceiptRows([][]string{
+		{"productId", "quantity", "unitCost", "currency", "lot", "expiry"},
+		{"12", "2.5", "1000", "VND", "L-1", "45658"},
+	})
+	if len(lineErrors) != 0 || len(lines) != 1 {
+		t.Fatalf("got %v, %v", lines, lineErrors)
+	}
+
+	line := lines[0]
+	if line.ProductId != 12 || line.Quantity.String() != "2.5" ||
+		line.UnitCost.String() != "1000" || line.CurrencyUomId != "VND" {
+		t.Errorf("got %+v", line)
+	}
+	if !line.LotNumber.Valid || line.LotNumber.String != "L-1" {
+		t.Errorf("lot number %+v", line.LotNumber)
+	}
+	want := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if !line.ExpiryDate.Valid || !line.ExpiryDate.Time.Equal(want) {
+		t.Errorf("expiry date %v, want %v", line.ExpiryDate.Time, want)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+func InventoryRoutes(root *Root) {
+	root.GetAuthorized(
+		"/api/inventory/view-adjustment-reason",
+		"IMPORT",
+		root.inventory.ViewAdjustmentReasonHandler)
+
+	root.PostIdempotent(
+		"/api/inventory/add-cycle-count",
+		"IMPORT",
+		root.inventory.AddCycleCountHandler)
+
+	root.GetAuthorized(
+		"/api/inventory/view-cycle-count",
+		"IMPORT",
+		root.inventory.ViewCycleCountHandler)
+
+	root.GetAuthorized(
+		"/api/inventory/view-single-cycle-count",
+		"IMPORT",
+		root.inventory.ViewSingleCycleCountHandler)
+
+	root.PostAuthorized(
+		"/api/inventory/record-count",
+		"IMPORT",
+		root.inventory.RecordCountHandler)
+
+	root.PostAuthorized(
+		"/api/inventory/submit-cycle-count",
+		"IMPORT",
+		root.inventory.SubmitCycleCountHandler)
+
+	root.PostAuthorized(
+		"/api/inventory/post-cycle-count",
+		"VIEW_EDIT_FACILITY",
+		root.inventory.PostCycleCountHandler)
+
+	root.PostAuthorized(
+		"/api/inventory/cancel-cycle-count",
+		"IMPORT",
+		root.inventory.CancelCycleCountHandler)
+
+	root.PostAuthorized(
+		"/api/inventory/adjust-inventory-item",
+		"VIEW_EDIT_FACILITY",
+		root.inventory.AdjustInventoryItemHandler)
+
+	root.GetAuthorized(
+		"/api/inventory/view-inventory-adjustment",
+		"IMPORT",
+		root.inventory.ViewInventoryAdjustmentHandler)
+
+	root.PostAuthorized(
+		"/api/inventory/release-quarantine",
+		"VIEW_EDIT_FACILITY",
+		root.inventory.ReleaseQuarantineHandler)
+}
@@ -0,0 +1,366 @@
+package inventory
+
+import (
+	"baseweb/basic"
+	"baseweb/security"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Root struct {
+	repo *Repo
+}
+
+func InitRoot(repo *Repo) *Root {
+	return &Root{
+		repo: repo,
+	}
+}
+
+var bodyError = errors.New("body constraints violation")
+
+func (root *Root) ViewAdjustmentReasonHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	reasons, err := root.repo.SelectAdjustmentReason(ctx)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		AdjustmentReasonList []AdjustmentReason `json:"adjustmentReasonList"`
+	}
+
+	res := Response{
+		AdjustmentReasonList: reasons,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) AddCycleCountHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+	type Request struct {
+		WarehouseId uuid.UUID `json:"warehouseId"`
+		LocationId  *int      `json:"locationId"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	id, err := root.repo.InsertCycleCount(ctx,
+		req.WarehouseId, req.LocationId, userLogin.Id)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		Id int64 `json:"id"`
+	}
+
+	res := Response{
+		Id: id,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewCycleCountHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	statusId, err := strconv.Atoi(query.Get("statusId"))
+	if err != nil {
+		statusId = 0
+	}
+
+	sortedBy := "created_at"
+	sortedByQuery := query.Get("sortedBy")
+	if sortedByQuery == "updatedAt" {
+		sortedBy = "updated_at"
+	} else if sortedByQuery == "postedAt" {
+		sortedBy = "posted_at"
+	}
+
+	sortOrder := "desc"
+	sortOrderQuery := query.Get("sortOrder")
+	if sortOrderQuery == "asc" {
+		sortOrder = "asc"
+	}
+
+	count, cycleCounts, err := root.repo.ViewCycleCount(ctx,
+		int16(statusId), page, pageSize, sortedBy, sortOrder)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		CycleCountCount int          `json:"cycleCountCount"`
+		CycleCountList  []CycleCount `json:"cycleCountList"`
+	}
+
+	res := Response{
+		CycleCountCount: count,
+		CycleCountList:  cycleCounts,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewSingleCycleCountHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	id, err := strconv.ParseInt(query.Get("cycleCountId"), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	cycleCount, items, variances, err := root.repo.GetCycleCount(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		CycleCount CycleCount       `json:"cycleCount"`
+		Items      []CycleCountItem `json:"items"`
+		Variances  []Variance       `json:"variances"`
+	}
+
+	res := Response{
+		CycleCount: cycleCount,
+		Items:      items,
+		Variances:  variances,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) RecordCountHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Id    int64         `json:"id"`
+		Items []CountedItem `json:"items"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range req.Items {
+		if item.CountedQuantity.IsNegative() {
+			return bodyError
+		}
+	}
+
+	err = root.repo.RecordCount(ctx, req.Id, req.Items)
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) SubmitCycleCountHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Id int64 `json:"id"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.SubmitCycleCount(ctx, req.Id)
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) PostCycleCountHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+	type Request struct {
+		Id int64 `json:"id"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.PostCycleCount(ctx, req.Id, userLogin.Id, time.Now())
+	if err != nil {
+		return basic.ReturnConflict(w, err,
+			ErrCycleCountStatus, ErrNegativeQuantity, ErrReservedQuantity)
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) CancelCycleCountHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Id int64 `json:"id"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.CancelCycleCount(ctx, req.Id)
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) AdjustInventoryItemHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+	adjustment := Adjustment{}
+	err := json.NewDecoder(r.Body).Decode(&adjustment)
+	if err != nil {
+		return err
+	}
+
+	if adjustment.Quantity.IsZero() ||
+		adjustment.AdjustmentReasonId < CountDiscrepancyReason ||
+		adjustment.AdjustmentReasonId > FoundReason {
+		return bodyError
+	}
+	adjustment.CycleCountId = nil
+	adjustment.CreatedBy = userLogin.Id
+
+	err = root.repo.AdjustInventoryItem(ctx, adjustment)
+	if err != nil {
+		return basic.ReturnConflict(w, err,
+			ErrNegativeQuantity, ErrReservedQuantity)
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) ViewInventoryAdjustmentHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	warehouseId, err := uuid.Parse(query.Get("warehouseId"))
+	if err != nil {
+		return err
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	sortOrder := "desc"
+	sortOrderQuery := query.Get("sortOrder")
+	if sortOrderQuery == "asc" {
+		sortOrder = "asc"
+	}
+
+	count, adjustments, err := root.repo.ViewInventoryAdjustment(ctx,
+		warehouseId, page, pageSize, sortOrder)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		AdjustmentCount int                `json:"adjustmentCount"`
+		AdjustmentList  []ClientAdjustment `json:"adjustmentList"`
+	}
+
+	res := Response{
+		AdjustmentCount: count,
+		AdjustmentList:  adjustments,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ReleaseQuarantineHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		InventoryItemId int64 `json:"inventoryItemId"`
+		LocationId      int   `json:"locationId"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.ReleaseQuarantine(ctx,
+		req.InventoryItemId, req.LocationId)
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
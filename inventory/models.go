@@ -0,0 +1,94 @@
+package inventory
+
+import (
+	"baseweb/basic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	OpenStatus      int16 = 1
+	SubmittedStatus int16 = 2
+	PostedStatus    int16 = 3
+	CanceledStatus  int16 = 4
+)
+
+const (
+	CountDiscrepancyReason int16 = 1
+	DamagedReason          int16 = 2
+	ExpiredReason          int16 = 3
+	LostReason             int16 = 4
+	FoundReason            int16 = 5
+)
+
+type AdjustmentReason struct {
+	Id   int16  `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+}
+
+type CycleCount struct {
+	Id            int64            `json:"id" db:"id"`
+	WarehouseId   uuid.UUID        `json:"warehouseId" db:"warehouse_id"`
+	WarehouseName string           `json:"warehouseName" db:"warehouse_name"`
+	LocationId    *int             `json:"locationId" db:"location_id"`
+	LocationCode  basic.NullString `json:"locationCode" db:"location_code"`
+	StatusId      int16            `json:"statusId" db:"cycle_count_status_id"`
+	CreatedBy     string           `json:"createdBy" db:"created_by"`
+	ApprovedBy    basic.NullString `json:"approvedBy" db:"approved_by"`
+	PostedAt      basic.NullTime   `json:"postedAt" db:"posted_at"`
+	CreatedAt     time.Time        `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time        `json:"updatedAt" db:"updated_at"`
+}
+
+type CycleCountItem struct {
+	Seq                int                 `json:"seq" db:"cycle_count_seq"`
+	InventoryItemId    int64               `json:"inventoryItemId" db:"inventory_item_id"`
+	ProductId          int64               `json:"productId" db:"product_id"`
+	ProductName        string              `json:"productName" db:"product_name"`
+	LocationId         *int                `json:"locationId" db:"location_id"`
+	LocationCode       basic.NullString    `json:"locationCode" db:"location_code"`
+	SystemQuantity     decimal.Decimal     `json:"systemQuantity" db:"system_quantity"`
+	CountedQuantity    decimal.NullDecimal `json:"countedQuantity" db:"counted_quantity"`
+	AdjustmentReasonId *int16              `json:"adjustmentReasonId" db:"adjustment_reason_id"`
+}
+
+type CountedItem struct {
+	Seq                int             `json:"seq"`
+	CountedQuantity    decimal.Decimal `json:"countedQuantity"`
+	AdjustmentReasonId *int16          `json:"adjustmentReasonId"`
+}
+
+// Variance compares, per product, the counted quantity with what
+// warehouse_product_statistics says is on hand in the counted scope.
+type Variance struct {
+	ProductId        int64           `json:"productId" db:"product_id"`
+	ProductName      string          `json:"productName" db:"product_name"`
+	QuantityOnHand   decimal.Decimal `json:"quantityOnHand" db:"quantity_on_hand"`
+	ExpectedQuantity decimal.Decimal `json:"expectedQuantity" db:"expected_quantity"`
+	CountedQuantity  decimal.Decimal `json:"countedQuantity" db:"counted_quantity"`
+	Variance         decimal.Decimal `json:"variance" db:"variance"`
+}
+
+type Adjustment struct {
+	InventoryItemId    int64           `json:"inventoryItemId" db:"inventory_item_id"`
+	Quantity           decimal.Decimal `json:"quantity" db:"quantity"`
+	AdjustmentReasonId int16           `json:"adjustmentReasonId" db:"adjustment_reason_id"`
+	Note               string          `json:"note" db:"note"`
+	CycleCountId       *int64          `json:"cycleCountId" db:"cycle_count_id"`
+	CreatedBy          uuid.UUID       `json:"createdBy" db:"created_by_user_login_id"`
+}
+
+type ClientAdjustment struct {
+	Id                 int64           `json:"id" db:"id"`
+	InventoryItemId    int64           `json:"inventoryItemId" db:"inventory_item_id"`
+	ProductId          int64           `json:"productId" db:"product_id"`
+	ProductName        string          `json:"productName" db:"product_name"`
+	Quantity           decimal.Decimal `json:"quantity" db:"quantity"`
+	AdjustmentReasonId int16           `json:"adjustmentReasonId" db:"adjustment_reason_id"`
+	Note               string          `json:"note" db:"note"`
+	CycleCountId       *int64          `json:"cycleCountId" db:"cycle_count_id"`
+	CreatedBy          string          `json:"createdBy" db:"created_by"`
+	CreatedAt          time.Time       `json:"createdAt" db:"created_at"`
+}
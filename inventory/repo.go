@@ -0,0 +1,704 @@
+package inventory
+
+import (
+	"baseweb/ledger"
+	"baseweb/location"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func InitRepo(db *sqlx.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+var ErrCycleCountStatus = errors.New("cycle count status transition not allowed")
+
+var ErrNotCounted = errors.New("cycle count has uncounted items")
+
+var ErrLocation = errors.New("location is not in the warehouse")
+
+var ErrNegativeQuantity = errors.New("adjustment makes quantity on hand negative")
+
+var ErrReservedQuantity = errors.New("adjustment takes reserved quantity")
+
+var cycleCountStatusTransitions = map[int16][]int16{
+	OpenStatus:      {SubmittedStatus, CanceledStatus},
+	SubmittedStatus: {PostedStatus, CanceledStatus},
+}
+
+func (repo *Repo) SelectAdjustmentReason(
+	ctx context.Context) ([]AdjustmentReason, error) {
+
+	log.Println("SelectAdjustmentReason")
+
+	result := make([]AdjustmentReason, 0)
+	query := `select id, name from adjustment_reason order by id`
+	err := repo.db.SelectContext(ctx, &result, query)
+	return result, err
+}
+
+func (repo *Repo) InsertCycleCount(
+	ctx context.Context,
+	warehouseId uuid.UUID, locationId *int,
+	userLoginId uuid.UUID) (int64, error) {
+
+	log.Println("InsertCycleCount", warehouseId, locationId)
+
+	var id int64
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return id, err
+	}
+	defer tx.Rollback()
+
+	if locationId != nil {
+		var count int
+		query := repo.db.Rebind(`
+            select count(*) from warehouse_location
+            where id = ? and warehouse_id = ?
+            `)
+		err = tx.GetContext(ctx, &count, query, *locationId, warehouseId)
+		if err != nil {
+			return id, err
+		}
+		if count == 0 {
+			return id, ErrLocation
+		}
+	}
+
+	query := repo.db.Rebind(`
+        insert into cycle_count(
+        warehouse_id, location_id,
+        cycle_count_status_id, created_by_user_login_id)
+        values (?, ?, ?, ?) returning id
+        `)
+	err = tx.GetContext(ctx, &id, query,
+		warehouseId, locationId, OpenStatus, userLoginId)
+	if err != nil {
+		return id, err
+	}
+
+	// the count sheet holds every item on hand in the location subtree,
+	// or in the whole warehouse when no location is given
+	query = repo.db.Rebind(`
+        with recursive scope as (
+            select id from warehouse_location where id = ?
+            union all
+            select l.id from warehouse_location l
+                inner join scope on l.parent_id = scope.id
+        )
+        insert into cycle_count_item(
+        cycle_count_id, cycle_count_seq,
+        inventory_item_id, product_id, location_id,
+        system_quantity)
+        select ?, row_number() over (order by l.code, i.product_id, i.id),
+        i.id, i.product_id, i.location_id,
+        i.quantity_on_hand
+        from inventory_item i
+            left join warehouse_location l on l.id = i.location_id
+        where i.warehouse_id = ? and i.quantity_on_hand > 0
+            and (cast(? as integer) is null
+                or i.location_id in (select id from scope))
+        `)
+	_, err = tx.ExecContext(ctx, query,
+		locationId, id, warehouseId, locationId)
+	if err != nil {
+		return id, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (repo *Repo) ViewCycleCount(
+	ctx context.Context,
+	statusId int16,
+	page, pageSize int,
+	sortedBy, sortOrder string) (int, []CycleCount, error) {
+
+	log.Println("ViewCycleCount", statusId,
+		page, pageSize, sortedBy, sortOrder)
+
+	var count int
+	result := make([]CycleCount, 0)
+
+	query := repo.db.Rebind(`
+        select count(*) from cycle_count
+        where ? = 0 or cycle_count_status_id = ?
+        `)
+	err := repo.db.GetContext(ctx, &count, query, statusId, statusId)
+	if err != nil {
+		return count, result, err
+	}
+
+	query = `select c.id, c.warehouse_id, f.name as warehouse_name,
+        c.location_id, l.code as location_code,
+        c.cycle_count_status_id,
+        u.username as created_by, a.username as approved_by,
+        c.posted_at, c.created_at, c.updated_at
+        from cycle_count c
+            inner join facility f on f.id = c.warehouse_id
+            inner join user_login u on u.id = c.created_by_user_login_id
+            left join user_login a on a.id = c.approved_by_user_login_id
+            left join warehouse_location l on l.id = c.location_id
+        where ? = 0 or c.cycle_count_status_id = ?
+        order by c.%s %s
+        offset ? limit ?`
+	query = fmt.Sprintf(query, sortedBy, sortOrder)
+	query = repo.db.Rebind(query)
+	err = repo.db.SelectContext(ctx, &result, query,
+		statusId, statusId, page*pageSize, pageSize)
+
+	return count, result, err
+}
+
+func (repo *Repo) GetCycleCount(
+	ctx context.Context, id int64) (
+	CycleCount, []CycleCountItem, []Variance, error) {
+
+	log.Println("GetCycleCount", id)
+
+	cycleCount := CycleCount{}
+	items := make([]CycleCountItem, 0)
+	variances := make([]Variance, 0)
+
+	query := repo.db.Rebind(`
+        select c.id, c.warehouse_id, f.name as warehouse_name,
+        c.location_id, l.code as location_code,
+        c.cycle_count_status_id,
+        u.username as created_by, a.username as approved_by,
+        c.posted_at, c.created_at, c.updated_at
+        from cycle_count c
+            inner join facility f on f.id = c.warehouse_id
+            inner join user_login u on u.id = c.created_by_user_login_id
+            left join user_login a on a.id = c.approved_by_user_login_id
+            left join warehouse_location l on l.id = c.location_id
+        where c.id = ?
+        `)
+	err := repo.db.GetContext(ctx, &cycleCount, query, id)
+	if err != nil {
+		return cycleCount, items, variances, err
+	}
+
+	query = repo.db.Rebind(`
+        select ci.cycle_count_seq, ci.inventory_item_id,
+        ci.product_id, p.name as product_name,
+        ci.location_id, l.code as location_code,
+        ci.system_quantity, ci.counted_quantity,
+        ci.adjustment_reason_id
+        from cycle_count_item ci
+            inner join product p on p.id = ci.product_id
+            left join warehouse_location l on l.id = ci.location_id
+        where ci.cycle_count_id = ?
+        order by ci.cycle_count_seq
+        `)
+	err = repo.db.SelectContext(ctx, &items, query, id)
+	if err != nil {
+		return cycleCount, items, variances, err
+	}
+
+	// the statistics cover the whole warehouse, what is on hand
+	// outside of the count sheet is taken out of the expected quantity
+	query = repo.db.Rebind(`
+        select ci.product_id, p.name as product_name,
+        coalesce(s.quantity_on_hand, 0) as quantity_on_hand,
+        coalesce(s.quantity_on_hand, 0) - coalesce((
+            select sum(i.quantity_on_hand) from inventory_item i
+            where i.warehouse_id = c.warehouse_id
+                and i.product_id = ci.product_id
+                and not exists (
+                    select 1 from cycle_count_item x
+                    where x.cycle_count_id = c.id
+                        and x.inventory_item_id = i.id)
+        ), 0) as expected_quantity,
+        coalesce(sum(ci.counted_quantity), 0) as counted_quantity,
+        0 as variance
+        from cycle_count c
+            inner join cycle_count_item ci on ci.cycle_count_id = c.id
+            inner join product p on p.id = ci.product_id
+            left join warehouse_product_statistics s
+                on s.warehouse_id = c.warehouse_id
+                and s.product_id = ci.product_id
+        where c.id = ?
+        group by c.id, c.warehouse_id, ci.product_id,
+            p.name, s.quantity_on_hand
+        order by ci.product_id
+        `)
+	err = repo.db.SelectContext(ctx, &variances, query, id)
+	if err != nil {
+		return cycleCount, items, variances, err
+	}
+
+	for i := range variances {
+		v := &variances[i]
+		v.Variance = v.CountedQuantity.Sub(v.ExpectedQuantity)
+	}
+
+	return cycleCount, items, variances, nil
+}
+
+func lockCycleCount(
+	ctx context.Context, tx *sqlx.Tx,
+	id int64, statusId int16) (int16, error) {
+
+	var current int16
+	query := tx.Rebind(`
+        select cycle_count_status_id from cycle_count
+        where id = ? for update
+        `)
+	err := tx.GetContext(ctx, &current, query, id)
+	if err != nil {
+		return current, err
+	}
+
+	for _, next := range cycleCountStatusTransitions[current] {
+		if next == statusId {
+			return current, nil
+		}
+	}
+	return current, ErrCycleCountStatus
+}
+
+func (repo *Repo) RecordCount(
+	ctx context.Context, id int64, items []CountedItem) error {
+
+	log.Println("RecordCount", id, len(items))
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// counts can only be recorded while the sheet could still be submitted
+	_, err = lockCycleCount(ctx, tx, id, SubmittedStatus)
+	if err != nil {
+		return err
+	}
+
+	query := repo.db.Rebind(`
+        update cycle_count_item
+        set counted_quantity = ?, adjustment_reason_id = ?
+        where cycle_count_id = ? and cycle_count_seq = ?
+        `)
+	for _, item := range items {
+		_, err = tx.ExecContext(ctx, query,
+			item.CountedQuantity, item.AdjustmentReasonId, id, item.Seq)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (repo *Repo) SubmitCycleCount(ctx context.Context, id int64) error {
+	log.Println("SubmitCycleCount", id)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = lockCycleCount(ctx, tx, id, SubmittedStatus)
+	if err != nil {
+		return err
+	}
+
+	var uncounted int
+	query := repo.db.Rebind(`
+        select count(*) from cycle_count_item
+        where cycle_count_id = ? and counted_quantity is null
+        `)
+	err = tx.GetContext(ctx, &uncounted, query, id)
+	if err != nil {
+		return err
+	}
+	if uncounted > 0 {
+		return ErrNotCounted
+	}
+
+	query = repo.db.Rebind(`
+        update cycle_count set cycle_count_status_id = ?
+        where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, SubmittedStatus, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *Repo) PostCycleCount(
+	ctx context.Context, id int64,
+	userLoginId uuid.UUID, now time.Time) error {
+
+	log.Println("PostCycleCount", id, userLoginId)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = lockCycleCount(ctx, tx, id, PostedStatus)
+	if err != nil {
+		return err
+	}
+
+	type Item struct {
+		InventoryItemId    int64           `db:"inventory_item_id"`
+		SystemQuantity     decimal.Decimal `db:"system_quantity"`
+		CountedQuantity    decimal.Decimal `db:"counted_quantity"`
+		AdjustmentReasonId *int16          `db:"adjustment_reason_id"`
+	}
+	items := make([]Item, 0)
+
+	// the variance is taken against the quantity on the sheet and then
+	// applied to what is on hand now, so stock moved since the sheet was
+	// created is kept rather than counted again
+	query := repo.db.Rebind(`
+        select inventory_item_id, system_quantity,
+        counted_quantity, adjustment_reason_id
+        from cycle_count_item
+        where cycle_count_id = ? and counted_quantity <> system_quantity
+        order by cycle_count_seq
+        `)
+	err = tx.SelectContext(ctx, &items, query, id)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		reasonId := CountDiscrepancyReason
+		if item.AdjustmentReasonId != nil {
+			reasonId = *item.AdjustmentReasonId
+		}
+
+		cycleCountId := id
+		err = PostAdjustment(ctx, tx, Adjustment{
+			InventoryItemId:    item.InventoryItemId,
+			Quantity:           item.CountedQuantity.Sub(item.SystemQuantity),
+			AdjustmentReasonId: reasonId,
+			Note:               fmt.Sprintf("cycle count %d", id),
+			CycleCountId:       &cycleCountId,
+			CreatedBy:          userLoginId,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	query = repo.db.Rebind(`
+        update cycle_count
+        set cycle_count_status_id = ?,
+            approved_by_user_login_id = ?, posted_at = ?
+        where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, PostedStatus, userLoginId, now, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *Repo) CancelCycleCount(ctx context.Context, id int64) error {
+	log.Println("CancelCycleCount", id)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = lockCycleCount(ctx, tx, id, CanceledStatus)
+	if err != nil {
+		return err
+	}
+
+	query := repo.db.Rebind(`
+        update cycle_count set cycle_count_status_id = ?
+        where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, CanceledStatus, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *Repo) AdjustInventoryItem(
+	ctx context.Context, adjustment Adjustment) error {
+
+	log.Println("AdjustInventoryItem", adjustment.InventoryItemId,
+		adjustment.Quantity, adjustment.AdjustmentReasonId)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = PostAdjustment(ctx, tx, adjustment)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+var ErrNotQuarantined = errors.New("inventory item is not in quarantine")
+
+// ReleaseQuarantine moves an item out of its quarantine bin into
+// locationId, a bin that is not in quarantine, which makes what is on
+// hand of it available.
+func (repo *Repo) ReleaseQuarantine(
+	ctx context.Context, inventoryItemId int64, locationId int) error {
+
+	log.Println("ReleaseQuarantine", inventoryItemId, locationId)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	type Item struct {
+		ProductId      int64           `db:"product_id"`
+		WarehouseId    uuid.UUID       `db:"warehouse_id"`
+		LocationId     *int            `db:"location_id"`
+		QuantityOnHand decimal.Decimal `db:"quantity_on_hand"`
+	}
+	item := Item{}
+
+	query := repo.db.Rebind(`
+        select product_id, warehouse_id, location_id, quantity_on_hand
+        from inventory_item where id = ?
+        for update
+        `)
+	err = tx.GetContext(ctx, &item, query, inventoryItemId)
+	if err != nil {
+		return err
+	}
+
+	quarantine, err := location.IsQuarantine(ctx, tx, item.LocationId)
+	if err != nil {
+		return err
+	}
+	if !quarantine {
+		return ErrNotQuarantined
+	}
+
+	err = location.ValidateBin(ctx, tx, item.WarehouseId, locationId)
+	if err != nil {
+		return err
+	}
+	quarantine, err = location.IsQuarantine(ctx, tx, &locationId)
+	if err != nil {
+		return err
+	}
+	if quarantine {
+		return ErrLocation
+	}
+
+	query = repo.db.Rebind(`
+        update inventory_item set location_id = ? where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, locationId, inventoryItemId)
+	if err != nil {
+		return err
+	}
+
+	query = repo.db.Rebind(`
+        update warehouse_product_statistics
+        set quantity_available = quantity_available + ?
+        where warehouse_id = ? and product_id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query,
+		item.QuantityOnHand, item.WarehouseId, item.ProductId)
+	if err != nil {
+		return err
+	}
+
+	err = ledger.Record(ctx, tx, ledger.Transaction{
+		TypeId:                ledger.QuarantineReleasedType,
+		WarehouseId:           item.WarehouseId,
+		ProductId:             item.ProductId,
+		InventoryItemId:       &inventoryItemId,
+		QuantityAvailableDiff: item.QuantityOnHand,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PostAdjustment changes the quantity on hand of an inventory item by
+// adjustment.Quantity, keeps the warehouse statistics in line and
+// records the change in inventory_adjustment.
+func PostAdjustment(
+	ctx context.Context, tx *sqlx.Tx, adjustment Adjustment) error {
+
+	log.Println("PostAdjustment", adjustment.InventoryItemId,
+		adjustment.Quantity, adjustment.AdjustmentReasonId)
+
+	type Item struct {
+		ProductId      int64           `db:"product_id"`
+		WarehouseId    uuid.UUID       `db:"warehouse_id"`
+		LocationId     *int            `db:"location_id"`
+		QuantityOnHand decimal.Decimal `db:"quantity_on_hand"`
+	}
+	item := Item{}
+
+	query := tx.Rebind(`
+        select product_id, warehouse_id, location_id, quantity_on_hand
+        from inventory_item where id = ?
+        for update
+        `)
+	err := tx.GetContext(ctx, &item, query, adjustment.InventoryItemId)
+	if err != nil {
+		return err
+	}
+
+	if item.QuantityOnHand.Add(adjustment.Quantity).IsNegative() {
+		return ErrNegativeQuantity
+	}
+
+	// quarantined stock was never available, so adjusting it leaves
+	// the available quantity alone
+	quarantine, err := location.IsQuarantine(ctx, tx, item.LocationId)
+	if err != nil {
+		return err
+	}
+	available := adjustment.Quantity
+	if quarantine {
+		available = decimal.Zero
+	}
+
+	// what is reserved for sale orders cannot be adjusted away
+	if available.IsNegative() {
+		var quantityAvailable decimal.Decimal
+		query = tx.Rebind(`
+            select quantity_available from warehouse_product_statistics
+            where warehouse_id = ? and product_id = ?
+            for update
+            `)
+		err = tx.GetContext(ctx, &quantityAvailable, query,
+			item.WarehouseId, item.ProductId)
+		if err != nil {
+			return err
+		}
+		if quantityAvailable.Add(available).IsNegative() {
+			return ErrReservedQuantity
+		}
+	}
+
+	query = tx.Rebind(`
+        update inventory_item
+        set quantity_on_hand = quantity_on_hand + ?
+        where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query,
+		adjustment.Quantity, adjustment.InventoryItemId)
+	if err != nil {
+		return err
+	}
+
+	query = tx.Rebind(`
+        update warehouse_product_statistics
+        set quantity_on_hand = quantity_on_hand + ?,
+            quantity_available = quantity_available + ?
+        where warehouse_id = ? and product_id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query,
+		adjustment.Quantity, available,
+		item.WarehouseId, item.ProductId)
+	if err != nil {
+		return err
+	}
+
+	var adjustmentId int64
+	query = tx.Rebind(`
+        insert into inventory_adjustment(
+        inventory_item_id, product_id, warehouse_id,
+        quantity, adjustment_reason_id, note,
+        cycle_count_id, created_by_user_login_id)
+        values (?, ?, ?, ?, ?, ?, ?, ?)
+        returning id
+        `)
+	err = tx.GetContext(ctx, &adjustmentId, query,
+		adjustment.InventoryItemId, item.ProductId, item.WarehouseId,
+		adjustment.Quantity, adjustment.AdjustmentReasonId, adjustment.Note,
+		adjustment.CycleCountId, adjustment.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	return ledger.Record(ctx, tx, ledger.Transaction{
+		TypeId:                ledger.AdjustmentType,
+		WarehouseId:           item.WarehouseId,
+		ProductId:             item.ProductId,
+		InventoryItemId:       &adjustment.InventoryItemId,
+		QuantityOnHandDiff:    adjustment.Quantity,
+		QuantityAvailableDiff: available,
+		InventoryAdjustmentId: &adjustmentId,
+	})
+}
+
+func (repo *Repo) ViewInventoryAdjustment(
+	ctx context.Context,
+	warehouseId uuid.UUID,
+	page, pageSize int,
+	sortOrder string) (int, []ClientAdjustment, error) {
+
+	log.Println("ViewInventoryAdjustment", warehouseId,
+		page, pageSize, sortOrder)
+
+	var count int
+	result := make([]ClientAdjustment, 0)
+
+	query := repo.db.Rebind(`
+        select count(*) from inventory_adjustment
+        where warehouse_id = ?
+        `)
+	err := repo.db.GetContext(ctx, &count, query, warehouseId)
+	if err != nil {
+		return count, result, err
+	}
+
+	query = `select a.id, a.inventory_item_id,
+        a.product_id, p.name as product_name,
+        a.quantity, a.adjustment_reason_id, a.note,
+        a.cycle_count_id, u.username as created_by,
+        a.created_at
+        from inventory_adjustment a
+            inner join product p on p.id = a.product_id
+            inner join user_login u on u.id = a.created_by_user_login_id
+        where a.warehouse_id = ?
+        order by a.created_at %s, a.id %s
+        offset ? limit ?`
+	query = fmt.Sprintf(query, sortOrder, sortOrder)
+	query = repo.db.Rebind(query)
+	err = repo.db.SelectContext(ctx, &result, query,
+		warehouseId, page*pageSize, pageSize)
+
+	return count, result, err
+}
@@ -0,0 +1,28 @@
+package main
+
+func InvoiceRoutes(root *Root) {
+	root.PostIdempotent(
+		"/api/invoice/add-invoice",
+		"VIEW_EDIT_ORDER",
+		root.invoice.AddInvoiceHandler)
+
+	root.GetAuthorized(
+		"/api/invoice/view-invoice",
+		"VIEW_EDIT_ORDER",
+		root.invoice.ViewInvoiceHandler)
+
+	root.GetAuthorized(
+		"/api/invoice/view-single-invoice",
+		"VIEW_EDIT_ORDER",
+		root.invoice.ViewSingleInvoiceHandler)
+
+	root.PostIdempotent(
+		"/api/invoice/add-payment",
+		"VIEW_EDIT_ORDER",
+		root.invoice.AddPaymentHandler)
+
+	root.GetAuthorized(
+		"/api/invoice/view-aging",
+		"VIEW_EDIT_ORDER",
+		root.invoice.ViewAgingHandler)
+}
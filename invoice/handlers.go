@@ -0,0 +1,261 @@
+package invoice
+
+import (
+	"baseweb/basic"
+	"baseweb/security"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type Root struct {
+	repo *Repo
+}
+
+func InitRoot(repo *Repo) *Root {
+	return &Root{
+		repo: repo,
+	}
+}
+
+var bodyError = errors.New("body constraints violation")
+
+// conflictErrors are the errors that come from the state of the sale
+// order or invoice rather than from a failure.
+var conflictErrors = []error{
+	ErrNotInvoiceable,
+	ErrNothingToInvoice,
+	ErrMixedCurrency,
+	ErrOverPayment,
+}
+
+func parseCustomerId(value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (root *Root) AddInvoiceHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+	type Request struct {
+		SaleOrderId int64 `json:"saleOrderId"`
+		DueDays     *int  `json:"dueDays"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	dueDays := DEFAULT_DUE_DAYS
+	if req.DueDays != nil {
+		if *req.DueDays < 0 {
+			return bodyError
+		}
+		dueDays = *req.DueDays
+	}
+
+	id, err := root.repo.InsertInvoice(ctx,
+		req.SaleOrderId, dueDays, userLogin.Id)
+	if err != nil {
+		return basic.ReturnConflict(w, err, conflictErrors...)
+	}
+
+	type Response struct {
+		Id int64 `json:"id"`
+	}
+
+	res := Response{
+		Id: id,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewInvoiceHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	customerId, err := parseCustomerId(query.Get("customerId"))
+	if err != nil {
+		return err
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	statusId, err := strconv.Atoi(query.Get("statusId"))
+	if err != nil {
+		statusId = 0
+	}
+
+	sortedBy := "created_at"
+	sortedByQuery := query.Get("sortedBy")
+	if sortedByQuery == "updatedAt" {
+		sortedBy = "updated_at"
+	} else if sortedByQuery == "dueAt" {
+		sortedBy = "due_at"
+	}
+
+	sortOrder := "desc"
+	sortOrderQuery := query.Get("sortOrder")
+	if sortOrderQuery == "asc" {
+		sortOrder = "asc"
+	}
+
+	count, invoices, err := root.repo.ViewInvoice(ctx,
+		customerId, int16(statusId), page, pageSize, sortedBy, sortOrder)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		InvoiceCount int             `json:"invoiceCount"`
+		InvoiceList  []ClientInvoice `json:"invoiceList"`
+	}
+
+	res := Response{
+		InvoiceCount: count,
+		InvoiceList:  invoices,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewSingleInvoiceHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	id, err := strconv.ParseInt(query.Get("id"), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	invoice, items, payments, err := root.repo.GetInvoice(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		Invoice     ClientInvoice `json:"invoice"`
+		Items       []InvoiceItem `json:"items"`
+		PaymentList []Payment     `json:"paymentList"`
+	}
+
+	res := Response{
+		Invoice:     invoice,
+		Items:       items,
+		PaymentList: payments,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) AddPaymentHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+	type Request struct {
+		InvoiceId int64           `json:"invoiceId"`
+		MethodId  int16           `json:"methodId"`
+		Amount    decimal.Decimal `json:"amount"`
+		Reference string          `json:"reference"`
+		PaidAt    *time.Time      `json:"paidAt"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	if req.MethodId != CashMethod && req.MethodId != TransferMethod {
+		return bodyError
+	}
+	if !req.Amount.IsPositive() {
+		return bodyError
+	}
+
+	paidAt := time.Now()
+	if req.PaidAt != nil {
+		paidAt = *req.PaidAt
+	}
+
+	id, err := root.repo.InsertPayment(ctx, req.InvoiceId,
+		req.MethodId, req.Amount, req.Reference, paidAt, userLogin.Id)
+	if err != nil {
+		return basic.ReturnConflict(w, err, conflictErrors...)
+	}
+
+	type Response struct {
+		Id int64 `json:"id"`
+	}
+
+	res := Response{
+		Id: id,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewAgingHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	customerId, err := parseCustomerId(query.Get("customerId"))
+	if err != nil {
+		return err
+	}
+
+	asOf, err := basic.ParseAsOf(query.Get("asOf"), time.Now())
+	if err != nil {
+		return err
+	}
+
+	aging, err := root.repo.SelectAging(ctx, customerId, asOf)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		AsOf      time.Time `json:"asOf"`
+		AgingList []Aging   `json:"agingList"`
+	}
+
+	res := Response{
+		AsOf:      asOf,
+		AgingList: aging,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
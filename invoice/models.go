@@ -0,0 +1,95 @@
+package invoice
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	IssuedStatus        int16 = 1
+	PartiallyPaidStatus int16 = 2
+	PaidStatus          int16 = 3
+)
+
+const (
+	CashMethod     int16 = 1
+	TransferMethod int16 = 2
+)
+
+type Invoice struct {
+	Id            int64           `json:"id" db:"id"`
+	SaleOrderId   int64           `json:"saleOrderId" db:"sale_order_id"`
+	CustomerId    uuid.UUID       `json:"customerId" db:"customer_id"`
+	CurrencyUomId string          `json:"currencyUomId" db:"currency_uom_id"`
+	StatusId      int16           `json:"statusId" db:"invoice_status_id"`
+	GrossAmount   decimal.Decimal `json:"grossAmount" db:"gross_amount"`
+	PaidAmount    decimal.Decimal `json:"paidAmount" db:"paid_amount"`
+	IssuedAt      time.Time       `json:"issuedAt" db:"issued_at"`
+	DueAt         time.Time       `json:"dueAt" db:"due_at"`
+	CreatedAt     time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time       `json:"updatedAt" db:"updated_at"`
+}
+
+type ClientInvoice struct {
+	Id             int64           `json:"id" db:"id"`
+	Number         string          `json:"number" db:"number"`
+	SaleOrderId    int64           `json:"saleOrderId" db:"sale_order_id"`
+	CustomerId     uuid.UUID       `json:"customerId" db:"customer_id"`
+	Customer       string          `json:"customer" db:"customer"`
+	CurrencyUomId  string          `json:"currencyUomId" db:"currency_uom_id"`
+	StatusId       int16           `json:"statusId" db:"invoice_status_id"`
+	DiscountAmount decimal.Decimal `json:"discountAmount" db:"discount_amount"`
+	NetAmount      decimal.Decimal `json:"netAmount" db:"net_amount"`
+	TaxAmount      decimal.Decimal `json:"taxAmount" db:"tax_amount"`
+	GrossAmount    decimal.Decimal `json:"grossAmount" db:"gross_amount"`
+	PaidAmount     decimal.Decimal `json:"paidAmount" db:"paid_amount"`
+	IssuedAt       time.Time       `json:"issuedAt" db:"issued_at"`
+	DueAt          time.Time       `json:"dueAt" db:"due_at"`
+	CreatedBy      string          `json:"createdBy" db:"created_by"`
+	CreatedAt      time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updatedAt" db:"updated_at"`
+}
+
+type InvoiceItem struct {
+	Seq            int16           `json:"seq" db:"invoice_seq"`
+	SaleOrderId    int64           `json:"saleOrderId" db:"sale_order_id"`
+	SaleOrderSeq   int             `json:"saleOrderSeq" db:"sale_order_seq"`
+	ProductId      int64           `json:"productId" db:"product_id"`
+	ProductName    string          `json:"productName" db:"product_name"`
+	ProductPriceId uuid.UUID       `json:"productPriceId" db:"product_price_id"`
+	Quantity       decimal.Decimal `json:"quantity" db:"quantity"`
+	UnitPrice      decimal.Decimal `json:"unitPrice" db:"unit_price"`
+	DiscountAmount decimal.Decimal `json:"discountAmount" db:"discount_amount"`
+	NetAmount      decimal.Decimal `json:"netAmount" db:"net_amount"`
+	TaxRate        decimal.Decimal `json:"taxRate" db:"tax_rate"`
+	TaxAmount      decimal.Decimal `json:"taxAmount" db:"tax_amount"`
+	GrossAmount    decimal.Decimal `json:"grossAmount" db:"gross_amount"`
+}
+
+type Payment struct {
+	Id        int64           `json:"id" db:"id"`
+	InvoiceId int64           `json:"invoiceId" db:"invoice_id"`
+	MethodId  int16           `json:"methodId" db:"payment_method_id"`
+	Amount    decimal.Decimal `json:"amount" db:"amount"`
+	Reference string          `json:"reference" db:"reference"`
+	PaidAt    time.Time       `json:"paidAt" db:"paid_at"`
+	CreatedBy string          `json:"createdBy" db:"created_by"`
+	CreatedAt time.Time       `json:"createdAt" db:"created_at"`
+}
+
+// Aging is what a customer still owes in one currency, split by how
+// long it is past due.
+type Aging struct {
+	CustomerId    uuid.UUID       `json:"customerId" db:"customer_id"`
+	Customer      string          `json:"customer" db:"customer"`
+	CurrencyUomId string          `json:"currencyUomId" db:"currency_uom_id"`
+	CurrentAmount decimal.Decimal `json:"currentAmount" db:"current_amount"`
+	Overdue30     decimal.Decimal `json:"overdue30" db:"overdue_30"`
+	Overdue60     decimal.Decimal `json:"overdue60" db:"overdue_60"`
+	Overdue90     decimal.Decimal `json:"overdue90" db:"overdue_90"`
+	OverdueOver90 decimal.Decimal `json:"overdueOver90" db:"overdue_over_90"`
+	TotalAmount   decimal.Decimal `json:"totalAmount" db:"total_amount"`
+	InvoiceCount  int             `json:"invoiceCount" db:"invoice_count"`
+}
@@ -0,0 +1,447 @@
+package invoice
+
+import (
+	"baseweb/order"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func InitRepo(db *sqlx.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+var ErrNotInvoiceable = errors.New("sale order has not been exported")
+
+var ErrNothingToInvoice = errors.New("sale order has nothing left to invoice")
+
+var ErrMixedCurrency = errors.New("sale order is priced in more than one currency")
+
+var ErrOverPayment = errors.New("payment exceeds the open amount of the invoice")
+
+// numberColumn shows an invoice number as year/number, the number padded
+// so that invoices of a year sort the same as text.
+const numberColumn = `i.invoice_year || '/' ||
+        lpad(cast(i.invoice_number as varchar), 6, '0') as number`
+
+// nextNumber takes the next invoice number of year. The counter row stays
+// locked until the transaction ends and goes back with it on rollback,
+// so numbers are handed out in order and without gaps.
+func nextNumber(ctx context.Context, tx *sqlx.Tx, year int) (int, error) {
+	var number int
+	query := tx.Rebind(`
+        insert into invoice_sequence(invoice_year, last_number)
+        values (?, 1)
+        on conflict (invoice_year) do update
+        set last_number = invoice_sequence.last_number + 1
+        returning last_number
+        `)
+	err := tx.GetContext(ctx, &number, query, year)
+
+	return number, err
+}
+
+// InsertInvoice bills what was shipped on a sale order and not invoiced
+// yet, so an order exported in parts gets one invoice per shipment.
+// Lines are priced at the product price stored on the order item.
+func (repo *Repo) InsertInvoice(
+	ctx context.Context, saleOrderId int64,
+	dueDays int, userLoginId uuid.UUID) (int64, error) {
+
+	log.Println("InsertInvoice", saleOrderId, dueDays)
+
+	var id int64
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return id, err
+	}
+	defer tx.Rollback()
+
+	type OrderInfo struct {
+		CustomerId     uuid.UUID       `db:"customer_id"`
+		StatusId       int16           `db:"sale_order_status_id"`
+		DiscountAmount decimal.Decimal `db:"discount_amount"`
+	}
+	info := OrderInfo{}
+
+	// the order stays locked while it is billed, so two invoices can
+	// not take the same shipped quantity
+	query := repo.db.Rebind(`
+        select customer_id, sale_order_status_id, discount_amount
+        from sale_order where id = ?
+        for update
+        `)
+	err = tx.GetContext(ctx, &info, query, saleOrderId)
+	if err != nil {
+		return id, err
+	}
+
+	if info.StatusId != order.ShippingStatus &&
+		info.StatusId != order.CompletedStatus {
+		return id, ErrNotInvoiceable
+	}
+
+	lines := make([]orderLine, 0)
+	query = repo.db.Rebind(`
+        select oi.sale_order_seq, oi.product_price_id,
+        pp.price, pp.currency_uom_id,
+        oi.quantity, oi.shipped_quantity, oi.backorder_of_seq,
+        oi.discount_amount, oi.tax_rate, oi.tax_amount,
+        coalesce((
+            select sum(ii.quantity) from invoice_item ii
+            where ii.sale_order_id = oi.sale_order_id
+                and ii.sale_order_seq = oi.sale_order_seq
+        ), 0) as invoiced_quantity
+        from sale_order_item oi
+            inner join product_price pp on pp.id = oi.product_price_id
+        where oi.sale_order_id = ? and oi.removed_at is null
+        order by oi.sale_order_seq
+        `)
+	err = tx.SelectContext(ctx, &lines, query, saleOrderId)
+	if err != nil {
+		return id, err
+	}
+
+	bySeq := make(map[int]orderLine)
+	for _, line := range lines {
+		bySeq[line.Seq] = line
+	}
+	shares := discountShares(lines, info.DiscountAmount)
+
+	items := make([]InvoiceItem, 0)
+	currencyUomId := ""
+	totalDiscount := decimal.Zero
+	totalNet := decimal.Zero
+	totalTax := decimal.Zero
+
+	for _, line := range lines {
+		quantity := line.ShippedQuantity.Sub(line.Invoiced)
+		if !quantity.IsPositive() {
+			continue
+		}
+
+		if currencyUomId == "" {
+			currencyUomId = line.CurrencyUomId
+		} else if currencyUomId != line.CurrencyUomId {
+			return id, ErrMixedCurrency
+		}
+
+		// discounts and tax were worked out for the whole priced line,
+		// a shipment takes its part of them
+		priced := origin(bySeq, line)
+		part := quantity.Div(priced.Quantity)
+
+		discount := priced.DiscountAmount.Add(shares[priced.Seq]).
+			Mul(part).Round(2)
+		net := line.Price.Mul(quantity).Round(2).Sub(discount)
+		lineTax := priced.TaxAmount.Mul(part).Round(2)
+
+		items = append(items, InvoiceItem{
+			SaleOrderId:    saleOrderId,
+			SaleOrderSeq:   line.Seq,
+			ProductPriceId: line.ProductPriceId,
+			Quantity:       quantity,
+			UnitPrice:      line.Price,
+			DiscountAmount: discount,
+			NetAmount:      net,
+			TaxRate:        priced.TaxRate,
+			TaxAmount:      lineTax,
+			GrossAmount:    net.Add(lineTax),
+		})
+
+		totalDiscount = totalDiscount.Add(discount)
+		totalNet = totalNet.Add(net)
+		totalTax = totalTax.Add(lineTax)
+	}
+
+	if len(items) == 0 {
+		return id, ErrNothingToInvoice
+	}
+
+	now := time.Now()
+	number, err := nextNumber(ctx, tx, now.Year())
+	if err != nil {
+		return id, err
+	}
+
+	query = repo.db.Rebind(`
+        insert into invoice(
+        invoice_year, invoice_number,
+        sale_order_id, customer_id, currency_uom_id, invoice_status_id,
+        discount_amount, net_amount, tax_amount, gross_amount,
+        issued_at, due_at, created_by_user_login_id)
+        values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        returning id
+        `)
+	err = tx.GetContext(ctx, &id, query,
+		now.Year(), number,
+		saleOrderId, info.CustomerId, currencyUomId, IssuedStatus,
+		totalDiscount, totalNet, totalTax, totalNet.Add(totalTax),
+		now, now.AddDate(0, 0, dueDays), userLoginId)
+	if err != nil {
+		return id, err
+	}
+
+	query = repo.db.Rebind(`
+        insert into invoice_item(
+        invoice_id, invoice_seq, sale_order_id, sale_order_seq,
+        product_price_id, quantity, unit_price,
+        discount_amount, net_amount, tax_rate, tax_amount, gross_amount)
+        values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        `)
+	for index, item := range items {
+		_, err = tx.ExecContext(ctx, query, id, index,
+			item.SaleOrderId, item.SaleOrderSeq,
+			item.ProductPriceId, item.Quantity, item.UnitPrice,
+			item.DiscountAmount, item.NetAmount,
+			item.TaxRate, item.TaxAmount, item.GrossAmount)
+		if err != nil {
+			return id, err
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+func (repo *Repo) ViewInvoice(
+	ctx context.Context,
+	customerId *uuid.UUID,
+	statusId int16,
+	page, pageSize int,
+	sortedBy, sortOrder string) (int, []ClientInvoice, error) {
+
+	log.Println("ViewInvoice", customerId, statusId,
+		page, pageSize, sortedBy, sortOrder)
+
+	var count int
+	result := make([]ClientInvoice, 0)
+
+	query := repo.db.Rebind(`
+        select count(*) from invoice
+        where (cast(? as uuid) is null or customer_id = ?)
+            and (? = 0 or invoice_status_id = ?)
+        `)
+	err := repo.db.GetContext(ctx, &count, query,
+		customerId, customerId, statusId, statusId)
+	if err != nil {
+		return count, result, err
+	}
+
+	query = `select i.id, ` + numberColumn + `,
+        i.sale_order_id, i.customer_id, c.name as customer,
+        i.currency_uom_id, i.invoice_status_id,
+        i.discount_amount, i.net_amount, i.tax_amount,
+        i.gross_amount, i.paid_amount,
+        i.issued_at, i.due_at,
+        u.username as created_by,
+        i.created_at, i.updated_at
+        from invoice i
+            inner join customer c on c.id = i.customer_id
+            inner join user_login u on u.id = i.created_by_user_login_id
+        where (cast(? as uuid) is null or i.customer_id = ?)
+            and (? = 0 or i.invoice_status_id = ?)
+        order by i.%s %s
+        offset ? limit ?`
+	query = fmt.Sprintf(query, sortedBy, sortOrder)
+	query = repo.db.Rebind(query)
+	err = repo.db.SelectContext(ctx, &result, query,
+		customerId, customerId, statusId, statusId,
+		page*pageSize, pageSize)
+
+	return count, result, err
+}
+
+func (repo *Repo) GetInvoice(
+	ctx context.Context, id int64) (
+	ClientInvoice, []InvoiceItem, []Payment, error) {
+
+	log.Println("GetInvoice", id)
+
+	invoice := ClientInvoice{}
+	items := make([]InvoiceItem, 0)
+	payments := make([]Payment, 0)
+
+	query := repo.db.Rebind(`
+        select i.id, ` + numberColumn + `,
+        i.sale_order_id, i.customer_id, c.name as customer,
+        i.currency_uom_id, i.invoice_status_id,
+        i.discount_amount, i.net_amount, i.tax_amount,
+        i.gross_amount, i.paid_amount,
+        i.issued_at, i.due_at,
+        u.username as created_by,
+        i.created_at, i.updated_at
+        from invoice i
+            inner join customer c on c.id = i.customer_id
+            inner join user_login u on u.id = i.created_by_user_login_id
+        where i.id = ?
+        `)
+	err := repo.db.GetContext(ctx, &invoice, query, id)
+	if err != nil {
+		return invoice, items, payments, err
+	}
+
+	query = repo.db.Rebind(`
+        select ii.invoice_seq, ii.sale_order_id, ii.sale_order_seq,
+        pp.product_id, p.name as product_name, ii.product_price_id,
+        ii.quantity, ii.unit_price,
+        ii.discount_amount, ii.net_amount,
+        ii.tax_rate, ii.tax_amount, ii.gross_amount
+        from invoice_item ii
+            inner join product_price pp on pp.id = ii.product_price_id
+            inner join product p on p.id = pp.product_id
+        where ii.invoice_id = ?
+        order by ii.invoice_seq
+        `)
+	err = repo.db.SelectContext(ctx, &items, query, id)
+	if err != nil {
+		return invoice, items, payments, err
+	}
+
+	query = repo.db.Rebind(`
+        select p.id, p.invoice_id, p.payment_method_id,
+        p.amount, p.reference, p.paid_at,
+        u.username as created_by, p.created_at
+        from payment p
+            inner join user_login u on u.id = p.created_by_user_login_id
+        where p.invoice_id = ?
+        order by p.paid_at, p.id
+        `)
+	err = repo.db.SelectContext(ctx, &payments, query, id)
+
+	return invoice, items, payments, err
+}
+
+// InsertPayment records money received against an invoice. An invoice
+// may be paid in parts, but never beyond its gross amount.
+func (repo *Repo) InsertPayment(
+	ctx context.Context, invoiceId int64,
+	methodId int16, amount decimal.Decimal,
+	reference string, paidAt time.Time,
+	userLoginId uuid.UUID) (int64, error) {
+
+	log.Println("InsertPayment", invoiceId, methodId,
+		amount, reference, paidAt)
+
+	var id int64
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return id, err
+	}
+	defer tx.Rollback()
+
+	invoice := Invoice{}
+	query := repo.db.Rebind(`
+        select id, sale_order_id, customer_id, currency_uom_id,
+        invoice_status_id, gross_amount, paid_amount,
+        issued_at, due_at, created_at, updated_at
+        from invoice where id = ?
+        for update
+        `)
+	err = tx.GetContext(ctx, &invoice, query, invoiceId)
+	if err != nil {
+		return id, err
+	}
+
+	paid := invoice.PaidAmount.Add(amount)
+	if paid.GreaterThan(invoice.GrossAmount) {
+		return id, ErrOverPayment
+	}
+
+	query = repo.db.Rebind(`
+        insert into payment(
+        invoice_id, payment_method_id, amount, reference,
+        paid_at, created_by_user_login_id)
+        values (?, ?, ?, ?, ?, ?) returning id
+        `)
+	err = tx.GetContext(ctx, &id, query, invoiceId,
+		methodId, amount, reference, paidAt, userLoginId)
+	if err != nil {
+		return id, err
+	}
+
+	statusId := PartiallyPaidStatus
+	if paid.Equal(invoice.GrossAmount) {
+		statusId = PaidStatus
+	}
+
+	query = repo.db.Rebind(`
+        update invoice set paid_amount = ?, invoice_status_id = ?
+        where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, paid, statusId, invoiceId)
+	if err != nil {
+		return id, err
+	}
+
+	return id, tx.Commit()
+}
+
+const agingQuery = `
+    with open_invoice as (
+        select i.customer_id, i.currency_uom_id, i.due_at,
+        i.gross_amount - coalesce((
+            select sum(p.amount) from payment p
+            where p.invoice_id = i.id and p.paid_at <= ?
+        ), 0) as open_amount
+        from invoice i
+        where i.issued_at <= ?
+            and (cast(? as uuid) is null or i.customer_id = ?)
+    )
+    select o.customer_id, c.name as customer, o.currency_uom_id,
+    sum(case when o.due_at >= ?
+        then o.open_amount else 0 end) as current_amount,
+    sum(case when o.due_at < ? and o.due_at >= ?
+        then o.open_amount else 0 end) as overdue_30,
+    sum(case when o.due_at < ? and o.due_at >= ?
+        then o.open_amount else 0 end) as overdue_60,
+    sum(case when o.due_at < ? and o.due_at >= ?
+        then o.open_amount else 0 end) as overdue_90,
+    sum(case when o.due_at < ?
+        then o.open_amount else 0 end) as overdue_over_90,
+    sum(o.open_amount) as total_amount,
+    count(*) as invoice_count
+    from open_invoice o
+        inner join customer c on c.id = o.customer_id
+    where o.open_amount > 0
+    group by o.customer_id, c.name, o.currency_uom_id
+    order by c.name, o.currency_uom_id`
+
+// SelectAging ages what customers owe as of asOf. Payments made after
+// asOf do not count, so the report can be run for a past date.
+func (repo *Repo) SelectAging(
+	ctx context.Context,
+	customerId *uuid.UUID, asOf time.Time) ([]Aging, error) {
+
+	log.Println("SelectAging", customerId, asOf)
+
+	result := make([]Aging, 0)
+
+	days30 := asOf.AddDate(0, 0, -30)
+	days60 := asOf.AddDate(0, 0, -60)
+	days90 := asOf.AddDate(0, 0, -90)
+
+	query := repo.db.Rebind(agingQuery)
+	err := repo.db.SelectContext(ctx, &result, query,
+		asOf, asOf, customerId, customerId,
+		asOf,
+		asOf, days30,
+		days30, days60,
+		days60, days90,
+		days90)
+
+	return result, err
+}
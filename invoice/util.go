@@ -0,0 +1,56 @@
+package invoice
+
+import (
+	"baseweb/tax"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// DEFAULT_DUE_DAYS is how long a customer has to pay an invoice when
+// none is given.
+const DEFAULT_DUE_DAYS = 30
+
+type orderLine struct {
+	Seq             int             `db:"sale_order_seq"`
+	ProductPriceId  uuid.UUID       `db:"product_price_id"`
+	Price           decimal.Decimal `db:"price"`
+	CurrencyUomId   string          `db:"currency_uom_id"`
+	Quantity        decimal.Decimal `db:"quantity"`
+	ShippedQuantity decimal.Decimal `db:"shipped_quantity"`
+	BackorderOfSeq  *int            `db:"backorder_of_seq"`
+	DiscountAmount  decimal.Decimal `db:"discount_amount"`
+	TaxRate         decimal.Decimal `db:"tax_rate"`
+	TaxAmount       decimal.Decimal `db:"tax_amount"`
+	Invoiced        decimal.Decimal `db:"invoiced_quantity"`
+}
+
+// discountShares spreads the order level discount over the priced lines
+// with tax.DiscountShares, so an order billed in full comes to the
+// amounts it was priced at. Backorder lines were never priced and get
+// no share.
+func discountShares(
+	lines []orderLine, orderDiscount decimal.Decimal) map[int]decimal.Decimal {
+
+	priced := make([]tax.Line, 0, len(lines))
+	for _, line := range lines {
+		if line.BackorderOfSeq != nil {
+			continue
+		}
+		priced = append(priced, tax.Line{
+			Seq:       line.Seq,
+			NetAmount: line.Price.Mul(line.Quantity).Sub(line.DiscountAmount),
+		})
+	}
+
+	return tax.DiscountShares(priced, orderDiscount)
+}
+
+// origin is the priced line a line was split from. A backorder line
+// carries no amounts of its own, so it is billed like its origin.
+func origin(lines map[int]orderLine, line orderLine) orderLine {
+	for line.BackorderOfSeq != nil {
+		line = lines[*line.BackorderOfSeq]
+	}
+	return line
+}
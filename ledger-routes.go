@@ -0,0 +1,23 @@
+package main
+
+func LedgerRoutes(root *Root) {
+	root.GetAuthorized(
+		"/api/ledger/view-transaction",
+		"IMPORT",
+		root.ledger.ViewTransactionHandler)
+
+	root.GetAuthorized(
+		"/api/ledger/view-stock-as-of",
+		"IMPORT",
+		root.ledger.ViewStockAsOfHandler)
+
+	root.GetAuthorized(
+		"/api/ledger/check-consistency",
+		"VIEW_EDIT_FACILITY",
+		root.ledger.CheckConsistencyHandler)
+
+	root.PostAuthorized(
+		"/api/ledger/rebuild-statistics",
+		"VIEW_EDIT_FACILITY",
+		root.ledger.RebuildStatisticsHandler)
+}
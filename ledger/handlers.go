@@ -0,0 +1,182 @@
+package ledger
+
+import (
+	"baseweb/basic"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Root struct {
+	repo *Repo
+}
+
+func InitRoot(repo *Repo) *Root {
+	return &Root{
+		repo: repo,
+	}
+}
+
+func parseProductId(value string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (root *Root) ViewTransactionHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	warehouseId, err := uuid.Parse(query.Get("warehouseId"))
+	if err != nil {
+		return err
+	}
+
+	productId, err := parseProductId(query.Get("productId"))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	to, err := basic.ParseAsOf(query.Get("to"), now)
+	if err != nil {
+		return err
+	}
+
+	from, err := basic.ParseTime(query.Get("from"), to.AddDate(0, 0, -30))
+	if err != nil {
+		return err
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	count, transactions, err := root.repo.ViewTransaction(ctx,
+		warehouseId, productId, from, to, page, pageSize)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		TransactionCount int                 `json:"transactionCount"`
+		TransactionList  []ClientTransaction `json:"transactionList"`
+	}
+
+	res := Response{
+		TransactionCount: count,
+		TransactionList:  transactions,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewStockAsOfHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	warehouseId, err := uuid.Parse(query.Get("warehouseId"))
+	if err != nil {
+		return err
+	}
+
+	productId, err := parseProductId(query.Get("productId"))
+	if err != nil {
+		return err
+	}
+
+	asOf, err := basic.ParseAsOf(query.Get("asOf"), time.Now())
+	if err != nil {
+		return err
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	count, stocks, err := root.repo.ViewStockAsOf(ctx,
+		warehouseId, productId, asOf, page, pageSize)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		AsOf       time.Time `json:"asOf"`
+		StockCount int       `json:"stockCount"`
+		StockList  []Stock   `json:"stockList"`
+	}
+
+	res := Response{
+		AsOf:       asOf,
+		StockCount: count,
+		StockList:  stocks,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) CheckConsistencyHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	drifts, err := root.repo.CheckConsistency(ctx)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		DriftList []Drift `json:"driftList"`
+	}
+
+	res := Response{
+		DriftList: drifts,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) RebuildStatisticsHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	drifts, err := root.repo.RebuildStatistics(ctx)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		DriftList []Drift `json:"driftList"`
+	}
+
+	res := Response{
+		DriftList: drifts,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
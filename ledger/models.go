@@ -0,0 +1,71 @@
+package ledger
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ReceiptType            int16 = 1
+	OrderPlacedType        int16 = 2
+	OrderCanceledType      int16 = 3
+	ExportType             int16 = 4
+	TransferShippedType    int16 = 5
+	TransferInTransitType  int16 = 6
+	TransferReceivedType   int16 = 7
+	AdjustmentType         int16 = 8
+	ReservationExpiredType int16 = 9
+	OrderChangedType       int16 = 10
+	ReturnReceivedType     int16 = 11
+	QuarantineReleasedType int16 = 12
+	ReturnRestoredType     int16 = 13
+)
+
+// Transaction is one append-only ledger entry. Each diff is what the
+// movement did to the matching warehouse_product_statistics column.
+type Transaction struct {
+	TypeId                int16           `json:"typeId" db:"inventory_transaction_type_id"`
+	WarehouseId           uuid.UUID       `json:"warehouseId" db:"warehouse_id"`
+	ProductId             int64           `json:"productId" db:"product_id"`
+	InventoryItemId       *int64          `json:"inventoryItemId" db:"inventory_item_id"`
+	QuantityOnHandDiff    decimal.Decimal `json:"quantityOnHandDiff" db:"quantity_on_hand_diff"`
+	QuantityAvailableDiff decimal.Decimal `json:"quantityAvailableDiff" db:"quantity_available_diff"`
+	QuantityInTransitDiff decimal.Decimal `json:"quantityInTransitDiff" db:"quantity_in_transit_diff"`
+	SaleOrderId           *int64          `json:"saleOrderId" db:"sale_order_id"`
+	SaleOrderSeq          *int            `json:"saleOrderSeq" db:"sale_order_seq"`
+	TransferOrderId       *int64          `json:"transferOrderId" db:"transfer_order_id"`
+	PurchaseOrderId       *int64          `json:"purchaseOrderId" db:"purchase_order_id"`
+	PurchaseOrderSeq      *int16          `json:"purchaseOrderSeq" db:"purchase_order_seq"`
+	InventoryAdjustmentId *int64          `json:"inventoryAdjustmentId" db:"inventory_adjustment_id"`
+	OccurredAt            time.Time       `json:"occurredAt" db:"occurred_at"`
+}
+
+type ClientTransaction struct {
+	Id          int64  `json:"id" db:"id"`
+	ProductName string `json:"productName" db:"product_name"`
+	Transaction
+}
+
+type Stock struct {
+	WarehouseId       uuid.UUID       `json:"warehouseId" db:"warehouse_id"`
+	ProductId         int64           `json:"productId" db:"product_id"`
+	ProductName       string          `json:"productName" db:"product_name"`
+	QuantityOnHand    decimal.Decimal `json:"quantityOnHand" db:"quantity_on_hand"`
+	QuantityAvailable decimal.Decimal `json:"quantityAvailable" db:"quantity_available"`
+	QuantityInTransit decimal.Decimal `json:"quantityInTransit" db:"quantity_in_transit"`
+}
+
+// Drift is a warehouse and product whose statistics no longer add up
+// to what the ledger says.
+type Drift struct {
+	WarehouseId                 uuid.UUID       `json:"warehouseId" db:"warehouse_id"`
+	ProductId                   int64           `json:"productId" db:"product_id"`
+	StatisticsQuantityOnHand    decimal.Decimal `json:"statisticsQuantityOnHand" db:"statistics_quantity_on_hand"`
+	StatisticsQuantityAvailable decimal.Decimal `json:"statisticsQuantityAvailable" db:"statistics_quantity_available"`
+	StatisticsQuantityInTransit decimal.Decimal `json:"statisticsQuantityInTransit" db:"statistics_quantity_in_transit"`
+	LedgerQuantityOnHand        decimal.Decimal `json:"ledgerQuantityOnHand" db:"ledger_quantity_on_hand"`
+	LedgerQuantityAvailable     decimal.Decimal `json:"ledgerQuantityAvailable" db:"ledger_quantity_available"`
+	LedgerQuantityInTransit     decimal.Decimal `json:"ledgerQuantityInTransit" db:"ledger_quantity_in_transit"`
+}
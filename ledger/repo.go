@@ -0,0 +1,238 @@
+package ledger
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func InitRepo(db *sqlx.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+// Record appends t to the ledger. It has to run in the same transaction
+// as the stock movement it describes.
+func Record(ctx context.Context, tx *sqlx.Tx, t Transaction) error {
+	log.Println("Record", t.TypeId, t.WarehouseId, t.ProductId,
+		t.QuantityOnHandDiff, t.QuantityAvailableDiff,
+		t.QuantityInTransitDiff)
+
+	if t.OccurredAt.IsZero() {
+		t.OccurredAt = time.Now()
+	}
+
+	query := `insert into inventory_transaction(
+        inventory_transaction_type_id,
+        warehouse_id, product_id, inventory_item_id,
+        quantity_on_hand_diff, quantity_available_diff,
+        quantity_in_transit_diff,
+        sale_order_id, sale_order_seq, transfer_order_id,
+        purchase_order_id, purchase_order_seq,
+        inventory_adjustment_id, occurred_at)
+    values (:inventory_transaction_type_id,
+        :warehouse_id, :product_id, :inventory_item_id,
+        :quantity_on_hand_diff, :quantity_available_diff,
+        :quantity_in_transit_diff,
+        :sale_order_id, :sale_order_seq, :transfer_order_id,
+        :purchase_order_id, :purchase_order_seq,
+        :inventory_adjustment_id, :occurred_at)`
+	_, err := tx.NamedExecContext(ctx, query, t)
+
+	return err
+}
+
+func (repo *Repo) ViewTransaction(
+	ctx context.Context,
+	warehouseId uuid.UUID, productId *int64,
+	from, to time.Time,
+	page, pageSize int) (int, []ClientTransaction, error) {
+
+	log.Println("ViewTransaction", warehouseId, productId,
+		from, to, page, pageSize)
+
+	var count int
+	result := make([]ClientTransaction, 0)
+
+	query := repo.db.Rebind(`
+        select count(*) from inventory_transaction
+        where warehouse_id = ?
+            and (cast(? as integer) is null or product_id = ?)
+            and occurred_at >= ? and occurred_at < ?
+        `)
+	err := repo.db.GetContext(ctx, &count, query,
+		warehouseId, productId, productId, from, to)
+	if err != nil {
+		return count, result, err
+	}
+
+	query = repo.db.Rebind(`
+        select t.id, p.name as product_name,
+        t.inventory_transaction_type_id,
+        t.warehouse_id, t.product_id, t.inventory_item_id,
+        t.quantity_on_hand_diff, t.quantity_available_diff,
+        t.quantity_in_transit_diff,
+        t.sale_order_id, t.sale_order_seq, t.transfer_order_id,
+        t.purchase_order_id, t.purchase_order_seq,
+        t.inventory_adjustment_id, t.occurred_at
+        from inventory_transaction t
+            inner join product p on p.id = t.product_id
+        where t.warehouse_id = ?
+            and (cast(? as integer) is null or t.product_id = ?)
+            and t.occurred_at >= ? and t.occurred_at < ?
+        order by t.occurred_at desc, t.id desc
+        offset ? limit ?
+        `)
+	err = repo.db.SelectContext(ctx, &result, query,
+		warehouseId, productId, productId, from, to,
+		page*pageSize, pageSize)
+
+	return count, result, err
+}
+
+// ViewStockAsOf adds up the ledger before asOf, so it answers what was
+// on hand, available and in transit at that moment.
+func (repo *Repo) ViewStockAsOf(
+	ctx context.Context,
+	warehouseId uuid.UUID, productId *int64,
+	asOf time.Time,
+	page, pageSize int) (int, []Stock, error) {
+
+	log.Println("ViewStockAsOf", warehouseId, productId,
+		asOf, page, pageSize)
+
+	var count int
+	result := make([]Stock, 0)
+
+	query := repo.db.Rebind(`
+        select count(distinct product_id) from inventory_transaction
+        where warehouse_id = ?
+            and (cast(? as integer) is null or product_id = ?)
+            and occurred_at < ?
+        `)
+	err := repo.db.GetContext(ctx, &count, query,
+		warehouseId, productId, productId, asOf)
+	if err != nil {
+		return count, result, err
+	}
+
+	query = repo.db.Rebind(`
+        select t.warehouse_id, t.product_id, p.name as product_name,
+        sum(t.quantity_on_hand_diff) as quantity_on_hand,
+        sum(t.quantity_available_diff) as quantity_available,
+        sum(t.quantity_in_transit_diff) as quantity_in_transit
+        from inventory_transaction t
+            inner join product p on p.id = t.product_id
+        where t.warehouse_id = ?
+            and (cast(? as integer) is null or t.product_id = ?)
+            and t.occurred_at < ?
+        group by t.warehouse_id, t.product_id, p.name
+        order by p.name, t.product_id
+        offset ? limit ?
+        `)
+	err = repo.db.SelectContext(ctx, &result, query,
+		warehouseId, productId, productId, asOf,
+		page*pageSize, pageSize)
+
+	return count, result, err
+}
+
+const driftQuery = `
+    with ledger as (
+        select warehouse_id, product_id,
+        sum(quantity_on_hand_diff) as quantity_on_hand,
+        sum(quantity_available_diff) as quantity_available,
+        sum(quantity_in_transit_diff) as quantity_in_transit
+        from inventory_transaction
+        group by warehouse_id, product_id
+    )
+    select coalesce(s.warehouse_id, l.warehouse_id) as warehouse_id,
+    coalesce(s.product_id, l.product_id) as product_id,
+    coalesce(s.quantity_on_hand, 0) as statistics_quantity_on_hand,
+    coalesce(s.quantity_available, 0) as statistics_quantity_available,
+    coalesce(s.quantity_in_transit, 0) as statistics_quantity_in_transit,
+    coalesce(l.quantity_on_hand, 0) as ledger_quantity_on_hand,
+    coalesce(l.quantity_available, 0) as ledger_quantity_available,
+    coalesce(l.quantity_in_transit, 0) as ledger_quantity_in_transit
+    from warehouse_product_statistics s
+        full outer join ledger l
+            on l.warehouse_id = s.warehouse_id
+            and l.product_id = s.product_id
+    where coalesce(s.quantity_on_hand, 0) <>
+            coalesce(l.quantity_on_hand, 0)
+        or coalesce(s.quantity_available, 0) <>
+            coalesce(l.quantity_available, 0)
+        or coalesce(s.quantity_in_transit, 0) <>
+            coalesce(l.quantity_in_transit, 0)
+    order by 1, 2`
+
+func (repo *Repo) CheckConsistency(ctx context.Context) ([]Drift, error) {
+	log.Println("CheckConsistency")
+
+	result := make([]Drift, 0)
+	err := repo.db.SelectContext(ctx, &result, driftQuery)
+
+	return result, err
+}
+
+// RebuildStatistics brings every drifting warehouse_product_statistics
+// row back in line with the ledger and returns what it changed. Stock
+// movements wait for it to finish, so the ledger cannot move under it.
+func (repo *Repo) RebuildStatistics(ctx context.Context) ([]Drift, error) {
+	log.Println("RebuildStatistics")
+
+	result := make([]Drift, 0)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+        lock table inventory_transaction, warehouse_product_statistics
+        in exclusive mode
+        `)
+	if err != nil {
+		return result, err
+	}
+
+	err = tx.SelectContext(ctx, &result, driftQuery)
+	if err != nil {
+		return result, err
+	}
+
+	query := repo.db.Rebind(`
+        insert into warehouse_product_statistics(
+        warehouse_id, product_id, inventory_item_count,
+        quantity_total, quantity_on_hand,
+        quantity_available, quantity_in_transit)
+        select ?, ?, count(*), coalesce(sum(quantity), 0), ?, ?, ?
+        from inventory_item
+        where warehouse_id = ? and product_id = ?
+        on conflict (warehouse_id, product_id) do update
+        set quantity_on_hand = excluded.quantity_on_hand,
+            quantity_available = excluded.quantity_available,
+            quantity_in_transit = excluded.quantity_in_transit
+        `)
+	for _, d := range result {
+		_, err = tx.ExecContext(ctx, query,
+			d.WarehouseId, d.ProductId,
+			d.LedgerQuantityOnHand, d.LedgerQuantityAvailable,
+			d.LedgerQuantityInTransit,
+			d.WarehouseId, d.ProductId)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, tx.Commit()
+}
@@ -0,0 +1,23 @@
+package main
+
+func LocationRoutes(root *Root) {
+	root.PostIdempotent(
+		"/api/location/add-location",
+		"VIEW_EDIT_FACILITY",
+		root.location.AddLocationHandler)
+
+	root.GetAuthorized(
+		"/api/location/view-location",
+		"VIEW_EDIT_FACILITY",
+		root.location.ViewLocationHandler)
+
+	root.PostAuthorized(
+		"/api/location/delete-location",
+		"VIEW_EDIT_FACILITY",
+		root.location.DeleteLocationHandler)
+
+	root.GetAuthorized(
+		"/api/location/suggest-putaway",
+		"IMPORT",
+		root.location.SuggestPutawayHandler)
+}
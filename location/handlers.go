@@ -0,0 +1,138 @@
+package location
+
+import (
+	"baseweb/basic"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type Root struct {
+	repo *Repo
+}
+
+func InitRoot(repo *Repo) *Root {
+	return &Root{
+		repo: repo,
+	}
+}
+
+var bodyError = errors.New("body constraints violation")
+
+func (root *Root) AddLocationHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	location := Location{}
+	err := json.NewDecoder(r.Body).Decode(&location)
+	if err != nil {
+		return err
+	}
+
+	if location.Code == "" ||
+		location.TypeId < ZoneType || location.TypeId > BinType {
+		return bodyError
+	}
+	if location.Capacity.Valid && location.Capacity.Decimal.IsNegative() {
+		return bodyError
+	}
+
+	err = root.repo.InsertLocation(ctx, location)
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) ViewLocationHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	warehouseId, err := uuid.Parse(r.URL.Query().Get("warehouseId"))
+	if err != nil {
+		return err
+	}
+
+	locations, err := root.repo.SelectLocationByWarehouse(ctx, warehouseId)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		LocationList []ClientLocation `json:"locationList"`
+	}
+
+	res := Response{
+		LocationList: locations,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) DeleteLocationHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Id int `json:"id"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.DeleteLocation(ctx, req.Id)
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) SuggestPutawayHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	warehouseId, err := uuid.Parse(query.Get("warehouseId"))
+	if err != nil {
+		return err
+	}
+
+	productId, err := strconv.ParseInt(query.Get("productId"), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	quantity, err := decimal.NewFromString(query.Get("quantity"))
+	if err != nil {
+		quantity = decimal.Zero
+	}
+
+	suggestions, err := SuggestPutaway(ctx, root.repo.db,
+		warehouseId, productId, quantity)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		SuggestionList []PutawaySuggestion `json:"suggestionList"`
+	}
+
+	res := Response{
+		SuggestionList: suggestions,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,48 @@
+package location
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ZoneType  int16 = 1
+	AisleType int16 = 2
+	RackType  int16 = 3
+	BinType   int16 = 4
+)
+
+type Location struct {
+	Id          int                 `json:"id" db:"id"`
+	WarehouseId uuid.UUID           `json:"warehouseId" db:"warehouse_id"`
+	ParentId    *int                `json:"parentId" db:"parent_id"`
+	TypeId      int16               `json:"locationTypeId" db:"location_type_id"`
+	Code        string              `json:"code" db:"code"`
+	Name        string              `json:"name" db:"name"`
+	Capacity    decimal.NullDecimal `json:"capacity" db:"capacity"`
+	Quarantine  bool                `json:"quarantine" db:"quarantine"`
+}
+
+type ClientLocation struct {
+	Id             int                 `json:"id" db:"id"`
+	WarehouseId    uuid.UUID           `json:"warehouseId" db:"warehouse_id"`
+	ParentId       *int                `json:"parentId" db:"parent_id"`
+	TypeId         int16               `json:"locationTypeId" db:"location_type_id"`
+	Code           string              `json:"code" db:"code"`
+	Name           string              `json:"name" db:"name"`
+	Capacity       decimal.NullDecimal `json:"capacity" db:"capacity"`
+	Quarantine     bool                `json:"quarantine" db:"quarantine"`
+	QuantityOnHand decimal.Decimal     `json:"quantityOnHand" db:"quantity_on_hand"`
+	CreatedAt      time.Time           `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time           `json:"updatedAt" db:"updated_at"`
+}
+
+type PutawaySuggestion struct {
+	LocationId     int                 `json:"locationId" db:"id"`
+	Code           string              `json:"code" db:"code"`
+	Capacity       decimal.NullDecimal `json:"capacity" db:"capacity"`
+	QuantityOnHand decimal.Decimal     `json:"quantityOnHand" db:"quantity_on_hand"`
+	ProductOnHand  decimal.Decimal     `json:"productOnHand" db:"product_on_hand"`
+}
@@ -0,0 +1,211 @@
+package location
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func InitRepo(db *sqlx.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+var ErrParent = errors.New("invalid parent location")
+var ErrNotBin = errors.New("location is not a bin of the warehouse")
+var ErrNotEmpty = errors.New("location is not empty")
+
+func (repo *Repo) InsertLocation(
+	ctx context.Context, location Location) error {
+
+	log.Println("InsertLocation", location.WarehouseId, location.ParentId,
+		location.TypeId, location.Code, location.Name)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if location.ParentId == nil {
+		if location.TypeId != ZoneType {
+			return ErrParent
+		}
+	} else {
+		type Parent struct {
+			WarehouseId uuid.UUID `db:"warehouse_id"`
+			TypeId      int16     `db:"location_type_id"`
+		}
+		parent := Parent{}
+
+		query := repo.db.Rebind(`
+            select warehouse_id, location_type_id
+            from warehouse_location where id = ?
+            `)
+		err = tx.GetContext(ctx, &parent, query, *location.ParentId)
+		if err != nil {
+			return err
+		}
+
+		if parent.WarehouseId != location.WarehouseId ||
+			parent.TypeId+1 != location.TypeId {
+			return ErrParent
+		}
+	}
+
+	query := `insert into warehouse_location(
+        warehouse_id, parent_id, location_type_id,
+        code, name, capacity, quarantine)
+        values (:warehouse_id, :parent_id, :location_type_id,
+        :code, :name, :capacity, :quarantine)`
+	_, err = tx.NamedExecContext(ctx, query, location)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *Repo) SelectLocationByWarehouse(
+	ctx context.Context, warehouseId uuid.UUID) ([]ClientLocation, error) {
+
+	log.Println("SelectLocationByWarehouse", warehouseId)
+
+	result := make([]ClientLocation, 0)
+
+	query := repo.db.Rebind(`
+        select l.id, l.warehouse_id, l.parent_id, l.location_type_id,
+        l.code, l.name, l.capacity, l.quarantine,
+        coalesce(s.quantity_on_hand, 0) as quantity_on_hand,
+        l.created_at, l.updated_at
+        from warehouse_location l
+            left join (
+                select location_id, sum(quantity_on_hand) as quantity_on_hand
+                from inventory_item
+                where warehouse_id = ? and location_id is not null
+                group by location_id
+            ) s on s.location_id = l.id
+        where l.warehouse_id = ?
+        order by l.location_type_id, l.code
+        `)
+	err := repo.db.SelectContext(ctx, &result, query, warehouseId, warehouseId)
+
+	return result, err
+}
+
+func (repo *Repo) DeleteLocation(ctx context.Context, id int) error {
+	log.Println("DeleteLocation", id)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var count int
+	query := repo.db.Rebind(`
+        select
+            (select count(*) from warehouse_location where parent_id = ?) +
+            (select count(*) from inventory_item where location_id = ?)
+        `)
+	err = tx.GetContext(ctx, &count, query, id, id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrNotEmpty
+	}
+
+	query = repo.db.Rebind(`delete from warehouse_location where id = ?`)
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SuggestPutaway lists bins able to take the quantity: bins already
+// holding the product come first, then empty bins, then the least
+// filled ones. Quarantine bins are only ever chosen by hand.
+func SuggestPutaway(
+	ctx context.Context, q sqlx.ExtContext,
+	warehouseId uuid.UUID, productId int64,
+	quantity decimal.Decimal) ([]PutawaySuggestion, error) {
+
+	log.Println("SuggestPutaway", warehouseId, productId, quantity)
+
+	result := make([]PutawaySuggestion, 0)
+
+	query := q.Rebind(`
+        select l.id, l.code, l.capacity,
+        coalesce(sum(i.quantity_on_hand), 0) as quantity_on_hand,
+        coalesce(sum(i.quantity_on_hand)
+            filter (where i.product_id = ?), 0) as product_on_hand
+        from warehouse_location l
+            left join inventory_item i
+                on i.location_id = l.id and i.quantity_on_hand > 0
+        where l.warehouse_id = ? and l.location_type_id = ?
+            and not l.quarantine
+        group by l.id
+        having l.capacity is null
+            or l.capacity - coalesce(sum(i.quantity_on_hand), 0) >= ?
+        order by product_on_hand > 0 desc,
+            quantity_on_hand = 0 desc,
+            quantity_on_hand, l.code
+        limit 5
+        `)
+	err := sqlx.SelectContext(ctx, q, &result, query,
+		productId, warehouseId, BinType, quantity)
+
+	return result, err
+}
+
+func ValidateBin(
+	ctx context.Context, q sqlx.ExtContext,
+	warehouseId uuid.UUID, locationId int) error {
+
+	log.Println("ValidateBin", warehouseId, locationId)
+
+	var count int
+	query := q.Rebind(`
+        select count(*) from warehouse_location
+        where id = ? and warehouse_id = ? and location_type_id = ?
+        `)
+	err := sqlx.GetContext(ctx, q, &count, query,
+		locationId, warehouseId, BinType)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotBin
+	}
+	return nil
+}
+
+// IsQuarantine tells whether a location holds stock that must not be
+// sold. What sits in a quarantine bin is on hand but not available.
+func IsQuarantine(
+	ctx context.Context, q sqlx.ExtContext, locationId *int) (bool, error) {
+
+	quarantine := false
+	if locationId == nil {
+		return quarantine, nil
+	}
+
+	query := q.Rebind(`
+        select quarantine from warehouse_location where id = ?
+        `)
+	err := sqlx.GetContext(ctx, q, &quarantine, query, *locationId)
+
+	return quarantine, err
+}
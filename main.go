@@ -11,16 +11,34 @@ import (
 	"time"
 
 	"baseweb/account"
+	"baseweb/allocation"
+	"baseweb/attachment"
 	"baseweb/basic"
+	"baseweb/document"
 	"baseweb/export"
 	"baseweb/facility"
+	"baseweb/idempotency"
 	importProduct "baseweb/import"
+	"baseweb/inventory"
+	"baseweb/invoice"
+	"baseweb/ledger"
+	"baseweb/location"
 	"baseweb/order"
 	"baseweb/product"
+	"baseweb/promotion"
+	"baseweb/purchase"
+	"baseweb/replenishment"
+	"baseweb/reservation"
+	"baseweb/returns"
 	"baseweb/salesman"
 	"baseweb/salesroute"
 	"baseweb/schedule"
 	"baseweb/security"
+	"baseweb/standingorder"
+	"baseweb/tax"
+	"baseweb/trace"
+	"baseweb/transfer"
+	"baseweb/valuation"
 
 	"github.com/go-redis/redis/v7"
 	"github.com/gorilla/mux"
@@ -29,29 +47,63 @@ import (
 )
 
 type Root struct {
-	router         *mux.Router
-	db             *sqlx.DB
-	redisClient    *redis.Client
-	securityRepo   *security.Repo
-	security       *security.Root
-	accountRepo    *account.Repo
-	account        *account.Root
-	productRepo    *product.Repo
-	product        *product.Root
-	facilityRepo   *facility.Repo
-	facility       *facility.Root
-	importRepo     *importProduct.Repo
-	importProduct  *importProduct.Root
-	orderRepo      *order.Repo
-	order          *order.Root
-	exportRepo     *export.Repo
-	export         *export.Root
-	salesrouteRepo *salesroute.Repo
-	salesroute     *salesroute.Root
-	salesman       *salesman.Root
-	salesmanRepo   *salesman.Repo
-	schedule       *schedule.Root
-	scheduleRepo   *schedule.Repo
+	router            *mux.Router
+	db                *sqlx.DB
+	redisClient       *redis.Client
+	securityRepo      *security.Repo
+	security          *security.Root
+	accountRepo       *account.Repo
+	account           *account.Root
+	productRepo       *product.Repo
+	product           *product.Root
+	facilityRepo      *facility.Repo
+	facility          *facility.Root
+	importRepo        *importProduct.Repo
+	importProduct     *importProduct.Root
+	orderRepo         *order.Repo
+	order             *order.Root
+	exportRepo        *export.Repo
+	export            *export.Root
+	salesrouteRepo    *salesroute.Repo
+	salesroute        *salesroute.Root
+	salesman          *salesman.Root
+	salesmanRepo      *salesman.Repo
+	schedule          *schedule.Root
+	scheduleRepo      *schedule.Repo
+	promotion         *promotion.Root
+	promotionRepo     *promotion.Repo
+	tax               *tax.Root
+	taxRepo           *tax.Repo
+	attachment        *attachment.Root
+	attachmentRepo    *attachment.Repo
+	location          *location.Root
+	locationRepo      *location.Repo
+	transfer          *transfer.Root
+	transferRepo      *transfer.Repo
+	inventory         *inventory.Root
+	inventoryRepo     *inventory.Repo
+	allocation        *allocation.Root
+	allocationRepo    *allocation.Repo
+	trace             *trace.Root
+	traceRepo         *trace.Repo
+	valuation         *valuation.Root
+	valuationRepo     *valuation.Repo
+	ledger            *ledger.Root
+	ledgerRepo        *ledger.Repo
+	purchase          *purchase.Root
+	purchaseRepo      *purchase.Repo
+	replenishment     *replenishment.Root
+	replenishmentRepo *replenishment.Repo
+	reservation       *reservation.Root
+	reservationRepo   *reservation.Repo
+	returns           *returns.Root
+	returnsRepo       *returns.Repo
+	invoice           *invoice.Root
+	invoiceRepo       *invoice.Repo
+	document          *document.Root
+	idempotencyRepo   *idempotency.Repo
+	standingorder     *standingorder.Root
+	standingorderRepo *standingorder.Repo
 }
 
 func UnwrapHandler(h basic.Handler) http.HandlerFunc {
@@ -103,6 +155,18 @@ func (root *Root) PostAuthorized(url string, perm string,
 				security.Authorized(perm, handler)))).Methods("POST")
 }
 
+// PostIdempotent is PostAuthorized for create endpoints, where a retry
+// carrying the same Idempotency-Key gets the first response back.
+func (root *Root) PostIdempotent(url string, perm string,
+	handler basic.Handler) {
+	root.router.HandleFunc(url,
+		UnwrapHandler(
+			root.Authenticated(
+				security.Authorized(perm,
+					idempotency.Idempotent(root.idempotencyRepo,
+						handler))))).Methods("POST")
+}
+
 func (root *Root) homeHandler(w http.ResponseWriter, r *http.Request) error {
 	time.Sleep(5 * time.Second)
 
@@ -154,6 +218,45 @@ func main() {
 	db := sqlx.MustConnect("postgres", config)
 	defer db.Close()
 
+	attachmentDir := os.Getenv("ATTACHMENT_DIR")
+	if attachmentDir == "" {
+		attachmentDir = "attachments"
+	}
+
+	documentTemplateDir := os.Getenv("DOCUMENT_TEMPLATE_DIR")
+	if documentTemplateDir == "" {
+		documentTemplateDir = "templates/document"
+	}
+
+	wkhtmltopdfPath := os.Getenv("WKHTMLTOPDF_PATH")
+	if wkhtmltopdfPath == "" {
+		wkhtmltopdfPath = "wkhtmltopdf"
+	}
+
+	replenishmentInterval, err := time.ParseDuration(
+		os.Getenv("REPLENISHMENT_INTERVAL"))
+	if err != nil {
+		replenishmentInterval = time.Hour
+	}
+
+	reservationInterval, err := time.ParseDuration(
+		os.Getenv("RESERVATION_INTERVAL"))
+	if err != nil {
+		reservationInterval = 15 * time.Minute
+	}
+
+	idempotencyInterval, err := time.ParseDuration(
+		os.Getenv("IDEMPOTENCY_INTERVAL"))
+	if err != nil {
+		idempotencyInterval = time.Hour
+	}
+
+	standingOrderInterval, err := time.ParseDuration(
+		os.Getenv("STANDING_ORDER_INTERVAL"))
+	if err != nil {
+		standingOrderInterval = time.Hour
+	}
+
 	redisClient := redis.NewClient(&redis.Options{
 		Addr: fmt.Sprintf("%s:6379", redisHost),
 		DB:   0,
@@ -169,33 +272,88 @@ func main() {
 	salesrouteRepo := salesroute.InitRepo(db)
 	salesmanRepo := salesman.InitRepo(db)
 	scheduleRepo := schedule.InitRepo(db)
+	promotionRepo := promotion.InitRepo(db)
+	taxRepo := tax.InitRepo(db)
+	attachmentRepo := attachment.InitRepo(db)
+	attachmentStorage := attachment.NewLocalStorage(attachmentDir)
+	locationRepo := location.InitRepo(db)
+	transferRepo := transfer.InitRepo(db)
+	inventoryRepo := inventory.InitRepo(db)
+	allocationRepo := allocation.InitRepo(db)
+	traceRepo := trace.InitRepo(db)
+	valuationRepo := valuation.InitRepo(db)
+	ledgerRepo := ledger.InitRepo(db)
+	purchaseRepo := purchase.InitRepo(db)
+	replenishmentRepo := replenishment.InitRepo(db)
+	reservationRepo := reservation.InitRepo(db)
+	returnsRepo := returns.InitRepo(db)
+	invoiceRepo := invoice.InitRepo(db)
+	documentTemplates := document.NewTemplates(documentTemplateDir)
+	documentRenderer := document.NewWkhtmltopdf(wkhtmltopdfPath)
+	idempotencyRepo := idempotency.InitRepo(db)
+	standingorderRepo := standingorder.InitRepo(db)
 
 	router := mux.NewRouter()
 
 	root := &Root{
-		router:         router,
-		db:             db,
-		redisClient:    redisClient,
-		securityRepo:   securityRepo,
-		security:       security.InitRoot(securityRepo),
-		accountRepo:    accountRepo,
-		account:        account.InitRoot(accountRepo),
-		productRepo:    productRepo,
-		product:        product.InitRoot(productRepo),
-		facilityRepo:   facilityRepo,
-		facility:       facility.InitRoot(facilityRepo),
-		importRepo:     importRepo,
-		importProduct:  importProduct.InitRoot(importRepo),
-		orderRepo:      orderRepo,
-		order:          order.InitRoot(orderRepo),
-		exportRepo:     exportRepo,
-		export:         export.InitRoot(exportRepo),
-		salesrouteRepo: salesrouteRepo,
-		salesroute:     salesroute.InitRoot(salesrouteRepo),
-		salesmanRepo:   salesmanRepo,
-		salesman:       salesman.InitRoot(salesmanRepo),
-		scheduleRepo:   scheduleRepo,
-		schedule:       schedule.InitRoot(scheduleRepo),
+		router:            router,
+		db:                db,
+		redisClient:       redisClient,
+		securityRepo:      securityRepo,
+		security:          security.InitRoot(securityRepo),
+		accountRepo:       accountRepo,
+		account:           account.InitRoot(accountRepo),
+		productRepo:       productRepo,
+		product:           product.InitRoot(productRepo),
+		facilityRepo:      facilityRepo,
+		facility:          facility.InitRoot(facilityRepo),
+		importRepo:        importRepo,
+		importProduct:     importProduct.InitRoot(importRepo),
+		orderRepo:         orderRepo,
+		order:             order.InitRoot(orderRepo),
+		exportRepo:        exportRepo,
+		export:            export.InitRoot(exportRepo),
+		salesrouteRepo:    salesrouteRepo,
+		salesroute:        salesroute.InitRoot(salesrouteRepo),
+		salesmanRepo:      salesmanRepo,
+		salesman:          salesman.InitRoot(salesmanRepo),
+		scheduleRepo:      scheduleRepo,
+		schedule:          schedule.InitRoot(scheduleRepo),
+		promotionRepo:     promotionRepo,
+		promotion:         promotion.InitRoot(promotionRepo),
+		taxRepo:           taxRepo,
+		tax:               tax.InitRoot(taxRepo),
+		attachmentRepo:    attachmentRepo,
+		attachment:        attachment.InitRoot(attachmentRepo, attachmentStorage),
+		locationRepo:      locationRepo,
+		location:          location.InitRoot(locationRepo),
+		transferRepo:      transferRepo,
+		transfer:          transfer.InitRoot(transferRepo),
+		inventoryRepo:     inventoryRepo,
+		inventory:         inventory.InitRoot(inventoryRepo),
+		allocationRepo:    allocationRepo,
+		allocation:        allocation.InitRoot(allocationRepo),
+		traceRepo:         traceRepo,
+		trace:             trace.InitRoot(traceRepo),
+		valuationRepo:     valuationRepo,
+		valuation:         valuation.InitRoot(valuationRepo),
+		ledgerRepo:        ledgerRepo,
+		ledger:            ledger.InitRoot(ledgerRepo),
+		purchaseRepo:      purchaseRepo,
+		purchase:          purchase.InitRoot(purchaseRepo),
+		replenishmentRepo: replenishmentRepo,
+		replenishment:     replenishment.InitRoot(replenishmentRepo),
+		reservationRepo:   reservationRepo,
+		reservation:       reservation.InitRoot(reservationRepo),
+		returnsRepo:       returnsRepo,
+		returns:           returns.InitRoot(returnsRepo),
+		invoiceRepo:       invoiceRepo,
+		invoice:           invoice.InitRoot(invoiceRepo),
+		document: document.InitRoot(orderRepo, exportRepo, invoiceRepo,
+			documentTemplates, documentRenderer),
+		idempotencyRepo:   idempotencyRepo,
+		standingorderRepo: standingorderRepo,
+		standingorder:     standingorder.InitRoot(standingorderRepo),
 	}
 
 	root.GetAuthorized("/", "VIEW_EDIT_USER_LOGIN", root.homeHandler)
@@ -210,11 +368,37 @@ func main() {
 	SalesrouteRoutes(root)
 	SalesmanRoutes(root)
 	ScheduleRoutes(root)
+	PromotionRoutes(root)
+	TaxRoutes(root)
+	AttachmentRoutes(root)
+	LocationRoutes(root)
+	TransferRoutes(root)
+	InventoryRoutes(root)
+	AllocationRoutes(root)
+	TraceRoutes(root)
+	ValuationRoutes(root)
+	LedgerRoutes(root)
+	PurchaseRoutes(root)
+	ReplenishmentRoutes(root)
+	ReservationRoutes(root)
+	ReturnsRoutes(root)
+	InvoiceRoutes(root)
+	DocumentRoutes(root)
+	StandingorderRoutes(root)
+
+	go replenishment.RunJob(context.Background(),
+		replenishmentRepo, replenishmentInterval)
+	go order.RunJob(context.Background(),
+		orderRepo, reservationInterval)
+	go idempotency.RunJob(context.Background(),
+		idempotencyRepo, idempotencyInterval)
+	go standingorder.RunJob(context.Background(),
+		standingorderRepo, standingOrderInterval)
 
 	http.Handle("/", router)
 
 	log.Println("Server is running")
-	err := http.ListenAndServe(":8080",
+	err = http.ListenAndServe(":8080",
 		http.HandlerFunc(applyJson(http.DefaultServeMux)))
 	log.Fatal(err)
 }
@@ -6,7 +6,7 @@ func OrderRoutes(root *Root) {
 		"VIEW_EDIT_ORDER",
 		root.order.ViewCustomerStoreByCustomerHandler)
 
-	root.PostAuthorized(
+	root.PostIdempotent(
 		"/api/order/add-order",
 		"VIEW_EDIT_ORDER",
 		root.order.AddOrderHandler)
@@ -35,4 +35,19 @@ func OrderRoutes(root *Root) {
 		"/api/order/cancel-sales-order",
 		"VIEW_EDIT_ORDER",
 		root.order.CancelSalesOrderHandler)
+
+	root.PostIdempotent(
+		"/api/order/add-order-item",
+		"VIEW_EDIT_ORDER",
+		root.order.AddOrderItemHandler)
+
+	root.PostAuthorized(
+		"/api/order/update-order-item-quantity",
+		"VIEW_EDIT_ORDER",
+		root.order.UpdateOrderItemQuantityHandler)
+
+	root.PostAuthorized(
+		"/api/order/remove-order-item",
+		"VIEW_EDIT_ORDER",
+		root.order.RemoveOrderItemHandler)
 }
@@ -2,11 +2,13 @@ package order
 
 import (
 	"baseweb/basic"
+	"baseweb/product"
 	"baseweb/security"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
@@ -121,14 +123,26 @@ func (root *Root) AddOrderHandler(
 		}
 	}
 
-	err = root.repo.AddOrder(ctx, req.CustomerId,
+	id, err := root.repo.AddOrder(ctx, req.CustomerId,
 		req.WarehouseId, req.Products, req.Address,
 		req.CustomerStoreId, userLogin.Id)
+	if errors.Is(err, product.ErrBarcode) {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil
+	}
 	if err != nil {
-		return err
+		return ReturnConflict(w, err)
 	}
 
-	return basic.ReturnOk(w)
+	type Response struct {
+		Id int64 `json:"id"`
+	}
+
+	res := Response{
+		Id: id,
+	}
+
+	return json.NewEncoder(w).Encode(res)
 }
 
 func (root *Root) ViewProductInfoByWarehouseHandler(
@@ -274,14 +288,28 @@ func (root *Root) ViewSingleSaleOrderHandler(
 		return err
 	}
 
+	promotions, err := root.repo.SelectSaleOrderPromotion(ctx, saleOrderId)
+	if err != nil {
+		return err
+	}
+
+	history, err := root.repo.SelectStatusHistory(ctx, saleOrderId)
+	if err != nil {
+		return err
+	}
+
 	type Response struct {
-		Order      SaleOrder       `json:"order"`
-		OrderItems []SaleOrderItem `json:"orderItems"`
+		Order         SaleOrder            `json:"order"`
+		OrderItems    []SaleOrderItem      `json:"orderItems"`
+		Promotions    []SaleOrderPromotion `json:"promotions"`
+		StatusHistory []StatusHistory      `json:"statusHistory"`
 	}
 
 	res := Response{
-		Order:      order,
-		OrderItems: items,
+		Order:         order,
+		OrderItems:    items,
+		Promotions:    promotions,
+		StatusHistory: history,
 	}
 
 	return json.NewEncoder(w).Encode(res)
@@ -291,6 +319,7 @@ func (root *Root) AcceptSalesOrderHandler(
 	w http.ResponseWriter, r *http.Request) error {
 
 	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
 
 	type Request struct {
 		Id int64 `json:"id"`
@@ -302,9 +331,9 @@ func (root *Root) AcceptSalesOrderHandler(
 		return err
 	}
 
-	err = root.repo.AcceptSalesOrder(ctx, req.Id)
+	err = root.repo.AcceptSalesOrder(ctx, req.Id, userLogin.Id)
 	if err != nil {
-		return err
+		return ReturnConflict(w, err)
 	}
 
 	return basic.ReturnOk(w)
@@ -314,9 +343,11 @@ func (root *Root) CancelSalesOrderHandler(
 	w http.ResponseWriter, r *http.Request) error {
 
 	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
 
 	type Request struct {
-		Id int64 `json:"id"`
+		Id     int64  `json:"id"`
+		Reason string `json:"reason"`
 	}
 
 	req := Request{}
@@ -325,10 +356,114 @@ func (root *Root) CancelSalesOrderHandler(
 		return err
 	}
 
-	err = root.repo.CancelSalesOrder(ctx, req.Id)
+	err = root.repo.CancelSalesOrder(ctx, req.Id,
+		userLogin.Id, req.Reason)
 	if err != nil {
-		return err
+		return ReturnConflict(w, err)
 	}
 
 	return basic.ReturnOk(w)
 }
+
+func (root *Root) AddOrderItemHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		SaleOrderId int64         `json:"saleOrderId"`
+		UpdatedAt   time.Time     `json:"updatedAt"`
+		Product     ClientProduct `json:"product"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	if req.Product.Quantity.LessThanOrEqual(decimal.Zero) {
+		return bodyError
+	}
+
+	updatedAt, err := root.repo.AddOrderItem(ctx,
+		req.SaleOrderId, req.UpdatedAt, req.Product)
+	if errors.Is(err, product.ErrBarcode) {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil
+	}
+	if err != nil {
+		return ReturnConflict(w, err)
+	}
+
+	type Response struct {
+		UpdatedAt time.Time `json:"updatedAt"`
+	}
+
+	return json.NewEncoder(w).Encode(Response{UpdatedAt: updatedAt})
+}
+
+func (root *Root) UpdateOrderItemQuantityHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		SaleOrderId  int64           `json:"saleOrderId"`
+		UpdatedAt    time.Time       `json:"updatedAt"`
+		SaleOrderSeq int             `json:"saleOrderSeq"`
+		Quantity     decimal.Decimal `json:"quantity"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	if req.Quantity.LessThanOrEqual(decimal.Zero) {
+		return bodyError
+	}
+
+	updatedAt, err := root.repo.UpdateOrderItemQuantity(ctx,
+		req.SaleOrderId, req.UpdatedAt, req.SaleOrderSeq, req.Quantity)
+	if err != nil {
+		return ReturnConflict(w, err)
+	}
+
+	type Response struct {
+		UpdatedAt time.Time `json:"updatedAt"`
+	}
+
+	return json.NewEncoder(w).Encode(Response{UpdatedAt: updatedAt})
+}
+
+func (root *Root) RemoveOrderItemHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		SaleOrderId  int64     `json:"saleOrderId"`
+		UpdatedAt    time.Time `json:"updatedAt"`
+		SaleOrderSeq int       `json:"saleOrderSeq"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	updatedAt, err := root.repo.RemoveOrderItem(ctx,
+		req.SaleOrderId, req.UpdatedAt, req.SaleOrderSeq)
+	if err != nil {
+		return ReturnConflict(w, err)
+	}
+
+	type Response struct {
+		UpdatedAt time.Time `json:"updatedAt"`
+	}
+
+	return json.NewEncoder(w).Encode(Response{UpdatedAt: updatedAt})
+}
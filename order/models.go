@@ -1,12 +1,21 @@
 package order
 
 import (
+	"baseweb/basic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 )
 
+const (
+	CreatedStatus   int16 = 1
+	AcceptedStatus  int16 = 2
+	ShippingStatus  int16 = 3
+	CompletedStatus int16 = 4
+	CanceledStatus  int16 = 5
+)
+
 type CustomerStore struct {
 	Id         uuid.UUID `json:"id" db:"id"`
 	Name       string    `json:"name" db:"name"`
@@ -19,6 +28,7 @@ type CustomerStore struct {
 
 type ClientProduct struct {
 	Id       int             `json:"id"`
+	Barcode  string          `json:"barcode"`
 	Quantity decimal.Decimal `json:"quantity"`
 }
 
@@ -38,24 +48,49 @@ type ProductInfo struct {
 }
 
 type SaleOrder struct {
-	Id            int64     `json:"id" db:"id"`
-	Customer      string    `json:"customer" db:"customer"`
-	Warehouse     string    `json:"warehouse" db:"warehouse"`
-	CreatedBy     string    `json:"createdBy" db:"created_by"`
-	Address       string    `json:"address" db:"ship_to_address"`
-	CustomerStore string    `json:"customerStore" db:"customer_store"`
-	StatusId      int       `json:"statusId" db:"sale_order_status_id"`
-	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt     time.Time `json:"updatedAt" db:"updated_at"`
+	Id             int64           `json:"id" db:"id"`
+	Customer       string          `json:"customer" db:"customer"`
+	Warehouse      string          `json:"warehouse" db:"warehouse"`
+	CreatedBy      string          `json:"createdBy" db:"created_by"`
+	Address        string          `json:"address" db:"ship_to_address"`
+	CustomerStore  string          `json:"customerStore" db:"customer_store"`
+	StatusId       int             `json:"statusId" db:"sale_order_status_id"`
+	DiscountAmount decimal.Decimal `json:"discountAmount" db:"discount_amount"`
+	NetAmount      decimal.Decimal `json:"netAmount" db:"net_amount"`
+	TaxAmount      decimal.Decimal `json:"taxAmount" db:"tax_amount"`
+	GrossAmount    decimal.Decimal `json:"grossAmount" db:"gross_amount"`
+	CreatedAt      time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updatedAt" db:"updated_at"`
 }
 
 type SaleOrderItem struct {
-	SaleOrderId   int64           `json:"saleOrderId" db:"sale_order_id"`
-	SaleOrderSeq  int             `json:"saleOrderSeq" db:"sale_order_seq"`
-	ProductName   string          `json:"productName" db:"product_name"`
-	Price         decimal.Decimal `json:"price" db:"price"`
-	CurrencyUomId string          `json:"currencyUomId" db:"currency_uom_id"`
-	Quantity      decimal.Decimal `json:"quantity" db:"quantity"`
-	EffectiveFrom time.Time       `json:"effectiveFrom" db:"effective_from"`
-	Exported      bool            `json:"exported" db:"exported"`
+	SaleOrderId     int64           `json:"saleOrderId" db:"sale_order_id"`
+	SaleOrderSeq    int             `json:"saleOrderSeq" db:"sale_order_seq"`
+	ProductName     string          `json:"productName" db:"product_name"`
+	Price           decimal.Decimal `json:"price" db:"price"`
+	CurrencyUomId   string          `json:"currencyUomId" db:"currency_uom_id"`
+	Quantity        decimal.Decimal `json:"quantity" db:"quantity"`
+	EffectiveFrom   time.Time       `json:"effectiveFrom" db:"effective_from"`
+	Exported        bool            `json:"exported" db:"exported"`
+	ShippedQuantity decimal.Decimal `json:"shippedQuantity" db:"shipped_quantity"`
+	BackorderOfSeq  *int            `json:"backorderOfSeq" db:"backorder_of_seq"`
+	DiscountAmount  decimal.Decimal `json:"discountAmount" db:"discount_amount"`
+	TaxRate         decimal.Decimal `json:"taxRate" db:"tax_rate"`
+	TaxAmount       decimal.Decimal `json:"taxAmount" db:"tax_amount"`
+}
+
+type SaleOrderPromotion struct {
+	PromotionId     int             `json:"promotionId" db:"promotion_id"`
+	Name            string          `json:"name" db:"name"`
+	PromotionTypeId int16           `json:"promotionTypeId" db:"promotion_type_id"`
+	SaleOrderSeq    *int            `json:"saleOrderSeq" db:"sale_order_seq"`
+	DiscountAmount  decimal.Decimal `json:"discountAmount" db:"discount_amount"`
+}
+
+type StatusHistory struct {
+	FromStatusId *int16           `json:"fromStatusId" db:"from_status_id"`
+	ToStatusId   int16            `json:"toStatusId" db:"to_status_id"`
+	ChangedBy    basic.NullString `json:"changedBy" db:"changed_by"`
+	Reason       basic.NullString `json:"reason" db:"reason"`
+	ChangedAt    time.Time        `json:"changedAt" db:"changed_at"`
 }
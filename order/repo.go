@@ -1,7 +1,12 @@
 package order
 
 import (
+	"baseweb/product"
+	"baseweb/promotion"
+	"baseweb/reservation"
+	"baseweb/tax"
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log"
@@ -82,77 +87,521 @@ func (repo *Repo) SelectCustomerStoreByCustomer(
 
 var quantityAvailableErr error = errors.New("quantity available exceeded")
 
+var ErrProductNotActive = errors.New("product is not active")
+
 func (repo *Repo) AddOrder(
 	ctx context.Context,
 	customerId, warehouseId uuid.UUID,
 	products []ClientProduct,
 	address string,
 	customerStoreId *uuid.UUID,
-	userLoginId uuid.UUID) error {
+	userLoginId uuid.UUID) (int64, error) {
 
 	log.Println("AddOrder", customerId, warehouseId, products,
 		address, customerStoreId)
 
 	tx, err := repo.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer tx.Rollback()
 
+	id, err := repo.InsertOrder(ctx, tx, customerId, warehouseId,
+		products, address, customerStoreId, userLoginId, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// InsertOrder creates a priced order with its items reserved within tx,
+// so a caller can record in the same transaction what the order was
+// created for.
+func (repo *Repo) InsertOrder(
+	ctx context.Context, tx *sqlx.Tx,
+	customerId, warehouseId uuid.UUID,
+	products []ClientProduct,
+	address string,
+	customerStoreId *uuid.UUID,
+	userLoginId uuid.UUID,
+	now time.Time) (int64, error) {
+
 	query := `insert into sale_order(
         customer_id, original_warehouse_id,
         created_by_user_login_id, ship_to_address, 
         ship_to_facility_customer_id,
         sale_order_status_id)
-        values (?, ?, ?, ?, ?, 1) returning id`
+        values (?, ?, ?, ?, ?, ?) returning id`
 	query = repo.db.Rebind(query)
 
 	var orderId int64
-	err = tx.GetContext(ctx, &orderId, query,
-		customerId, warehouseId, userLoginId, address, customerStoreId)
+	err := tx.GetContext(ctx, &orderId, query,
+		customerId, warehouseId, userLoginId, address, customerStoreId,
+		CreatedStatus)
 	if err != nil {
-		return err
+		return orderId, err
 	}
 
-	now := time.Now()
+	err = recordStatus(ctx, tx, nil, StatusChange{
+		SaleOrderId: orderId,
+		StatusId:    CreatedStatus,
+		UserLoginId: &userLoginId,
+		ChangedAt:   now,
+	})
+	if err != nil {
+		return orderId, err
+	}
+
+	for index, item := range products {
+		err = insertOrderItem(ctx, tx, orderId, index,
+			warehouseId, item, now)
+		if err != nil {
+			return orderId, err
+		}
+	}
+
+	err = repo.priceOrder(ctx, tx, orderId, now)
+
+	return orderId, err
+}
+
+// insertOrderItem adds item as line seq of the order at its current
+// price and reserves the quantity in the warehouse.
+func insertOrderItem(
+	ctx context.Context, tx *sqlx.Tx,
+	orderId int64, seq int, warehouseId uuid.UUID,
+	item ClientProduct, now time.Time) error {
+
+	var err error
+	productId := int64(item.Id)
+	if item.Barcode != "" {
+		productId, err = product.ResolveBarcode(ctx, tx, item.Barcode)
+		if err != nil {
+			return err
+		}
+	}
 
-	priceQuery := `select id from product_price
+	var statusId int16
+	query := tx.Rebind(
+		`select product_status_id from product where id = ?`)
+	err = tx.GetContext(ctx, &statusId, query, productId)
+	if err != nil {
+		return err
+	}
+	if statusId != product.ActiveStatus {
+		return ErrProductNotActive
+	}
+
+	query = `select id from product_price
         where product_id = ?
             and effective_from <= ? 
             and (expired_at is null or ? < expired_at)`
-	priceQuery = repo.db.Rebind(priceQuery)
+	query = tx.Rebind(query)
+
+	var priceId uuid.UUID
+	err = tx.GetContext(ctx, &priceId, query, productId, now, now)
+	if err == sql.ErrNoRows {
+		return product.ErrNoPrice
+	}
+	if err != nil {
+		return err
+	}
 
 	query = `insert into sale_order_item(
         sale_order_id, sale_order_seq,
         product_price_id, quantity)
         values (?, ?, ?, ?)`
-	query = repo.db.Rebind(query)
+	query = tx.Rebind(query)
+
+	_, err = tx.ExecContext(ctx, query, orderId, seq, priceId, item.Quantity)
+	if err != nil {
+		return err
+	}
 
-	updateAvailableQuery := `update warehouse_product_statistics
-        set quantity_available = quantity_available - ?
-        where product_id = ? and warehouse_id = ?`
-	updateAvailableQuery = repo.db.Rebind(updateAvailableQuery)
+	return reservation.Reserve(ctx, tx, reservation.Reservation{
+		SaleOrderId:  orderId,
+		SaleOrderSeq: seq,
+		WarehouseId:  warehouseId,
+		ProductId:    productId,
+		Quantity:     item.Quantity,
+	}, now)
+}
+
+func (repo *Repo) priceOrder(
+	ctx context.Context, tx *sqlx.Tx,
+	orderId int64, now time.Time) error {
+
+	log.Println("priceOrder", orderId)
+
+	type OrderInfo struct {
+		CustomerId      uuid.UUID  `db:"customer_id"`
+		CustomerStoreId *uuid.UUID `db:"ship_to_facility_customer_id"`
+	}
+	info := OrderInfo{}
 
-	for index, product := range products {
-		var priceId uuid.UUID
-		err = tx.GetContext(ctx, &priceId, priceQuery, product.Id, now, now)
+	query := repo.db.Rebind(`
+        select customer_id, ship_to_facility_customer_id
+        from sale_order where id = ?
+        `)
+	err := tx.GetContext(ctx, &info, query, orderId)
+	if err != nil {
+		return err
+	}
+
+	type OrderLine struct {
+		Seq           int             `db:"sale_order_seq"`
+		ProductId     int64           `db:"product_id"`
+		Price         decimal.Decimal `db:"price"`
+		CurrencyUomId string          `db:"currency_uom_id"`
+		Quantity      decimal.Decimal `db:"quantity"`
+	}
+	orderLines := make([]OrderLine, 0)
+
+	query = repo.db.Rebind(`
+        select i.sale_order_seq, pp.product_id,
+        pp.price, pp.currency_uom_id, i.quantity
+        from sale_order_item i
+            inner join product_price pp on pp.id = i.product_price_id
+        where i.sale_order_id = ? and i.removed_at is null
+        order by i.sale_order_seq
+        `)
+	err = tx.SelectContext(ctx, &orderLines, query, orderId)
+	if err != nil {
+		return err
+	}
+
+	lines := make([]promotion.Line, len(orderLines))
+	for i, l := range orderLines {
+		lines[i] = promotion.Line{
+			Seq:           l.Seq,
+			ProductId:     l.ProductId,
+			Price:         l.Price,
+			CurrencyUomId: l.CurrencyUomId,
+			Quantity:      l.Quantity,
+		}
+	}
+
+	promotions, err := promotion.SelectActivePromotion(ctx, tx,
+		info.CustomerId, info.CustomerStoreId, now)
+	if err != nil {
+		return err
+	}
+
+	result := promotion.Evaluate(promotions, lines)
+
+	query = repo.db.Rebind(`
+        delete from sale_order_promotion where sale_order_id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, orderId)
+	if err != nil {
+		return err
+	}
+
+	query = repo.db.Rebind(`
+        update sale_order_item set discount_amount = ?
+        where sale_order_id = ? and sale_order_seq = ?
+        `)
+	for _, line := range lines {
+		_, err = tx.ExecContext(ctx, query,
+			result.LineDiscounts[line.Seq], orderId, line.Seq)
 		if err != nil {
 			return err
 		}
+	}
 
-		_, err = tx.ExecContext(ctx, updateAvailableQuery,
-			product.Quantity, product.Id, warehouseId)
+	query = repo.db.Rebind(`
+        insert into sale_order_promotion(
+        sale_order_id, sale_order_seq,
+        promotion_id, discount_amount)
+        values (?, ?, ?, ?)
+        `)
+	for _, applied := range result.Applied {
+		_, err = tx.ExecContext(ctx, query, orderId,
+			applied.Seq, applied.PromotionId, applied.DiscountAmount)
 		if err != nil {
 			return err
 		}
+	}
+
+	productIdList := make([]int64, len(lines))
+	for i, line := range lines {
+		productIdList[i] = line.ProductId
+	}
 
-		_, err = tx.ExecContext(ctx, query, orderId, index, priceId, product.Quantity)
+	rates, err := tax.SelectProductTaxRate(ctx, tx, productIdList, now)
+	if err != nil {
+		return err
+	}
+
+	taxLines := make([]tax.Line, len(lines))
+	for i, line := range lines {
+		taxLines[i] = tax.Line{
+			Seq: line.Seq,
+			NetAmount: line.Price.Mul(line.Quantity).
+				Sub(result.LineDiscounts[line.Seq]),
+			Rate: rates[line.ProductId],
+		}
+	}
+
+	totals := tax.Compute(taxLines, result.OrderDiscount)
+
+	query = repo.db.Rebind(`
+        update sale_order_item set tax_rate = ?, tax_amount = ?
+        where sale_order_id = ? and sale_order_seq = ?
+        `)
+	for _, line := range taxLines {
+		_, err = tx.ExecContext(ctx, query, line.Rate,
+			totals.LineTaxes[line.Seq], orderId, line.Seq)
 		if err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit()
+	query = repo.db.Rebind(`
+        update sale_order
+        set discount_amount = ?,
+            net_amount = ?, tax_amount = ?, gross_amount = ?
+        where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, result.OrderDiscount,
+		totals.NetAmount, totals.TaxAmount, totals.GrossAmount, orderId)
+	return err
+}
+
+var ErrOrderNotEditable = errors.New("sale order is no longer editable")
+
+var ErrStaleOrder = errors.New("sale order was changed by someone else")
+
+var ErrLastItem = errors.New("cannot remove the last item of a sale order")
+
+// lockEditableOrder locks an order that is still created and was last
+// changed at updatedAt, returning its warehouse. The caller saw the
+// order as of updatedAt, so any later change means its edit would
+// overwrite someone else's.
+func lockEditableOrder(
+	ctx context.Context, tx *sqlx.Tx,
+	orderId int64, updatedAt time.Time) (uuid.UUID, error) {
+
+	type OrderInfo struct {
+		WarehouseId uuid.UUID `db:"original_warehouse_id"`
+		StatusId    int16     `db:"sale_order_status_id"`
+		UpdatedAt   time.Time `db:"updated_at"`
+	}
+	info := OrderInfo{}
+
+	query := tx.Rebind(`
+        select original_warehouse_id, sale_order_status_id, updated_at
+        from sale_order where id = ?
+        for update
+        `)
+	err := tx.GetContext(ctx, &info, query, orderId)
+	if err != nil {
+		return info.WarehouseId, err
+	}
+
+	if info.StatusId != CreatedStatus {
+		return info.WarehouseId, ErrOrderNotEditable
+	}
+	if !info.UpdatedAt.Equal(updatedAt) {
+		return info.WarehouseId, ErrStaleOrder
+	}
+
+	return info.WarehouseId, nil
+}
+
+// repriceOrder moves the remaining lines to the prices in effect now
+// and prices the order again. A line whose product has no current
+// price keeps the one it has.
+func (repo *Repo) repriceOrder(
+	ctx context.Context, tx *sqlx.Tx,
+	orderId int64, now time.Time) (time.Time, error) {
+
+	var updatedAt time.Time
+
+	query := repo.db.Rebind(`
+        update sale_order_item i
+        set product_price_id = pp.id
+        from product_price cur, product_price pp
+        where cur.id = i.product_price_id
+            and pp.product_id = cur.product_id
+            and pp.effective_from <= ?
+            and (pp.expired_at is null or ? < pp.expired_at)
+            and i.sale_order_id = ? and i.removed_at is null
+        `)
+	_, err := tx.ExecContext(ctx, query, now, now, orderId)
+	if err != nil {
+		return updatedAt, err
+	}
+
+	err = repo.priceOrder(ctx, tx, orderId, now)
+	if err != nil {
+		return updatedAt, err
+	}
+
+	query = repo.db.Rebind(`select updated_at from sale_order where id = ?`)
+	err = tx.GetContext(ctx, &updatedAt, query, orderId)
+
+	return updatedAt, err
+}
+
+func (repo *Repo) AddOrderItem(
+	ctx context.Context,
+	orderId int64, updatedAt time.Time,
+	item ClientProduct) (time.Time, error) {
+
+	log.Println("AddOrderItem", orderId, updatedAt, item)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return updatedAt, err
+	}
+	defer tx.Rollback()
+
+	warehouseId, err := lockEditableOrder(ctx, tx, orderId, updatedAt)
+	if err != nil {
+		return updatedAt, err
+	}
+
+	// removed lines keep their seq, so the next one comes after them
+	var seq int
+	query := repo.db.Rebind(`
+        select coalesce(max(sale_order_seq) + 1, 0)
+        from sale_order_item where sale_order_id = ?
+        `)
+	err = tx.GetContext(ctx, &seq, query, orderId)
+	if err != nil {
+		return updatedAt, err
+	}
+
+	now := time.Now()
+	err = insertOrderItem(ctx, tx, orderId, seq, warehouseId, item, now)
+	if err != nil {
+		return updatedAt, err
+	}
+
+	updatedAt, err = repo.repriceOrder(ctx, tx, orderId, now)
+	if err != nil {
+		return updatedAt, err
+	}
+
+	return updatedAt, tx.Commit()
+}
+
+func (repo *Repo) UpdateOrderItemQuantity(
+	ctx context.Context,
+	orderId int64, updatedAt time.Time,
+	seq int, quantity decimal.Decimal) (time.Time, error) {
+
+	log.Println("UpdateOrderItemQuantity", orderId, updatedAt,
+		seq, quantity)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return updatedAt, err
+	}
+	defer tx.Rollback()
+
+	_, err = lockEditableOrder(ctx, tx, orderId, updatedAt)
+	if err != nil {
+		return updatedAt, err
+	}
+
+	query := repo.db.Rebind(`
+        update sale_order_item set quantity = ?
+        where sale_order_id = ? and sale_order_seq = ?
+            and removed_at is null
+        `)
+	result, err := tx.ExecContext(ctx, query, quantity, orderId, seq)
+	if err != nil {
+		return updatedAt, err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return updatedAt, err
+	}
+	if rowAffected < 1 {
+		return updatedAt, sql.ErrNoRows
+	}
+
+	now := time.Now()
+	err = reservation.Resize(ctx, tx, orderId, seq, quantity, now)
+	if err != nil {
+		return updatedAt, err
+	}
+
+	updatedAt, err = repo.repriceOrder(ctx, tx, orderId, now)
+	if err != nil {
+		return updatedAt, err
+	}
+
+	return updatedAt, tx.Commit()
+}
+
+// RemoveOrderItem takes a line off the order. The line is kept, marked
+// removed, because the stock ledger refers to it.
+func (repo *Repo) RemoveOrderItem(
+	ctx context.Context,
+	orderId int64, updatedAt time.Time,
+	seq int) (time.Time, error) {
+
+	log.Println("RemoveOrderItem", orderId, updatedAt, seq)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return updatedAt, err
+	}
+	defer tx.Rollback()
+
+	_, err = lockEditableOrder(ctx, tx, orderId, updatedAt)
+	if err != nil {
+		return updatedAt, err
+	}
+
+	var count int
+	query := repo.db.Rebind(`
+        select count(*) from sale_order_item
+        where sale_order_id = ? and removed_at is null
+        `)
+	err = tx.GetContext(ctx, &count, query, orderId)
+	if err != nil {
+		return updatedAt, err
+	}
+	if count <= 1 {
+		return updatedAt, ErrLastItem
+	}
+
+	now := time.Now()
+	query = repo.db.Rebind(`
+        update sale_order_item
+        set removed_at = ?, discount_amount = 0,
+            tax_rate = 0, tax_amount = 0
+        where sale_order_id = ? and sale_order_seq = ?
+            and removed_at is null
+        `)
+	result, err := tx.ExecContext(ctx, query, now, orderId, seq)
+	if err != nil {
+		return updatedAt, err
+	}
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return updatedAt, err
+	}
+	if rowAffected < 1 {
+		return updatedAt, sql.ErrNoRows
+	}
+
+	err = reservation.ReleaseItem(ctx, tx, orderId, seq, now)
+	if err != nil {
+		return updatedAt, err
+	}
+
+	updatedAt, err = repo.repriceOrder(ctx, tx, orderId, now)
+	if err != nil {
+		return updatedAt, err
+	}
+
+	return updatedAt, tx.Commit()
 }
 
 func (repo *Repo) ViewProductInfoByWarehouse(
@@ -173,10 +622,12 @@ func (repo *Repo) ViewProductInfoByWarehouse(
            inner join product_price pp on pp.product_id = p.id
         where
            s.warehouse_id = ?
+           and p.product_status_id = ?
            and pp.effective_from <= ?
            and (pp.expired_at is null or ? < pp.expired_at)`
 	query = repo.db.Rebind(query)
-	err := repo.db.GetContext(ctx, &count, query, warehouseId, now, now)
+	err := repo.db.GetContext(ctx, &count, query,
+		warehouseId, product.ActiveStatus, now, now)
 	if err != nil {
 		return count, result, err
 	}
@@ -192,6 +643,7 @@ func (repo *Repo) ViewProductInfoByWarehouse(
            inner join user_login u on u.id = p.created_by_user_login_id
         where
            s.warehouse_id = ?
+           and p.product_status_id = ?
            and pp.effective_from <= ?
            and (pp.expired_at is null or ? < pp.expired_at)
         order by p.%s %s
@@ -199,7 +651,8 @@ func (repo *Repo) ViewProductInfoByWarehouse(
 	query = fmt.Sprintf(query, sortedBy, sortOrder)
 	query = repo.db.Rebind(query)
 	err = repo.db.SelectContext(ctx, &result, query,
-		warehouseId, now, now, page*pageSize, pageSize)
+		warehouseId, product.ActiveStatus, now, now,
+		page*pageSize, pageSize)
 
 	return count, result, err
 }
@@ -224,11 +677,12 @@ func (repo *Repo) SelectProductInfoByWarehouse(
            inner join user_login u on u.id = p.created_by_user_login_id
         where
            s.warehouse_id = ?
+           and p.product_status_id = ?
            and pp.effective_from <= ?
            and (pp.expired_at is null or ? < pp.expired_at)`
 	query = repo.db.Rebind(query)
 	err := repo.db.SelectContext(ctx, &result, query,
-		warehouseId, now, now)
+		warehouseId, product.ActiveStatus, now, now)
 
 	return result, err
 }
@@ -323,7 +777,8 @@ func (repo *Repo) GetSaleOrder(
         fw.name as warehouse, u.username as created_by,
         o.ship_to_address,
         coalesce(fc.name, '') as customer_store,
-        o.sale_order_status_id,
+        o.sale_order_status_id, o.discount_amount,
+        o.net_amount, o.tax_amount, o.gross_amount,
         o.created_at, o.updated_at
         from sale_order o
             inner join customer c on c.id = o.customer_id
@@ -343,11 +798,13 @@ func (repo *Repo) GetSaleOrder(
 
 	query = `select i.sale_order_id, i.sale_order_seq,
         p.name as product_name, pp.price, pp.currency_uom_id,
-        i.quantity, pp.effective_from, i.exported
+        i.quantity, pp.effective_from, i.exported,
+        i.shipped_quantity, i.backorder_of_seq,
+        i.discount_amount, i.tax_rate, i.tax_amount
         from sale_order_item i
             inner join product_price pp on pp.id = i.product_price_id
             inner join product p on p.id = pp.product_id
-        where i.sale_order_id = ?
+        where i.sale_order_id = ? and i.removed_at is null
         order by i.sale_order_seq`
 	query = repo.db.Rebind(query)
 	err = repo.db.SelectContext(ctx, &items, query, saleOrderId)
@@ -355,23 +812,50 @@ func (repo *Repo) GetSaleOrder(
 	return order, items, err
 }
 
-func (repo *Repo) AcceptSalesOrder(ctx context.Context, id int64) error {
-	log.Println("AcceptSalesOrder", id)
+func (repo *Repo) SelectSaleOrderPromotion(
+	ctx context.Context,
+	saleOrderId int64,
+) ([]SaleOrderPromotion, error) {
+	log.Println("SelectSaleOrderPromotion", saleOrderId)
+
+	result := make([]SaleOrderPromotion, 0)
 
 	query := repo.db.Rebind(`
-        update sale_order
-        set sale_order_status_id = 2
-        where id = ? and sale_order_status_id = 1
+        select sp.promotion_id, pr.name, pr.promotion_type_id,
+        sp.sale_order_seq, sp.discount_amount
+        from sale_order_promotion sp
+            inner join promotion pr on pr.id = sp.promotion_id
+        where sp.sale_order_id = ?
+        order by sp.sale_order_seq nulls last
         `)
+	err := repo.db.SelectContext(ctx, &result, query, saleOrderId)
+	return result, err
+}
 
-	_, err := repo.db.ExecContext(ctx, query, id)
-	return err
+func (repo *Repo) SelectStatusHistory(
+	ctx context.Context,
+	saleOrderId int64,
+) ([]StatusHistory, error) {
+	log.Println("SelectStatusHistory", saleOrderId)
+
+	result := make([]StatusHistory, 0)
+
+	query := repo.db.Rebind(`
+        select h.from_status_id, h.to_status_id,
+        u.username as changed_by, h.reason, h.changed_at
+        from sale_order_status_history h
+            left join user_login u on u.id = h.changed_by_user_login_id
+        where h.sale_order_id = ?
+        order by h.changed_at, h.id
+        `)
+	err := repo.db.SelectContext(ctx, &result, query, saleOrderId)
+	return result, err
 }
 
-var cancelErr = errors.New("cancel wrong sales order")
+func (repo *Repo) AcceptSalesOrder(
+	ctx context.Context, id int64, userLoginId uuid.UUID) error {
 
-func (repo *Repo) CancelSalesOrder(ctx context.Context, id int64) error {
-	log.Println("CancelSalesOrder", id)
+	log.Println("AcceptSalesOrder", id)
 
 	tx, err := repo.db.BeginTxx(ctx, nil)
 	if err != nil {
@@ -379,56 +863,107 @@ func (repo *Repo) CancelSalesOrder(ctx context.Context, id int64) error {
 	}
 	defer tx.Rollback()
 
-	query := repo.db.Rebind(`
-        update sale_order
-        set sale_order_status_id = 5
-        where id = ? and sale_order_status_id = 1
-        `)
+	err = ChangeStatus(ctx, tx, StatusChange{
+		SaleOrderId: id,
+		StatusId:    AcceptedStatus,
+		UserLoginId: &userLoginId,
+		ChangedAt:   time.Now(),
+	})
+	if err != nil {
+		return err
+	}
 
-	result, err := tx.ExecContext(ctx, query, id)
+	err = reservation.Hold(ctx, tx, id)
 	if err != nil {
 		return err
 	}
-	rowAffected, err := result.RowsAffected()
+
+	return tx.Commit()
+}
+
+func (repo *Repo) CancelSalesOrder(
+	ctx context.Context, id int64,
+	userLoginId uuid.UUID, reason string) error {
+
+	log.Println("CancelSalesOrder", id, reason)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	if rowAffected < 1 {
-		return cancelErr
+	defer tx.Rollback()
+
+	now := time.Now()
+	err = ChangeStatus(ctx, tx, StatusChange{
+		SaleOrderId: id,
+		StatusId:    CanceledStatus,
+		UserLoginId: &userLoginId,
+		Reason:      reason,
+		ChangedAt:   now,
+	})
+	if err != nil {
+		return err
 	}
 
-	type OrderItem struct {
-		ProductId   int64           `db:"product_id"`
-		WarehouseId uuid.UUID       `db:"original_warehouse_id"`
-		Quantity    decimal.Decimal `db:"quantity"`
+	err = reservation.Release(ctx, tx, id,
+		reservation.ReleasedStatus, now)
+	if err != nil {
+		return err
 	}
 
-	query = repo.db.Rebind(`
-        select pp.product_id, o.original_warehouse_id, oi.quantity
-        from sale_order_item oi
-        inner join product_price pp on pp.id = oi.product_price_id
-        inner join sale_order o on o.id = oi.sale_order_id
-        where sale_order_id = ?
-        `)
+	return tx.Commit()
+}
+
+// ExpireSalesOrder cancels the orders still created whose reservations
+// are past their expiry and gives the stock back, returning how many
+// there were.
+func (repo *Repo) ExpireSalesOrder(
+	ctx context.Context, now time.Time) (int, error) {
 
-	items := make([]OrderItem, 0)
-	err = tx.SelectContext(ctx, &items, query, id)
+	log.Println("ExpireSalesOrder", now)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	defer tx.Rollback()
 
-	updateAvailableQuery := `
-        update warehouse_product_statistics
-        set quantity_available = quantity_available + :quantity
-        where product_id = :product_id
-            and warehouse_id = :original_warehouse_id
-        `
-	for _, item := range items {
-		_, err = tx.NamedExecContext(ctx, updateAvailableQuery, item)
+	orderIds := make([]int64, 0)
+	query := repo.db.Rebind(`
+        select o.id from sale_order o
+        where o.sale_order_status_id = ?
+            and exists (
+                select 1 from stock_reservation r
+                where r.sale_order_id = o.id
+                    and r.reservation_status_id = ?
+                    and r.expires_at <= ?
+            )
+        order by o.id
+        for update skip locked
+        `)
+	err = tx.SelectContext(ctx, &orderIds, query,
+		CreatedStatus, reservation.ActiveStatus, now)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range orderIds {
+		err = ChangeStatus(ctx, tx, StatusChange{
+			SaleOrderId: id,
+			StatusId:    CanceledStatus,
+			Reason:      "reservation expired",
+			ChangedAt:   now,
+		})
 		if err != nil {
-			return err
+			return 0, err
+		}
+
+		err = reservation.Release(ctx, tx, id,
+			reservation.ExpiredStatus, now)
+		if err != nil {
+			return 0, err
 		}
 	}
 
-	return tx.Commit()
+	return len(orderIds), tx.Commit()
 }
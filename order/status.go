@@ -0,0 +1,122 @@
+package order
+
+import (
+	"baseweb/basic"
+	"baseweb/product"
+	"baseweb/reservation"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrStatusTransition = errors.New("sale order status transition not allowed")
+
+// statusTransitions lists the statuses an order may move to from each
+// status. Shipping moves to itself as the remaining items are exported,
+// completed and canceled orders go nowhere.
+var statusTransitions = map[int16][]int16{
+	CreatedStatus:  {AcceptedStatus, CanceledStatus},
+	AcceptedStatus: {ShippingStatus},
+	ShippingStatus: {ShippingStatus, CompletedStatus},
+}
+
+func checkStatus(statusId, nextStatusId int16) error {
+	for _, next := range statusTransitions[statusId] {
+		if next == nextStatusId {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %d to %d",
+		ErrStatusTransition, statusId, nextStatusId)
+}
+
+// StatusChange is a request to move an order to StatusId. UserLoginId
+// is nil when the system made the change.
+type StatusChange struct {
+	SaleOrderId int64
+	StatusId    int16
+	UserLoginId *uuid.UUID
+	Reason      string
+	ChangedAt   time.Time
+}
+
+// ChangeStatus locks the order, checks the transition is allowed and
+// records it in the status history. Moving to the status the order is
+// already in changes nothing and is not recorded.
+func ChangeStatus(ctx context.Context, tx *sqlx.Tx, c StatusChange) error {
+	var statusId int16
+	query := tx.Rebind(`
+        select sale_order_status_id from sale_order
+        where id = ?
+        for update
+        `)
+	err := tx.GetContext(ctx, &statusId, query, c.SaleOrderId)
+	if err != nil {
+		return err
+	}
+
+	err = checkStatus(statusId, c.StatusId)
+	if err != nil {
+		return err
+	}
+	if statusId == c.StatusId {
+		return nil
+	}
+
+	query = tx.Rebind(`
+        update sale_order
+        set sale_order_status_id = ?
+        where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, c.StatusId, c.SaleOrderId)
+	if err != nil {
+		return err
+	}
+
+	return recordStatus(ctx, tx, &statusId, c)
+}
+
+func recordStatus(
+	ctx context.Context, tx *sqlx.Tx,
+	fromStatusId *int16, c StatusChange) error {
+
+	var reason *string
+	if c.Reason != "" {
+		reason = &c.Reason
+	}
+
+	query := tx.Rebind(`
+        insert into sale_order_status_history(
+        sale_order_id, from_status_id, to_status_id,
+        changed_by_user_login_id, reason, changed_at)
+        values (?, ?, ?, ?, ?, ?)
+        `)
+	_, err := tx.ExecContext(ctx, query,
+		c.SaleOrderId, fromStatusId, c.StatusId,
+		c.UserLoginId, reason, c.ChangedAt)
+	return err
+}
+
+// conflictErrors are the errors that come from the state of the order
+// rather than from a failure.
+var conflictErrors = []error{
+	ErrStatusTransition,
+	ErrOrderNotEditable,
+	ErrStaleOrder,
+	ErrLastItem,
+	ErrProductNotActive,
+	product.ErrNoPrice,
+	reservation.ErrNotStocked,
+}
+
+// ReturnConflict answers an order that is not in a state to take the
+// request with 409 Conflict. Any other error is handed back to the
+// caller.
+func ReturnConflict(w http.ResponseWriter, err error) error {
+	return basic.ReturnConflict(w, err, conflictErrors...)
+}
@@ -1,7 +1,11 @@
 package order
 
 import (
+	"baseweb/basic"
+	"context"
+	"log"
 	"sort"
+	"time"
 
 	"github.com/lithammer/fuzzysearch/fuzzy"
 )
@@ -115,3 +119,16 @@ func FuzzySearchProductInfo(
 	}
 	return len(matches), result
 }
+
+// RunJob cancels the orders whose reservations expired every interval
+// until ctx is done.
+func RunJob(ctx context.Context, repo *Repo, interval time.Duration) {
+	basic.RunEvery(ctx, interval, func() {
+		count, err := repo.ExpireSalesOrder(ctx, time.Now())
+		if err != nil {
+			log.Println("[ERROR] reservation job", err)
+		} else if count > 0 {
+			log.Println("reservation job expired", count, "orders")
+		}
+	})
+}
@@ -1,7 +1,7 @@
 package main
 
 func ProductRoutes(root *Root) {
-	root.PostAuthorized(
+	root.PostIdempotent(
 		"/api/product/add-product",
 		"VIEW_EDIT_PRODUCT",
 		root.product.AddProductHandler)
@@ -31,8 +31,37 @@ func ProductRoutes(root *Root) {
 		"VIEW_EDIT_PRODUCT",
 		root.product.ViewProductPriceHandler)
 
-	root.PostAuthorized(
+	root.PostIdempotent(
 		"/api/product/add-product-price",
 		"VIEW_EDIT_PRODUCT",
 		root.product.AddProductPriceHandler)
+
+	root.PostIdempotent(
+		"/api/product/add-product-barcode",
+		"VIEW_EDIT_PRODUCT",
+		root.product.AddProductBarcodeHandler)
+
+	root.GetAuthorized(
+		"/api/product/view-product-barcode",
+		"VIEW_EDIT_PRODUCT",
+		root.product.ViewProductBarcodeHandler)
+
+	root.PostAuthorized(
+		"/api/product/delete-product-barcode",
+		"VIEW_EDIT_PRODUCT",
+		root.product.DeleteProductBarcodeHandler)
+
+	root.GetAuthenticated(
+		"/api/product/lookup-barcode",
+		root.product.LookupBarcodeHandler)
+
+	root.PostAuthorized(
+		"/api/product/change-product-status",
+		"VIEW_EDIT_PRODUCT",
+		root.product.ChangeProductStatusHandler)
+
+	root.PostAuthorized(
+		"/api/product/discontinue-product",
+		"VIEW_EDIT_PRODUCT",
+		root.product.DiscontinueProductHandler)
 }
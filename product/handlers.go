@@ -3,7 +3,9 @@ package product
 import (
 	"baseweb/basic"
 	"baseweb/security"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -31,6 +33,12 @@ func (root *Root) AddProductHandler(
 		return err
 	}
 	product.CreatedBy = userLogin.Id
+	if product.TaxCategoryId == 0 {
+		product.TaxCategoryId = 1
+	}
+	if product.StatusId != DraftStatus {
+		product.StatusId = ActiveStatus
+	}
 
 	err = root.repo.InsertProduct(ctx, product)
 	if err != nil {
@@ -79,9 +87,14 @@ func (root *Root) ViewProductHandler(
 	var count uint
 	var products []ClientProduct
 
+	statusId, err := strconv.Atoi(queries.Get("statusId"))
+	if err != nil {
+		statusId = 0
+	}
+
 	count, products, err = ViewProductFuzzy(ctx, root.repo,
 		uint(page), uint(pageSize),
-		sortedBy, sortOrder, search)
+		sortedBy, sortOrder, search, int16(statusId))
 
 	type Response struct {
 		ProductList  []ClientProduct `json:"productList"`
@@ -106,6 +119,9 @@ func (root *Root) UpdateProductHandler(
 	if err != nil {
 		return err
 	}
+	if product.TaxCategoryId == 0 {
+		product.TaxCategoryId = 1
+	}
 
 	err = root.repo.UpdateProduct(ctx, product)
 	if err != nil {
@@ -178,9 +194,14 @@ func (root *Root) ViewProductPricingHandler(
 
 	var count uint
 	var products []ClientProduct
+	statusId, err := strconv.Atoi(queries.Get("statusId"))
+	if err != nil {
+		statusId = 0
+	}
+
 	count, products, err = ViewProductFuzzy(ctx, root.repo,
 		uint(page), uint(pageSize),
-		sortedBy, sortOrder, search)
+		sortedBy, sortOrder, search, int16(statusId))
 
 	idList := make([]int64, len(products))
 	for i := 0; i < len(products); i++ {
@@ -291,3 +312,162 @@ func (root *Root) AddProductPriceHandler(
 
 	return json.NewEncoder(w).Encode(res)
 }
+
+var bodyError = errors.New("body constraints violation")
+
+func (root *Root) AddProductBarcodeHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+	type Request struct {
+		ProductId int64  `json:"productId"`
+		Code      string `json:"code"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	code, ok := NormalizeGtin(req.Code)
+	if !ok {
+		return bodyError
+	}
+
+	err = root.repo.InsertProductBarcode(ctx,
+		req.ProductId, code, userLogin.Id)
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) ViewProductBarcodeHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	queries := r.URL.Query()
+
+	productId, err := strconv.Atoi(queries.Get("id"))
+	if err != nil {
+		return err
+	}
+
+	barcodes, err := root.repo.SelectProductBarcode(ctx, int64(productId))
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		BarcodeList []ProductBarcode `json:"barcodeList"`
+	}
+
+	res := Response{
+		BarcodeList: barcodes,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) DeleteProductBarcodeHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Code string `json:"code"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	code, ok := NormalizeGtin(req.Code)
+	if !ok {
+		return bodyError
+	}
+
+	err = root.repo.DeleteProductBarcode(ctx, code)
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) LookupBarcodeHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	code, ok := NormalizeGtin(r.URL.Query().Get("code"))
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil
+	}
+
+	product, err := root.repo.GetProductByBarcode(ctx, code)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(product)
+}
+
+func (root *Root) ChangeProductStatusHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Id       int64 `json:"id"`
+		StatusId int16 `json:"statusId"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.ChangeProductStatus(ctx, req.Id, req.StatusId, time.Now())
+	if err != nil {
+		return basic.ReturnConflict(w, err, ErrProductStatus, ErrNoPrice)
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) DiscontinueProductHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Id int64 `json:"id"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.ChangeProductStatus(ctx,
+		req.Id, DiscontinuedStatus, time.Now())
+	if err != nil {
+		return basic.ReturnConflict(w, err, ErrProductStatus)
+	}
+
+	return basic.ReturnOk(w)
+}
@@ -8,28 +8,39 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+const (
+	DraftStatus        int16 = 1
+	ActiveStatus       int16 = 2
+	DiscontinuedStatus int16 = 3
+	ObsoleteStatus     int16 = 4
+)
+
 type Product struct {
-	Id          int64               `json:"id" db:"id"`
-	Name        string              `json:"name" db:"name"`
-	CreatedBy   uuid.UUID           `json:"createdBy" db:"created_by_user_login_id"`
-	Description string              `json:"description" db:"description"`
-	Weight      decimal.NullDecimal `json:"weight" db:"weight"`
-	WeightUomId string              `json:"weightUomId" db:"weight_uom_id"`
-	UnitUomId   string              `json:"unitUomId" db:"unit_uom_id"`
-	CreatedAt   time.Time           `json:"createdAt" db:"created_at"`
-	UpdatedAt   time.Time           `json:"updatedAt" db:"updated_at"`
+	Id            int64               `json:"id" db:"id"`
+	Name          string              `json:"name" db:"name"`
+	CreatedBy     uuid.UUID           `json:"createdBy" db:"created_by_user_login_id"`
+	Description   string              `json:"description" db:"description"`
+	Weight        decimal.NullDecimal `json:"weight" db:"weight"`
+	WeightUomId   string              `json:"weightUomId" db:"weight_uom_id"`
+	UnitUomId     string              `json:"unitUomId" db:"unit_uom_id"`
+	TaxCategoryId int16               `json:"taxCategoryId" db:"tax_category_id"`
+	StatusId      int16               `json:"statusId" db:"product_status_id"`
+	CreatedAt     time.Time           `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time           `json:"updatedAt" db:"updated_at"`
 }
 
 type ClientProduct struct {
-	Id          int64               `json:"id" db:"id"`
-	Name        string              `json:"name" db:"name"`
-	CreatedBy   string              `json:"createdBy" db:"created_by"`
-	Description string              `json:"description" db:"description"`
-	Weight      decimal.NullDecimal `json:"weight" db:"weight"`
-	WeightUomId string              `json:"weightUomId" db:"weight_uom_id"`
-	UnitUomId   string              `json:"unitUomId" db:"unit_uom_id"`
-	CreatedAt   time.Time           `json:"createdAt" db:"created_at"`
-	UpdatedAt   time.Time           `json:"updatedAt" db:"updated_at"`
+	Id            int64               `json:"id" db:"id"`
+	Name          string              `json:"name" db:"name"`
+	CreatedBy     string              `json:"createdBy" db:"created_by"`
+	Description   string              `json:"description" db:"description"`
+	Weight        decimal.NullDecimal `json:"weight" db:"weight"`
+	WeightUomId   string              `json:"weightUomId" db:"weight_uom_id"`
+	UnitUomId     string              `json:"unitUomId" db:"unit_uom_id"`
+	TaxCategoryId int16               `json:"taxCategoryId" db:"tax_category_id"`
+	StatusId      int16               `json:"statusId" db:"product_status_id"`
+	CreatedAt     time.Time           `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time           `json:"updatedAt" db:"updated_at"`
 }
 
 type ProductPrice struct {
@@ -51,3 +62,10 @@ type InsertionPrice struct {
 	CreatedBy     uuid.UUID       `json:"createdBy" db:"created_by_user_login_id"`
 	EffectiveFrom time.Time       `json:"effectiveFrom" db:"effective_from"`
 }
+
+type ProductBarcode struct {
+	Code      string    `json:"code" db:"code"`
+	ProductId int64     `json:"productId" db:"product_id"`
+	CreatedBy string    `json:"createdBy" db:"created_by"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
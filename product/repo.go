@@ -2,10 +2,13 @@ package product
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -28,7 +31,8 @@ func InitRepo(db *sqlx.DB) *Repo {
 
 	query = `select p.id, p.name, 
         p.weight, p.weight_uom_id, p.unit_uom_id,
-        p.description, p.created_at, p.updated_at,
+        p.description, p.tax_category_id, p.product_status_id,
+        p.created_at, p.updated_at,
         u.username as created_by
         from product p
         inner join user_login u
@@ -42,7 +46,8 @@ func InitRepo(db *sqlx.DB) *Repo {
 
 	query = `select p.id, p.name, 
         p.weight, p.weight_uom_id, p.unit_uom_id,
-        p.description, p.created_at, p.updated_at,
+        p.description, p.tax_category_id, p.product_status_id,
+        p.created_at, p.updated_at,
         u.username as created_by
         from product p
         inner join user_login u
@@ -54,7 +59,8 @@ func InitRepo(db *sqlx.DB) *Repo {
 
 	query = `select p.id, p.name, 
         p.weight, p.weight_uom_id, p.unit_uom_id,
-        p.description, p.created_at, p.updated_at,
+        p.description, p.tax_category_id, p.product_status_id,
+        p.created_at, p.updated_at,
         u.username as created_by
         from product p
         inner join user_login u
@@ -107,10 +113,12 @@ func (repo *Repo) InsertProduct(
 	query := `insert into product(
         name, created_by_user_login_id,
         description, weight,
-        weight_uom_id, unit_uom_id)
+        weight_uom_id, unit_uom_id, tax_category_id,
+        product_status_id)
         values(:name, :created_by_user_login_id,
         :description, :weight,
-        :weight_uom_id, :unit_uom_id)`
+        :weight_uom_id, :unit_uom_id, :tax_category_id,
+        :product_status_id)`
 
 	_, err := repo.db.NamedExecContext(ctx, query, product)
 	return err
@@ -118,53 +126,81 @@ func (repo *Repo) InsertProduct(
 
 func (repo *Repo) ViewProduct(
 	ctx context.Context, page,
-	pageSize uint, sortedBy, sortOrder string) (uint, []ClientProduct, error) {
+	pageSize uint, sortedBy, sortOrder string,
+	statusId int16) (uint, []ClientProduct, error) {
 
-	log.Println("ViewProduct", page, pageSize, sortedBy, sortOrder)
+	log.Println("ViewProduct", page, pageSize, sortedBy, sortOrder, statusId)
 
 	var count uint
 	result := make([]ClientProduct, 0)
 
-	err := repo.productCount.GetContext(ctx, &count)
-	if err != nil {
-		return count, result, err
-	}
+	if statusId == 0 {
+		err := repo.productCount.GetContext(ctx, &count)
+		if err != nil {
+			return count, result, err
+		}
 
-	if sortedBy == "created_at" && sortOrder == "desc" {
-		err = repo.viewProduct.SelectContext(ctx, &result, pageSize, page*pageSize)
-		return count, result, err
+		if sortedBy == "created_at" && sortOrder == "desc" {
+			err = repo.viewProduct.SelectContext(ctx, &result, pageSize, page*pageSize)
+			return count, result, err
+		}
 	} else {
-		query := fmt.Sprintf(`select p.id, p.name, 
-            p.weight, p.weight_uom_id, p.unit_uom_id,
-            p.description, p.created_at, p.updated_at,
-            u.username as created_by
-            from product p
-            inner join user_login u
-                on u.id = p.created_by_user_login_id
-            order by p.%s %s
-            limit ? offset ?`, sortedBy, sortOrder)
-		log.Println("[SQL]", query)
-
-		err = repo.db.SelectContext(ctx, &result,
-			repo.db.Rebind(query), pageSize, page*pageSize)
-		return count, result, err
+		query := repo.db.Rebind(
+			`select count(*) from product where product_status_id = ?`)
+		err := repo.db.GetContext(ctx, &count, query, statusId)
+		if err != nil {
+			return count, result, err
+		}
 	}
+
+	query := fmt.Sprintf(`select p.id, p.name, 
+        p.weight, p.weight_uom_id, p.unit_uom_id,
+        p.description, p.tax_category_id, p.product_status_id,
+        p.created_at, p.updated_at,
+        u.username as created_by
+        from product p
+        inner join user_login u
+            on u.id = p.created_by_user_login_id
+        where ? = 0 or p.product_status_id = ?
+        order by p.%s %s
+        limit ? offset ?`, sortedBy, sortOrder)
+	log.Println("[SQL]", query)
+
+	err := repo.db.SelectContext(ctx, &result, repo.db.Rebind(query),
+		statusId, statusId, pageSize, page*pageSize)
+	return count, result, err
 }
 
 func (repo *Repo) SelectProduct(
-	ctx context.Context) (uint, []ClientProduct, error) {
+	ctx context.Context, statusId int16) (uint, []ClientProduct, error) {
 
-	log.Println("SelectProduct")
+	log.Println("SelectProduct", statusId)
 
 	var count uint
 	result := make([]ClientProduct, 0)
 
-	err := repo.productCount.GetContext(ctx, &count)
-	if err != nil {
+	if statusId == 0 {
+		err := repo.productCount.GetContext(ctx, &count)
+		if err != nil {
+			return count, result, err
+		}
+
+		err = repo.selectProduct.SelectContext(ctx, &result)
 		return count, result, err
 	}
 
-	err = repo.selectProduct.SelectContext(ctx, &result)
+	query := `select p.id, p.name, 
+        p.weight, p.weight_uom_id, p.unit_uom_id,
+        p.description, p.tax_category_id, p.product_status_id,
+        p.created_at, p.updated_at,
+        u.username as created_by
+        from product p
+        inner join user_login u
+            on u.id = p.created_by_user_login_id
+        where p.product_status_id = ?`
+	err := repo.db.SelectContext(ctx, &result,
+		repo.db.Rebind(query), statusId)
+	count = uint(len(result))
 	return count, result, err
 }
 
@@ -178,7 +214,8 @@ func (repo *Repo) UpdateProduct(
 	query := `update product set name = :name,
         description = :description, weight = :weight, 
         weight_uom_id = :weight_uom_id,
-        unit_uom_id = :unit_uom_id where id = :id`
+        unit_uom_id = :unit_uom_id,
+        tax_category_id = :tax_category_id where id = :id`
 	_, err := repo.db.NamedExecContext(ctx, query, product)
 	return err
 }
@@ -310,3 +347,176 @@ func (repo *Repo) InsertProductPrice(
 
 	return tx.Commit()
 }
+
+func (repo *Repo) InsertProductBarcode(
+	ctx context.Context, productId int64,
+	code string, userLoginId uuid.UUID) error {
+
+	log.Println("InsertProductBarcode", productId, code)
+
+	query := `insert into product_barcode(
+        code, product_id, created_by_user_login_id)
+        values (?, ?, ?)`
+	query = repo.db.Rebind(query)
+	_, err := repo.db.ExecContext(ctx, query, code, productId, userLoginId)
+	return err
+}
+
+func (repo *Repo) SelectProductBarcode(
+	ctx context.Context, productId int64) ([]ProductBarcode, error) {
+
+	log.Println("SelectProductBarcode", productId)
+
+	result := make([]ProductBarcode, 0)
+
+	query := `select b.code, b.product_id,
+        u.username as created_by, b.created_at
+        from product_barcode b
+        inner join user_login u
+            on u.id = b.created_by_user_login_id
+        where b.product_id = ?
+        order by b.created_at`
+	query = repo.db.Rebind(query)
+	err := repo.db.SelectContext(ctx, &result, query, productId)
+	return result, err
+}
+
+func (repo *Repo) DeleteProductBarcode(
+	ctx context.Context, code string) error {
+
+	log.Println("DeleteProductBarcode", code)
+
+	query := repo.db.Rebind(`delete from product_barcode where code = ?`)
+	_, err := repo.db.ExecContext(ctx, query, code)
+	return err
+}
+
+func (repo *Repo) GetProductByBarcode(
+	ctx context.Context, code string) (ClientProduct, error) {
+
+	log.Println("GetProductByBarcode", code)
+
+	product := ClientProduct{}
+
+	query := `select p.id, p.name,
+        p.weight, p.weight_uom_id, p.unit_uom_id,
+        p.description, p.tax_category_id, p.product_status_id,
+        p.created_at, p.updated_at,
+        u.username as created_by
+        from product p
+        inner join product_barcode b on b.product_id = p.id
+        inner join user_login u
+            on u.id = p.created_by_user_login_id
+        where b.code = ?`
+	query = repo.db.Rebind(query)
+	err := repo.db.GetContext(ctx, &product, query, code)
+	return product, err
+}
+
+var ErrBarcode = errors.New("invalid or unknown barcode")
+
+func ResolveBarcode(
+	ctx context.Context, tx *sqlx.Tx, code string) (int64, error) {
+
+	log.Println("ResolveBarcode", code)
+
+	var productId int64
+
+	code, ok := NormalizeGtin(code)
+	if !ok {
+		return productId, ErrBarcode
+	}
+
+	query := tx.Rebind(
+		`select product_id from product_barcode where code = ?`)
+	err := tx.GetContext(ctx, &productId, query, code)
+	if err == sql.ErrNoRows {
+		return productId, ErrBarcode
+	}
+	return productId, err
+}
+
+var productStatusTransitions = map[int16][]int16{
+	DraftStatus:        {ActiveStatus, ObsoleteStatus},
+	ActiveStatus:       {DiscontinuedStatus},
+	DiscontinuedStatus: {ActiveStatus, ObsoleteStatus},
+}
+
+var ErrProductStatus = errors.New("product status transition not allowed")
+
+var ErrNoPrice = errors.New("product has no current price")
+
+func (repo *Repo) ChangeProductStatus(
+	ctx context.Context, id int64,
+	statusId int16, now time.Time) error {
+
+	log.Println("ChangeProductStatus", id, statusId)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current int16
+	query := repo.db.Rebind(`
+        select product_status_id from product
+        where id = ? for update
+        `)
+	err = tx.GetContext(ctx, &current, query, id)
+	if err != nil {
+		return err
+	}
+
+	allowed := false
+	for _, next := range productStatusTransitions[current] {
+		if next == statusId {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return ErrProductStatus
+	}
+
+	// leaving Discontinued expired every price, an active product
+	// without one could not be ordered
+	if statusId == ActiveStatus {
+		var priceCount int
+		query = repo.db.Rebind(`
+            select count(*) from product_price
+            where product_id = ? and effective_from <= ?
+                and (expired_at is null or ? < expired_at)
+            `)
+		err = tx.GetContext(ctx, &priceCount, query, id, now, now)
+		if err != nil {
+			return err
+		}
+		if priceCount == 0 {
+			return ErrNoPrice
+		}
+	}
+
+	query = repo.db.Rebind(`
+        update product set product_status_id = ? where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, statusId, id)
+	if err != nil {
+		return err
+	}
+
+	if statusId == DiscontinuedStatus || statusId == ObsoleteStatus {
+		query = repo.db.Rebind(`
+            update product_price
+            set expired_at = ?
+            where product_id = ?
+                and (expired_at is null or ? < expired_at)
+            `)
+		_, err = tx.ExecContext(ctx, query, now, id, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
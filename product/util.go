@@ -3,6 +3,7 @@ package product
 import (
 	"context"
 	"sort"
+	"strings"
 
 	"github.com/lithammer/fuzzysearch/fuzzy"
 )
@@ -65,13 +66,13 @@ func ViewProductFuzzy(
 	ctx context.Context, repo *Repo,
 	page, pageSize uint,
 	sortedBy, sortOrder string,
-	search string) (uint, []ClientProduct, error) {
+	search string, statusId int16) (uint, []ClientProduct, error) {
 
 	if search == "" {
 		return repo.ViewProduct(
-			ctx, uint(page), uint(pageSize), sortedBy, sortOrder)
+			ctx, uint(page), uint(pageSize), sortedBy, sortOrder, statusId)
 	} else {
-		count, products, err := repo.SelectProduct(ctx)
+		count, products, err := repo.SelectProduct(ctx, statusId)
 		if err != nil {
 			return count, products, err
 		}
@@ -80,3 +81,44 @@ func ViewProductFuzzy(
 		return count, products, err
 	}
 }
+
+// NormalizeGtin validates a GTIN-8, GTIN-12 (UPC-A), GTIN-13 (EAN) or
+// GTIN-14 code and returns it left padded with zeros to 14 digits, so
+// the same item scanned as UPC-A or EAN-13 resolves to one code.
+func NormalizeGtin(code string) (string, bool) {
+	code = strings.TrimSpace(code)
+
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return "", false
+	}
+
+	sum := 0
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		if c < '0' || c > '9' {
+			return "", false
+		}
+		digit := int(c - '0')
+
+		// weights alternate 3, 1, 3, ... starting from the digit just
+		// left of the check digit
+		pos := len(code) - 1 - i
+		if pos == 0 {
+			continue
+		}
+		if pos%2 == 1 {
+			sum += 3 * digit
+		} else {
+			sum += digit
+		}
+	}
+
+	check := (10 - sum%10) % 10
+	if int(code[len(code)-1]-'0') != check {
+		return "", false
+	}
+
+	return strings.Repeat("0", 14-len(code)) + code, true
+}
@@ -0,0 +1,18 @@
+package main
+
+func PromotionRoutes(root *Root) {
+	root.PostIdempotent(
+		"/api/promotion/add-promotion",
+		"VIEW_EDIT_ORDER",
+		root.promotion.AddPromotionHandler)
+
+	root.GetAuthorized(
+		"/api/promotion/view-promotion",
+		"VIEW_EDIT_ORDER",
+		root.promotion.ViewPromotionHandler)
+
+	root.PostAuthorized(
+		"/api/promotion/expire-promotion",
+		"VIEW_EDIT_ORDER",
+		root.promotion.ExpirePromotionHandler)
+}
@@ -0,0 +1,173 @@
+package promotion
+
+import (
+	"baseweb/basic"
+	"baseweb/security"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type Root struct {
+	repo *Repo
+}
+
+func InitRoot(repo *Repo) *Root {
+	return &Root{
+		repo: repo,
+	}
+}
+
+var bodyError = errors.New("body constraints violation")
+
+func validPromotion(p Promotion) bool {
+	if p.Name == "" {
+		return false
+	}
+	if p.Percentage.IsNegative() || p.Amount.IsNegative() ||
+		p.MinOrderAmount.IsNegative() {
+		return false
+	}
+	if p.Percentage.GreaterThan(hundred) {
+		return false
+	}
+
+	switch p.TypeId {
+	case PercentageType, OrderPercentageType:
+		if !p.Percentage.IsPositive() {
+			return false
+		}
+	case FixedAmountType, OrderFixedAmountType:
+		if !p.Amount.IsPositive() {
+			return false
+		}
+	case BuyXGetYType:
+		if p.ProductId == nil || !p.BuyQuantity.IsPositive() ||
+			!p.GetQuantity.IsPositive() {
+			return false
+		}
+	default:
+		return false
+	}
+
+	if isOrderLevel(p) || p.TypeId == FixedAmountType {
+		if !p.CurrencyUomId.Valid {
+			return false
+		}
+	}
+
+	if p.ExpiredAt.Valid && !p.EffectiveFrom.Before(p.ExpiredAt.Time) {
+		return false
+	}
+
+	return true
+}
+
+func (root *Root) AddPromotionHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+	promotion := Promotion{}
+	err := json.NewDecoder(r.Body).Decode(&promotion)
+	if err != nil {
+		return err
+	}
+	promotion.CreatedBy = userLogin.Id
+	if promotion.EffectiveFrom.IsZero() {
+		promotion.EffectiveFrom = time.Now()
+	}
+
+	if !validPromotion(promotion) {
+		return bodyError
+	}
+
+	err = root.repo.InsertPromotion(ctx, promotion)
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) ViewPromotionHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	sortedBy := "created_at"
+	sortedByQuery := query.Get("sortedBy")
+	if sortedByQuery == "updatedAt" {
+		sortedBy = "updated_at"
+	} else if sortedByQuery == "effectiveFrom" {
+		sortedBy = "effective_from"
+	} else if sortedByQuery == "name" {
+		sortedBy = "name"
+	}
+
+	sortOrder := "desc"
+	sortOrderQuery := query.Get("sortOrder")
+	if sortOrderQuery == "asc" {
+		sortOrder = "asc"
+	}
+
+	count, promotions, err := root.repo.ViewPromotion(ctx,
+		page, pageSize, sortedBy, sortOrder)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		PromotionCount int               `json:"promotionCount"`
+		PromotionList  []ClientPromotion `json:"promotionList"`
+	}
+
+	res := Response{
+		PromotionCount: count,
+		PromotionList:  promotions,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ExpirePromotionHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Id int `json:"id"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.ExpirePromotion(ctx, req.Id, time.Now())
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
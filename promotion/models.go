@@ -0,0 +1,77 @@
+package promotion
+
+import (
+	"baseweb/basic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	PercentageType       int16 = 1
+	FixedAmountType      int16 = 2
+	BuyXGetYType         int16 = 3
+	OrderPercentageType  int16 = 4
+	OrderFixedAmountType int16 = 5
+)
+
+type Promotion struct {
+	Id              int              `json:"id" db:"id"`
+	Name            string           `json:"name" db:"name"`
+	TypeId          int16            `json:"promotionTypeId" db:"promotion_type_id"`
+	ProductId       *int64           `json:"productId" db:"product_id"`
+	CustomerId      *uuid.UUID       `json:"customerId" db:"customer_id"`
+	CustomerStoreId *uuid.UUID       `json:"customerStoreId" db:"customer_store_id"`
+	Percentage      decimal.Decimal  `json:"percentage" db:"percentage"`
+	Amount          decimal.Decimal  `json:"amount" db:"amount"`
+	CurrencyUomId   basic.NullString `json:"currencyUomId" db:"currency_uom_id"`
+	BuyQuantity     decimal.Decimal  `json:"buyQuantity" db:"buy_quantity"`
+	GetQuantity     decimal.Decimal  `json:"getQuantity" db:"get_quantity"`
+	MinOrderAmount  decimal.Decimal  `json:"minOrderAmount" db:"min_order_amount"`
+	CreatedBy       uuid.UUID        `json:"createdBy" db:"created_by_user_login_id"`
+	EffectiveFrom   time.Time        `json:"effectiveFrom" db:"effective_from"`
+	ExpiredAt       basic.NullTime   `json:"expiredAt" db:"expired_at"`
+}
+
+type ClientPromotion struct {
+	Id              int              `json:"id" db:"id"`
+	Name            string           `json:"name" db:"name"`
+	TypeId          int16            `json:"promotionTypeId" db:"promotion_type_id"`
+	ProductId       *int64           `json:"productId" db:"product_id"`
+	ProductName     basic.NullString `json:"productName" db:"product_name"`
+	CustomerId      *uuid.UUID       `json:"customerId" db:"customer_id"`
+	CustomerName    basic.NullString `json:"customerName" db:"customer_name"`
+	CustomerStoreId *uuid.UUID       `json:"customerStoreId" db:"customer_store_id"`
+	Percentage      decimal.Decimal  `json:"percentage" db:"percentage"`
+	Amount          decimal.Decimal  `json:"amount" db:"amount"`
+	CurrencyUomId   basic.NullString `json:"currencyUomId" db:"currency_uom_id"`
+	BuyQuantity     decimal.Decimal  `json:"buyQuantity" db:"buy_quantity"`
+	GetQuantity     decimal.Decimal  `json:"getQuantity" db:"get_quantity"`
+	MinOrderAmount  decimal.Decimal  `json:"minOrderAmount" db:"min_order_amount"`
+	CreatedBy       string           `json:"createdBy" db:"created_by"`
+	EffectiveFrom   time.Time        `json:"effectiveFrom" db:"effective_from"`
+	ExpiredAt       basic.NullTime   `json:"expiredAt" db:"expired_at"`
+	CreatedAt       time.Time        `json:"createdAt" db:"created_at"`
+	UpdatedAt       time.Time        `json:"updatedAt" db:"updated_at"`
+}
+
+type Line struct {
+	Seq           int
+	ProductId     int64
+	Price         decimal.Decimal
+	CurrencyUomId string
+	Quantity      decimal.Decimal
+}
+
+type Applied struct {
+	PromotionId    int
+	Seq            *int
+	DiscountAmount decimal.Decimal
+}
+
+type Result struct {
+	LineDiscounts map[int]decimal.Decimal
+	OrderDiscount decimal.Decimal
+	Applied       []Applied
+}
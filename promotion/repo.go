@@ -0,0 +1,138 @@
+package promotion
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func InitRepo(db *sqlx.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+func (repo *Repo) InsertPromotion(
+	ctx context.Context, promotion Promotion) error {
+
+	log.Println("InsertPromotion", promotion.Name, promotion.TypeId,
+		promotion.ProductId, promotion.CustomerId,
+		promotion.CustomerStoreId, promotion.EffectiveFrom)
+
+	query := `insert into promotion(
+        name, promotion_type_id,
+        product_id, customer_id, customer_store_id,
+        percentage, amount, currency_uom_id,
+        buy_quantity, get_quantity, min_order_amount,
+        created_by_user_login_id,
+        effective_from, expired_at)
+        values (:name, :promotion_type_id,
+        :product_id, :customer_id, :customer_store_id,
+        :percentage, :amount, :currency_uom_id,
+        :buy_quantity, :get_quantity, :min_order_amount,
+        :created_by_user_login_id,
+        :effective_from, :expired_at)`
+
+	_, err := repo.db.NamedExecContext(ctx, query, promotion)
+	return err
+}
+
+func (repo *Repo) ViewPromotion(
+	ctx context.Context,
+	page, pageSize int,
+	sortedBy, sortOrder string) (int, []ClientPromotion, error) {
+
+	log.Println("ViewPromotion", page, pageSize, sortedBy, sortOrder)
+
+	var count int
+	result := make([]ClientPromotion, 0)
+
+	query := `select count(*) from promotion`
+	err := repo.db.GetContext(ctx, &count, query)
+	if err != nil {
+		return count, result, err
+	}
+
+	query = `select pr.id, pr.name, pr.promotion_type_id,
+        pr.product_id, p.name as product_name,
+        pr.customer_id, c.name as customer_name,
+        pr.customer_store_id,
+        pr.percentage, pr.amount, pr.currency_uom_id,
+        pr.buy_quantity, pr.get_quantity, pr.min_order_amount,
+        u.username as created_by,
+        pr.effective_from, pr.expired_at,
+        pr.created_at, pr.updated_at
+        from promotion pr
+            inner join user_login u on u.id = pr.created_by_user_login_id
+            left join product p on p.id = pr.product_id
+            left join customer c on c.id = pr.customer_id
+        order by pr.%s %s
+        offset ? limit ?`
+	query = fmt.Sprintf(query, sortedBy, sortOrder)
+	query = repo.db.Rebind(query)
+	err = repo.db.SelectContext(ctx, &result, query,
+		page*pageSize, pageSize)
+
+	return count, result, err
+}
+
+func (repo *Repo) ExpirePromotion(
+	ctx context.Context, id int, now time.Time) error {
+
+	log.Println("ExpirePromotion", id)
+
+	query := repo.db.Rebind(`
+        update promotion set expired_at = ?
+        where id = ? and (expired_at is null or ? < expired_at)
+        `)
+	res, err := repo.db.ExecContext(ctx, query, now, id, now)
+	if err != nil {
+		return err
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func SelectActivePromotion(
+	ctx context.Context, tx *sqlx.Tx,
+	customerId uuid.UUID, customerStoreId *uuid.UUID,
+	now time.Time) ([]Promotion, error) {
+
+	log.Println("SelectActivePromotion", customerId, customerStoreId)
+
+	result := make([]Promotion, 0)
+
+	query := tx.Rebind(`
+        select id, name, promotion_type_id,
+        product_id, customer_id, customer_store_id,
+        percentage, amount, currency_uom_id,
+        buy_quantity, get_quantity, min_order_amount,
+        created_by_user_login_id,
+        effective_from, expired_at
+        from promotion
+        where effective_from <= ?
+            and (expired_at is null or ? < expired_at)
+            and (customer_id is null or customer_id = ?)
+            and (customer_store_id is null or customer_store_id = ?)
+        `)
+	err := tx.SelectContext(ctx, &result, query,
+		now, now, customerId, customerStoreId)
+
+	return result, err
+}
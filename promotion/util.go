@@ -0,0 +1,139 @@
+package promotion
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+func isOrderLevel(p Promotion) bool {
+	return p.TypeId == OrderPercentageType || p.TypeId == OrderFixedAmountType
+}
+
+func lineDiscount(p Promotion, line Line) decimal.Decimal {
+	if p.ProductId != nil && *p.ProductId != line.ProductId {
+		return decimal.Zero
+	}
+	if p.CurrencyUomId.Valid && p.CurrencyUomId.String != line.CurrencyUomId {
+		return decimal.Zero
+	}
+
+	total := line.Price.Mul(line.Quantity)
+
+	switch p.TypeId {
+	case PercentageType:
+		return total.Mul(p.Percentage).Div(hundred)
+
+	case FixedAmountType:
+		if !p.CurrencyUomId.Valid {
+			return decimal.Zero
+		}
+		discount := p.Amount.Mul(line.Quantity)
+		if discount.GreaterThan(total) {
+			discount = total
+		}
+		return discount
+
+	case BuyXGetYType:
+		// the free units are taken out of the ordered quantity:
+		// buy 10 get 1 means every 11 units ordered, 1 is free
+		if p.ProductId == nil || !p.BuyQuantity.IsPositive() ||
+			!p.GetQuantity.IsPositive() {
+			return decimal.Zero
+		}
+		group := p.BuyQuantity.Add(p.GetQuantity)
+		free := line.Quantity.Div(group).Floor().Mul(p.GetQuantity)
+		return free.Mul(line.Price)
+	}
+
+	return decimal.Zero
+}
+
+func orderDiscount(p Promotion, subtotals map[string]decimal.Decimal) decimal.Decimal {
+	if !p.CurrencyUomId.Valid {
+		return decimal.Zero
+	}
+
+	subtotal, ok := subtotals[p.CurrencyUomId.String]
+	if !ok || subtotal.LessThan(p.MinOrderAmount) {
+		return decimal.Zero
+	}
+
+	switch p.TypeId {
+	case OrderPercentageType:
+		return subtotal.Mul(p.Percentage).Div(hundred)
+
+	case OrderFixedAmountType:
+		if p.Amount.GreaterThan(subtotal) {
+			return subtotal
+		}
+		return p.Amount
+	}
+
+	return decimal.Zero
+}
+
+// Evaluate picks, for every line, the line promotion giving the biggest
+// discount, then the best order promotion on the discounted subtotal.
+// Promotions never stack on the same line.
+func Evaluate(promotions []Promotion, lines []Line) Result {
+	result := Result{
+		LineDiscounts: make(map[int]decimal.Decimal),
+		OrderDiscount: decimal.Zero,
+		Applied:       make([]Applied, 0),
+	}
+
+	subtotals := make(map[string]decimal.Decimal)
+
+	for _, line := range lines {
+		best := decimal.Zero
+		bestId := 0
+		for _, p := range promotions {
+			if isOrderLevel(p) {
+				continue
+			}
+			discount := lineDiscount(p, line).Round(2)
+			if discount.GreaterThan(best) {
+				best = discount
+				bestId = p.Id
+			}
+		}
+
+		result.LineDiscounts[line.Seq] = best
+		if bestId != 0 {
+			seq := line.Seq
+			result.Applied = append(result.Applied, Applied{
+				PromotionId:    bestId,
+				Seq:            &seq,
+				DiscountAmount: best,
+			})
+		}
+
+		net := line.Price.Mul(line.Quantity).Sub(best)
+		subtotals[line.CurrencyUomId] =
+			subtotals[line.CurrencyUomId].Add(net)
+	}
+
+	best := decimal.Zero
+	bestId := 0
+	for _, p := range promotions {
+		if !isOrderLevel(p) {
+			continue
+		}
+		discount := orderDiscount(p, subtotals).Round(2)
+		if discount.GreaterThan(best) {
+			best = discount
+			bestId = p.Id
+		}
+	}
+
+	result.OrderDiscount = best
+	if bestId != 0 {
+		result.Applied = append(result.Applied, Applied{
+			PromotionId:    bestId,
+			DiscountAmount: best,
+		})
+	}
+
+	return result
+}
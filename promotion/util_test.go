@@ -0,0 +1,153 @@
+package promotion
+
+import (
+	"baseweb/basic"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func dec(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
+
+func currency(value string) basic.NullString {
+	c := basic.NullString{}
+	c.Valid = true
+	c.String = value
+	return c
+}
+
+func TestEvaluate(t *testing.T) {
+	product1 := int64(1)
+	product2 := int64(2)
+
+	lines := []Line{
+		{Seq: 1, ProductId: 1, Price: dec("100"), CurrencyUomId: "VND", Quantity: dec("22")},
+		{Seq: 2, ProductId: 2, Price: dec("33.33"), CurrencyUomId: "VND", Quantity: dec("3")},
+	}
+
+	tests := []struct {
+		name          string
+		promotions    []Promotion
+		lineDiscounts map[int]string
+		orderDiscount string
+		applied       []int
+	}{
+		{
+			name:          "no promotions",
+			lineDiscounts: map[int]string{1: "0", 2: "0"},
+			orderDiscount: "0",
+		},
+		{
+			name: "percentage on one product",
+			promotions: []Promotion{
+				{Id: 1, TypeId: PercentageType, ProductId: &product2, Percentage: dec("10")},
+			},
+			lineDiscounts: map[int]string{1: "0", 2: "10"},
+			orderDiscount: "0",
+			applied:       []int{1},
+		},
+		{
+			name: "fixed amount needs a currency and is capped",
+			promotions: []Promotion{
+				{Id: 1, TypeId: FixedAmountType, Amount: dec("5")},
+				{Id: 2, TypeId: FixedAmountType, ProductId: &product2,
+					Amount: dec("50"), CurrencyUomId: currency("VND")},
+			},
+			lineDiscounts: map[int]string{1: "0", 2: "99.99"},
+			orderDiscount: "0",
+			applied:       []int{2},
+		},
+		{
+			name: "buy x get y gives whole groups only",
+			promotions: []Promotion{
+				{Id: 1, TypeId: BuyXGetYType, ProductId: &product1,
+					BuyQuantity: dec("10"), GetQuantity: dec("1")},
+			},
+			lineDiscounts: map[int]string{1: "200", 2: "0"},
+			orderDiscount: "0",
+			applied:       []int{1},
+		},
+		{
+			name: "best line promotion wins without stacking",
+			promotions: []Promotion{
+				{Id: 1, TypeId: PercentageType, Percentage: dec("5")},
+				{Id: 2, TypeId: BuyXGetYType, ProductId: &product1,
+					BuyQuantity: dec("10"), GetQuantity: dec("1")},
+			},
+			lineDiscounts: map[int]string{1: "200", 2: "5"},
+			orderDiscount: "0",
+			applied:       []int{2, 1},
+		},
+		{
+			name: "order percentage on the discounted subtotal",
+			promotions: []Promotion{
+				{Id: 1, TypeId: PercentageType, ProductId: &product1, Percentage: dec("50")},
+				{Id: 2, TypeId: OrderPercentageType, Percentage: dec("10"),
+					CurrencyUomId: currency("VND"), MinOrderAmount: dec("1000")},
+			},
+			lineDiscounts: map[int]string{1: "1100", 2: "0"},
+			orderDiscount: "120",
+			applied:       []int{1, 2},
+		},
+		{
+			name: "order promotion below the minimum amount",
+			promotions: []Promotion{
+				{Id: 1, TypeId: OrderFixedAmountType, Amount: dec("100"),
+					CurrencyUomId: currency("VND"), MinOrderAmount: dec("5000")},
+			},
+			lineDiscounts: map[int]string{1: "0", 2: "0"},
+			orderDiscount: "0",
+		},
+		{
+			name: "order promotion in another currency",
+			promotions: []Promotion{
+				{Id: 1, TypeId: OrderFixedAmountType, Amount: dec("100"),
+					CurrencyUomId: currency("USD")},
+			},
+			lineDiscounts: map[int]string{1: "0", 2: "0"},
+			orderDiscount: "0",
+		},
+		{
+			name: "best order promotion is capped at the subtotal",
+			promotions: []Promotion{
+				{Id: 1, TypeId: OrderPercentageType, Percentage: dec("10"),
+					CurrencyUomId: currency("VND")},
+				{Id: 2, TypeId: OrderFixedAmountType, Amount: dec("99999"),
+					CurrencyUomId: currency("VND")},
+			},
+			lineDiscounts: map[int]string{1: "0", 2: "0"},
+			orderDiscount: "2299.99",
+			applied:       []int{2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Evaluate(tt.promotions, lines)
+
+			for seq, want := range tt.lineDiscounts {
+				got := result.LineDiscounts[seq]
+				if !got.Equal(dec(want)) {
+					t.Errorf("line %d discount %v, want %v", seq, got, want)
+				}
+			}
+			if !result.OrderDiscount.Equal(dec(tt.orderDiscount)) {
+				t.Errorf("order discount %v, want %v",
+					result.OrderDiscount, tt.orderDiscount)
+			}
+
+			if len(result.Applied) != len(tt.applied) {
+				t.Fatalf("applied %+v, want promotions %v",
+					result.Applied, tt.applied)
+			}
+			for i, a := range result.Applied {
+				if a.PromotionId != tt.applied[i] {
+					t.Errorf("applied %d is promotion %d, want %d",
+						i, a.PromotionId, tt.applied[i])
+				}
+			}
+		})
+	}
+}
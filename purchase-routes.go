@@ -0,0 +1,33 @@
+package main
+
+func PurchaseRoutes(root *Root) {
+	root.PostIdempotent(
+		"/api/purchase/add-purchase-order",
+		"IMPORT",
+		root.purchase.AddPurchaseOrderHandler)
+
+	root.GetAuthorized(
+		"/api/purchase/view-purchase-order",
+		"IMPORT",
+		root.purchase.ViewPurchaseOrderHandler)
+
+	root.GetAuthorized(
+		"/api/purchase/view-single-purchase-order",
+		"IMPORT",
+		root.purchase.ViewSinglePurchaseOrderHandler)
+
+	root.PostAuthorized(
+		"/api/purchase/receive-purchase-order",
+		"IMPORT",
+		root.purchase.ReceivePurchaseOrderHandler)
+
+	root.PostAuthorized(
+		"/api/purchase/cancel-purchase-order",
+		"IMPORT",
+		root.purchase.CancelPurchaseOrderHandler)
+
+	root.GetAuthorized(
+		"/api/purchase/view-supplier-performance",
+		"IMPORT",
+		root.purchase.ViewSupplierPerformanceHandler)
+}
@@ -0,0 +1,281 @@
+package purchase
+
+import (
+	"baseweb/basic"
+	"baseweb/security"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Root struct {
+	repo *Repo
+}
+
+func InitRoot(repo *Repo) *Root {
+	return &Root{
+		repo: repo,
+	}
+}
+
+var bodyError = errors.New("body constraints violation")
+
+// conflictErrors are the errors that come from the state of the
+// purchase order rather than from a failure.
+var conflictErrors = []error{
+	ErrPurchaseStatus,
+	ErrOverReceipt,
+}
+
+func parseSupplierId(value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (root *Root) AddPurchaseOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+	type Request struct {
+		SupplierId    uuid.UUID       `json:"supplierId"`
+		WarehouseId   uuid.UUID       `json:"warehouseId"`
+		CurrencyUomId string          `json:"currencyUomId"`
+		ExpectedDate  time.Time       `json:"expectedDate"`
+		Products      []ClientProduct `json:"products"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	if req.CurrencyUomId == "" || len(req.Products) == 0 {
+		return bodyError
+	}
+	for _, p := range req.Products {
+		if !p.Quantity.IsPositive() || p.UnitCost.IsNegative() {
+			return bodyError
+		}
+	}
+
+	id, err := root.repo.InsertPurchaseOrder(ctx,
+		req.SupplierId, req.WarehouseId,
+		req.CurrencyUomId, req.ExpectedDate,
+		req.Products, userLogin.Id)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		Id int64 `json:"id"`
+	}
+
+	res := Response{
+		Id: id,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewPurchaseOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	supplierId, err := parseSupplierId(query.Get("supplierId"))
+	if err != nil {
+		return err
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	statusId, err := strconv.Atoi(query.Get("statusId"))
+	if err != nil {
+		statusId = 0
+	}
+
+	sortedBy := "created_at"
+	sortedByQuery := query.Get("sortedBy")
+	if sortedByQuery == "updatedAt" {
+		sortedBy = "updated_at"
+	} else if sortedByQuery == "expectedDate" {
+		sortedBy = "expected_date"
+	}
+
+	sortOrder := "desc"
+	sortOrderQuery := query.Get("sortOrder")
+	if sortOrderQuery == "asc" {
+		sortOrder = "asc"
+	}
+
+	count, orders, err := root.repo.ViewPurchaseOrder(ctx,
+		supplierId, int16(statusId), page, pageSize, sortedBy, sortOrder)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		PurchaseOrderCount int                   `json:"purchaseOrderCount"`
+		PurchaseOrderList  []ClientPurchaseOrder `json:"purchaseOrderList"`
+	}
+
+	res := Response{
+		PurchaseOrderCount: count,
+		PurchaseOrderList:  orders,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewSinglePurchaseOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	id, err := strconv.ParseInt(query.Get("purchaseOrderId"), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	order, items, err := root.repo.GetPurchaseOrder(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		PurchaseOrder ClientPurchaseOrder `json:"purchaseOrder"`
+		Items         []PurchaseOrderItem `json:"items"`
+	}
+
+	res := Response{
+		PurchaseOrder: order,
+		Items:         items,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ReceivePurchaseOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Id    int64         `json:"id"`
+		Lines []ReceiptLine `json:"lines"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	if len(req.Lines) == 0 {
+		return bodyError
+	}
+	for _, line := range req.Lines {
+		if !line.Quantity.IsPositive() {
+			return bodyError
+		}
+	}
+
+	err = root.repo.ReceivePurchaseOrder(ctx, req.Id, req.Lines, time.Now())
+	if err != nil {
+		return basic.ReturnConflict(w, err, conflictErrors...)
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) CancelPurchaseOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Id int64 `json:"id"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.CancelPurchaseOrder(ctx, req.Id)
+	if err != nil {
+		return basic.ReturnConflict(w, err, conflictErrors...)
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) ViewSupplierPerformanceHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	supplierId, err := parseSupplierId(query.Get("supplierId"))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(),
+		0, 0, 0, 0, time.Local)
+
+	to, err := parseDate(query.Get("to"), today.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	from, err := parseDate(query.Get("from"), to.AddDate(0, -3, 0))
+	if err != nil {
+		return err
+	}
+
+	performances, err := root.repo.ViewSupplierPerformance(
+		ctx, supplierId, from, to)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		From            time.Time             `json:"from"`
+		To              time.Time             `json:"to"`
+		PerformanceList []SupplierPerformance `json:"performanceList"`
+	}
+
+	res := Response{
+		From:            from,
+		To:              to,
+		PerformanceList: performances,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
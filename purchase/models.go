@@ -0,0 +1,82 @@
+package purchase
+
+import (
+	"baseweb/basic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	OpenStatus              int16 = 1
+	PartiallyReceivedStatus int16 = 2
+	ReceivedStatus          int16 = 3
+	CanceledStatus          int16 = 4
+)
+
+type PurchaseOrder struct {
+	Id            int64     `json:"id" db:"id"`
+	SupplierId    uuid.UUID `json:"supplierId" db:"supplier_id"`
+	WarehouseId   uuid.UUID `json:"warehouseId" db:"warehouse_id"`
+	CurrencyUomId string    `json:"currencyUomId" db:"currency_uom_id"`
+	ExpectedDate  time.Time `json:"expectedDate" db:"expected_date"`
+	StatusId      int16     `json:"statusId" db:"purchase_order_status_id"`
+	CreatedBy     uuid.UUID `json:"createdBy" db:"created_by_user_login_id"`
+	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type ClientPurchaseOrder struct {
+	Id            int64     `json:"id" db:"id"`
+	SupplierId    uuid.UUID `json:"supplierId" db:"supplier_id"`
+	SupplierName  string    `json:"supplierName" db:"supplier_name"`
+	WarehouseId   uuid.UUID `json:"warehouseId" db:"warehouse_id"`
+	WarehouseName string    `json:"warehouseName" db:"warehouse_name"`
+	CurrencyUomId string    `json:"currencyUomId" db:"currency_uom_id"`
+	ExpectedDate  time.Time `json:"expectedDate" db:"expected_date"`
+	StatusId      int16     `json:"statusId" db:"purchase_order_status_id"`
+	CreatedBy     string    `json:"createdBy" db:"created_by"`
+	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type ClientProduct struct {
+	Id           int64           `json:"id"`
+	Quantity     decimal.Decimal `json:"quantity"`
+	UnitCost     decimal.Decimal `json:"unitCost"`
+	ExpectedDate *time.Time      `json:"expectedDate"`
+}
+
+type PurchaseOrderItem struct {
+	Seq              int16           `json:"seq" db:"purchase_order_seq"`
+	ProductId        int64           `json:"productId" db:"product_id"`
+	ProductName      string          `json:"productName" db:"product_name"`
+	Quantity         decimal.Decimal `json:"quantity" db:"quantity"`
+	UnitCost         decimal.Decimal `json:"unitCost" db:"unit_cost"`
+	ExpectedDate     time.Time       `json:"expectedDate" db:"expected_date"`
+	ReceivedQuantity decimal.Decimal `json:"receivedQuantity" db:"received_quantity"`
+	CompletedAt      basic.NullTime  `json:"completedAt" db:"completed_at"`
+}
+
+type ReceiptLine struct {
+	Seq        int16            `json:"seq"`
+	Quantity   decimal.Decimal  `json:"quantity"`
+	LocationId *int             `json:"locationId"`
+	LotNumber  basic.NullString `json:"lotNumber"`
+	ExpiryDate basic.NullTime   `json:"expiryDate"`
+}
+
+// SupplierPerformance counts the purchase order lines a supplier was
+// expected to deliver in a period. A line is complete once everything
+// ordered has been received, and on time when that happened no later
+// than its expected date.
+type SupplierPerformance struct {
+	SupplierId    uuid.UUID       `json:"supplierId" db:"supplier_id"`
+	SupplierName  string          `json:"supplierName" db:"supplier_name"`
+	LineCount     int             `json:"lineCount" db:"line_count"`
+	CompleteCount int             `json:"completeCount" db:"complete_count"`
+	OnTimeCount   int             `json:"onTimeCount" db:"on_time_count"`
+	CompleteRate  decimal.Decimal `json:"completeRate" db:"-"`
+	OnTimeRate    decimal.Decimal `json:"onTimeRate" db:"-"`
+}
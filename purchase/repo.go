@@ -0,0 +1,389 @@
+package purchase
+
+import (
+	importProduct "baseweb/import"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func InitRepo(db *sqlx.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+var ErrPurchaseStatus = errors.New("purchase order status transition not allowed")
+
+var ErrOverReceipt = errors.New("received quantity exceeds ordered quantity")
+
+var purchaseStatusTransitions = map[int16][]int16{
+	OpenStatus:              {PartiallyReceivedStatus, ReceivedStatus, CanceledStatus},
+	PartiallyReceivedStatus: {PartiallyReceivedStatus, ReceivedStatus},
+}
+
+func (repo *Repo) InsertPurchaseOrder(
+	ctx context.Context,
+	supplierId, warehouseId uuid.UUID,
+	currencyUomId string, expectedDate time.Time,
+	products []ClientProduct,
+	userLoginId uuid.UUID) (int64, error) {
+
+	log.Println("InsertPurchaseOrder", supplierId, warehouseId,
+		currencyUomId, expectedDate, products)
+
+	var id int64
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return id, err
+	}
+	defer tx.Rollback()
+
+	query := repo.db.Rebind(`
+        insert into purchase_order(
+        supplier_id, warehouse_id, currency_uom_id, expected_date,
+        purchase_order_status_id, created_by_user_login_id)
+        values (?, ?, ?, ?, ?, ?) returning id
+        `)
+	err = tx.GetContext(ctx, &id, query,
+		supplierId, warehouseId, currencyUomId, expectedDate,
+		OpenStatus, userLoginId)
+	if err != nil {
+		return id, err
+	}
+
+	query = repo.db.Rebind(`
+        insert into purchase_order_item(
+        purchase_order_id, purchase_order_seq,
+        product_id, quantity, unit_cost, expected_date)
+        values (?, ?, ?, ?, ?, ?)
+        `)
+	for index, p := range products {
+		// lines without a date of their own are expected with the order
+		lineExpectedDate := expectedDate
+		if p.ExpectedDate != nil {
+			lineExpectedDate = *p.ExpectedDate
+		}
+
+		_, err = tx.ExecContext(ctx, query, id, index,
+			p.Id, p.Quantity, p.UnitCost, lineExpectedDate)
+		if err != nil {
+			return id, err
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+func (repo *Repo) ViewPurchaseOrder(
+	ctx context.Context,
+	supplierId *uuid.UUID,
+	statusId int16,
+	page, pageSize int,
+	sortedBy, sortOrder string) (int, []ClientPurchaseOrder, error) {
+
+	log.Println("ViewPurchaseOrder", supplierId, statusId,
+		page, pageSize, sortedBy, sortOrder)
+
+	var count int
+	result := make([]ClientPurchaseOrder, 0)
+
+	query := repo.db.Rebind(`
+        select count(*) from purchase_order
+        where (cast(? as uuid) is null or supplier_id = ?)
+            and (? = 0 or purchase_order_status_id = ?)
+        `)
+	err := repo.db.GetContext(ctx, &count, query,
+		supplierId, supplierId, statusId, statusId)
+	if err != nil {
+		return count, result, err
+	}
+
+	query = `select po.id,
+        po.supplier_id, s.name as supplier_name,
+        po.warehouse_id, f.name as warehouse_name,
+        po.currency_uom_id, po.expected_date,
+        po.purchase_order_status_id,
+        u.username as created_by,
+        po.created_at, po.updated_at
+        from purchase_order po
+            inner join supplier s on s.id = po.supplier_id
+            inner join facility f on f.id = po.warehouse_id
+            inner join user_login u on u.id = po.created_by_user_login_id
+        where (cast(? as uuid) is null or po.supplier_id = ?)
+            and (? = 0 or po.purchase_order_status_id = ?)
+        order by po.%s %s
+        offset ? limit ?`
+	query = fmt.Sprintf(query, sortedBy, sortOrder)
+	query = repo.db.Rebind(query)
+	err = repo.db.SelectContext(ctx, &result, query,
+		supplierId, supplierId, statusId, statusId,
+		page*pageSize, pageSize)
+
+	return count, result, err
+}
+
+func (repo *Repo) GetPurchaseOrder(
+	ctx context.Context, id int64) (
+	ClientPurchaseOrder, []PurchaseOrderItem, error) {
+
+	log.Println("GetPurchaseOrder", id)
+
+	order := ClientPurchaseOrder{}
+	items := make([]PurchaseOrderItem, 0)
+
+	query := repo.db.Rebind(`
+        select po.id,
+        po.supplier_id, s.name as supplier_name,
+        po.warehouse_id, f.name as warehouse_name,
+        po.currency_uom_id, po.expected_date,
+        po.purchase_order_status_id,
+        u.username as created_by,
+        po.created_at, po.updated_at
+        from purchase_order po
+            inner join supplier s on s.id = po.supplier_id
+            inner join facility f on f.id = po.warehouse_id
+            inner join user_login u on u.id = po.created_by_user_login_id
+        where po.id = ?
+        `)
+	err := repo.db.GetContext(ctx, &order, query, id)
+	if err != nil {
+		return order, items, err
+	}
+
+	query = repo.db.Rebind(`
+        select pi.purchase_order_seq, pi.product_id,
+        p.name as product_name, pi.quantity, pi.unit_cost,
+        pi.expected_date, pi.received_quantity, pi.completed_at
+        from purchase_order_item pi
+            inner join product p on p.id = pi.product_id
+        where pi.purchase_order_id = ?
+        order by pi.purchase_order_seq
+        `)
+	err = repo.db.SelectContext(ctx, &items, query, id)
+
+	return order, items, err
+}
+
+func lockPurchaseOrder(
+	ctx context.Context, tx *sqlx.Tx,
+	id int64) (PurchaseOrder, error) {
+
+	order := PurchaseOrder{}
+	query := tx.Rebind(`
+        select id, supplier_id, warehouse_id, currency_uom_id,
+        expected_date, purchase_order_status_id,
+        created_by_user_login_id, created_at, updated_at
+        from purchase_order where id = ?
+        for update
+        `)
+	err := tx.GetContext(ctx, &order, query, id)
+
+	return order, err
+}
+
+func checkPurchaseStatus(statusId, nextStatusId int16) error {
+	for _, next := range purchaseStatusTransitions[statusId] {
+		if next == nextStatusId {
+			return nil
+		}
+	}
+	return ErrPurchaseStatus
+}
+
+func (repo *Repo) ReceivePurchaseOrder(
+	ctx context.Context, id int64,
+	lines []ReceiptLine, now time.Time) error {
+
+	log.Println("ReceivePurchaseOrder", id, lines)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	order, err := lockPurchaseOrder(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	// both receiving states accept a partial receipt, so this only
+	// rules out orders that are already received or canceled
+	err = checkPurchaseStatus(order.StatusId, PartiallyReceivedStatus)
+	if err != nil {
+		return err
+	}
+
+	itemQuery := repo.db.Rebind(`
+        select purchase_order_seq, product_id, quantity, unit_cost,
+        expected_date, received_quantity, completed_at
+        from purchase_order_item
+        where purchase_order_id = ? and purchase_order_seq = ?
+        for update
+        `)
+
+	updateQuery := repo.db.Rebind(`
+        update purchase_order_item
+        set received_quantity = ?, completed_at = ?
+        where purchase_order_id = ? and purchase_order_seq = ?
+        `)
+
+	for _, line := range lines {
+		item := PurchaseOrderItem{}
+		err = tx.GetContext(ctx, &item, itemQuery, id, line.Seq)
+		if err != nil {
+			return err
+		}
+
+		received := item.ReceivedQuantity.Add(line.Quantity)
+		if received.GreaterThan(item.Quantity) {
+			return ErrOverReceipt
+		}
+
+		seq := line.Seq
+		_, err = importProduct.ReceiveInventoryItem(ctx, tx,
+			importProduct.InventoryItem{
+				ProductId:        item.ProductId,
+				WarehouseId:      order.WarehouseId,
+				LocationId:       line.LocationId,
+				Quantity:         line.Quantity,
+				UnitCost:         item.UnitCost,
+				CurrencyUomId:    order.CurrencyUomId,
+				LotNumber:        line.LotNumber,
+				ExpiryDate:       line.ExpiryDate,
+				PurchaseOrderId:  &id,
+				PurchaseOrderSeq: &seq,
+			})
+		if err != nil {
+			return err
+		}
+
+		var completedAt *time.Time
+		if received.Equal(item.Quantity) {
+			completedAt = &now
+		}
+
+		_, err = tx.ExecContext(ctx, updateQuery,
+			received, completedAt, id, line.Seq)
+		if err != nil {
+			return err
+		}
+	}
+
+	var openCount int
+	query := repo.db.Rebind(`
+        select count(*) from purchase_order_item
+        where purchase_order_id = ? and completed_at is null
+        `)
+	err = tx.GetContext(ctx, &openCount, query, id)
+	if err != nil {
+		return err
+	}
+
+	statusId := PartiallyReceivedStatus
+	if openCount == 0 {
+		statusId = ReceivedStatus
+	}
+
+	query = repo.db.Rebind(`
+        update purchase_order
+        set purchase_order_status_id = ?
+        where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, statusId, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *Repo) CancelPurchaseOrder(ctx context.Context, id int64) error {
+	log.Println("CancelPurchaseOrder", id)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	order, err := lockPurchaseOrder(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	err = checkPurchaseStatus(order.StatusId, CanceledStatus)
+	if err != nil {
+		return err
+	}
+
+	query := repo.db.Rebind(`
+        update purchase_order
+        set purchase_order_status_id = ?
+        where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, CanceledStatus, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ViewSupplierPerformance reports on the lines expected in [from, to)
+// of orders that were not canceled. Lines still open past their expected
+// date count as neither complete nor on time.
+func (repo *Repo) ViewSupplierPerformance(
+	ctx context.Context,
+	supplierId *uuid.UUID,
+	from, to time.Time) ([]SupplierPerformance, error) {
+
+	log.Println("ViewSupplierPerformance", supplierId, from, to)
+
+	result := make([]SupplierPerformance, 0)
+
+	query := repo.db.Rebind(`
+        select po.supplier_id, s.name as supplier_name,
+        count(*) as line_count,
+        count(*) filter (
+            where pi.received_quantity >= pi.quantity
+        ) as complete_count,
+        count(*) filter (
+            where pi.completed_at is not null
+                and pi.completed_at::date <= pi.expected_date
+        ) as on_time_count
+        from purchase_order_item pi
+            inner join purchase_order po on po.id = pi.purchase_order_id
+            inner join supplier s on s.id = po.supplier_id
+        where po.purchase_order_status_id <> ?
+            and pi.expected_date >= ? and pi.expected_date < ?
+            and (cast(? as uuid) is null or po.supplier_id = ?)
+        group by po.supplier_id, s.name
+        order by s.name
+        `)
+	err := repo.db.SelectContext(ctx, &result, query,
+		CanceledStatus, from, to, supplierId, supplierId)
+	if err != nil {
+		return result, err
+	}
+
+	for i := range result {
+		result[i].CompleteRate = rate(
+			result[i].CompleteCount, result[i].LineCount)
+		result[i].OnTimeRate = rate(
+			result[i].OnTimeCount, result[i].LineCount)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,26 @@
+package purchase
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// rate is count over total as a percentage, zero when there is nothing
+// to count.
+func rate(count, total int) decimal.Decimal {
+	if total == 0 {
+		return decimal.Zero
+	}
+	return decimal.NewFromInt(int64(count)).
+		Mul(decimal.NewFromInt(100)).
+		Div(decimal.NewFromInt(int64(total))).Round(2)
+}
+
+// parseDate reads a plain date, an empty value gives def.
+func parseDate(value string, def time.Time) (time.Time, error) {
+	if value == "" {
+		return def, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
@@ -0,0 +1,28 @@
+package main
+
+func ReplenishmentRoutes(root *Root) {
+	root.PostAuthorized(
+		"/api/replenishment/update-reorder-setting",
+		"VIEW_EDIT_FACILITY",
+		root.replenishment.UpdateReorderSettingHandler)
+
+	root.PostAuthorized(
+		"/api/replenishment/delete-reorder-setting",
+		"VIEW_EDIT_FACILITY",
+		root.replenishment.DeleteReorderSettingHandler)
+
+	root.GetAuthorized(
+		"/api/replenishment/view-reorder-setting",
+		"VIEW_EDIT_FACILITY",
+		root.replenishment.ViewReorderSettingHandler)
+
+	root.PostAuthorized(
+		"/api/replenishment/evaluate",
+		"VIEW_EDIT_FACILITY",
+		root.replenishment.EvaluateHandler)
+
+	root.GetAuthorized(
+		"/api/replenishment/view-suggestion",
+		"IMPORT",
+		root.replenishment.ViewSuggestionHandler)
+}
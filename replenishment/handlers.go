@@ -0,0 +1,185 @@
+package replenishment
+
+import (
+	"baseweb/basic"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Root struct {
+	repo *Repo
+}
+
+func InitRoot(repo *Repo) *Root {
+	return &Root{
+		repo: repo,
+	}
+}
+
+var bodyError = errors.New("body constraints violation")
+
+func (root *Root) UpdateReorderSettingHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	setting := ReorderSetting{}
+	err := json.NewDecoder(r.Body).Decode(&setting)
+	if err != nil {
+		return err
+	}
+
+	if setting.MinQuantity.IsNegative() ||
+		!setting.MaxQuantity.IsPositive() ||
+		setting.MinQuantity.GreaterThan(setting.MaxQuantity) ||
+		setting.LeadTimeDays < 0 {
+		return bodyError
+	}
+	if setting.ReorderPoint.Valid &&
+		setting.ReorderPoint.Decimal.IsNegative() {
+		return bodyError
+	}
+
+	err = root.repo.UpsertReorderSetting(ctx, setting)
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) DeleteReorderSettingHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		WarehouseId uuid.UUID `json:"warehouseId"`
+		ProductId   int64     `json:"productId"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.DeleteReorderSetting(ctx, req.WarehouseId, req.ProductId)
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) ViewReorderSettingHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	warehouseId, err := uuid.Parse(query.Get("warehouseId"))
+	if err != nil {
+		return err
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	sortedBy := "updated_at"
+	sortedByQuery := query.Get("sortedBy")
+	if sortedByQuery == "createdAt" {
+		sortedBy = "created_at"
+	} else if sortedByQuery == "productId" {
+		sortedBy = "product_id"
+	}
+
+	sortOrder := "desc"
+	sortOrderQuery := query.Get("sortOrder")
+	if sortOrderQuery == "asc" {
+		sortOrder = "asc"
+	}
+
+	count, settings, err := root.repo.ViewReorderSetting(ctx,
+		warehouseId, page, pageSize, sortedBy, sortOrder)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		ReorderSettingCount int                    `json:"reorderSettingCount"`
+		ReorderSettingList  []ClientReorderSetting `json:"reorderSettingList"`
+	}
+
+	res := Response{
+		ReorderSettingCount: count,
+		ReorderSettingList:  settings,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) EvaluateHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	err := root.repo.Evaluate(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) ViewSuggestionHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	warehouseId, err := uuid.Parse(query.Get("warehouseId"))
+	if err != nil {
+		return err
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	count, suggestions, err := root.repo.ViewSuggestion(ctx,
+		warehouseId, page, pageSize)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		SuggestionCount int          `json:"suggestionCount"`
+		SuggestionList  []Suggestion `json:"suggestionList"`
+	}
+
+	res := Response{
+		SuggestionCount: count,
+		SuggestionList:  suggestions,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,51 @@
+package replenishment
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type ReorderSetting struct {
+	WarehouseId  uuid.UUID           `json:"warehouseId" db:"warehouse_id"`
+	ProductId    int64               `json:"productId" db:"product_id"`
+	MinQuantity  decimal.Decimal     `json:"minQuantity" db:"min_quantity"`
+	MaxQuantity  decimal.Decimal     `json:"maxQuantity" db:"max_quantity"`
+	ReorderPoint decimal.NullDecimal `json:"reorderPoint" db:"reorder_point"`
+	LeadTimeDays int                 `json:"leadTimeDays" db:"lead_time_days"`
+}
+
+type ClientReorderSetting struct {
+	WarehouseId  uuid.UUID           `json:"warehouseId" db:"warehouse_id"`
+	ProductId    int64               `json:"productId" db:"product_id"`
+	ProductName  string              `json:"productName" db:"product_name"`
+	MinQuantity  decimal.Decimal     `json:"minQuantity" db:"min_quantity"`
+	MaxQuantity  decimal.Decimal     `json:"maxQuantity" db:"max_quantity"`
+	ReorderPoint decimal.NullDecimal `json:"reorderPoint" db:"reorder_point"`
+	LeadTimeDays int                 `json:"leadTimeDays" db:"lead_time_days"`
+	CreatedAt    time.Time           `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time           `json:"updatedAt" db:"updated_at"`
+}
+
+// Position is a reorder setting along with where the stock stands.
+type Position struct {
+	ReorderSetting
+	QuantityAvailable decimal.Decimal `db:"quantity_available"`
+	QuantityInTransit decimal.Decimal `db:"quantity_in_transit"`
+	QuantityOnOrder   decimal.Decimal `db:"quantity_on_order"`
+	ExportedQuantity  decimal.Decimal `db:"exported_quantity"`
+}
+
+type Suggestion struct {
+	WarehouseId       uuid.UUID       `json:"warehouseId" db:"warehouse_id"`
+	ProductId         int64           `json:"productId" db:"product_id"`
+	ProductName       string          `json:"productName" db:"product_name"`
+	QuantityAvailable decimal.Decimal `json:"quantityAvailable" db:"quantity_available"`
+	QuantityInTransit decimal.Decimal `json:"quantityInTransit" db:"quantity_in_transit"`
+	QuantityOnOrder   decimal.Decimal `json:"quantityOnOrder" db:"quantity_on_order"`
+	DailyVelocity     decimal.Decimal `json:"dailyVelocity" db:"daily_velocity"`
+	ReorderPoint      decimal.Decimal `json:"reorderPoint" db:"reorder_point"`
+	SuggestedQuantity decimal.Decimal `json:"suggestedQuantity" db:"suggested_quantity"`
+	ComputedAt        time.Time       `json:"computedAt" db:"computed_at"`
+}
@@ -0,0 +1,221 @@
+package replenishment
+
+import (
+	"baseweb/purchase"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func InitRepo(db *sqlx.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+func (repo *Repo) UpsertReorderSetting(
+	ctx context.Context, setting ReorderSetting) error {
+
+	log.Println("UpsertReorderSetting", setting.WarehouseId,
+		setting.ProductId, setting.MinQuantity, setting.MaxQuantity,
+		setting.ReorderPoint, setting.LeadTimeDays)
+
+	query := `insert into reorder_setting(
+        warehouse_id, product_id,
+        min_quantity, max_quantity, reorder_point, lead_time_days)
+        values (:warehouse_id, :product_id,
+        :min_quantity, :max_quantity, :reorder_point, :lead_time_days)
+        on conflict (warehouse_id, product_id) do update
+        set min_quantity = excluded.min_quantity,
+            max_quantity = excluded.max_quantity,
+            reorder_point = excluded.reorder_point,
+            lead_time_days = excluded.lead_time_days`
+	_, err := repo.db.NamedExecContext(ctx, query, setting)
+	return err
+}
+
+func (repo *Repo) DeleteReorderSetting(
+	ctx context.Context, warehouseId uuid.UUID, productId int64) error {
+
+	log.Println("DeleteReorderSetting", warehouseId, productId)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := repo.db.Rebind(`
+        delete from replenishment_suggestion
+        where warehouse_id = ? and product_id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, warehouseId, productId)
+	if err != nil {
+		return err
+	}
+
+	query = repo.db.Rebind(`
+        delete from reorder_setting
+        where warehouse_id = ? and product_id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, warehouseId, productId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *Repo) ViewReorderSetting(
+	ctx context.Context,
+	warehouseId uuid.UUID,
+	page, pageSize int,
+	sortedBy, sortOrder string) (int, []ClientReorderSetting, error) {
+
+	log.Println("ViewReorderSetting", warehouseId,
+		page, pageSize, sortedBy, sortOrder)
+
+	var count int
+	result := make([]ClientReorderSetting, 0)
+
+	query := repo.db.Rebind(`
+        select count(*) from reorder_setting where warehouse_id = ?
+        `)
+	err := repo.db.GetContext(ctx, &count, query, warehouseId)
+	if err != nil {
+		return count, result, err
+	}
+
+	query = `select r.warehouse_id, r.product_id, p.name as product_name,
+        r.min_quantity, r.max_quantity, r.reorder_point, r.lead_time_days,
+        r.created_at, r.updated_at
+        from reorder_setting r
+            inner join product p on p.id = r.product_id
+        where r.warehouse_id = ?
+        order by r.%s %s
+        offset ? limit ?`
+	query = fmt.Sprintf(query, sortedBy, sortOrder)
+	query = repo.db.Rebind(query)
+	err = repo.db.SelectContext(ctx, &result, query,
+		warehouseId, page*pageSize, pageSize)
+
+	return count, result, err
+}
+
+// Evaluate recomputes every replenishment suggestion from the current
+// statistics and the exports of the last VELOCITY_DAYS.
+func (repo *Repo) Evaluate(ctx context.Context, now time.Time) error {
+	log.Println("Evaluate", now)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	positions := make([]Position, 0)
+	query := repo.db.Rebind(`
+        select r.warehouse_id, r.product_id,
+        r.min_quantity, r.max_quantity, r.reorder_point, r.lead_time_days,
+        coalesce(s.quantity_available, 0) as quantity_available,
+        coalesce(s.quantity_in_transit, 0) as quantity_in_transit,
+        coalesce((
+            select sum(pi.quantity - pi.received_quantity)
+            from purchase_order_item pi
+                inner join purchase_order po
+                    on po.id = pi.purchase_order_id
+            where po.warehouse_id = r.warehouse_id
+                and pi.product_id = r.product_id
+                and po.purchase_order_status_id in (?, ?)
+        ), 0) as quantity_on_order,
+        coalesce((
+            select sum(d.exported_quantity)
+            from inventory_item_detail d
+                inner join inventory_item i on i.id = d.inventory_item_id
+            where i.warehouse_id = r.warehouse_id
+                and i.product_id = r.product_id
+                and d.effective_from > ?
+        ), 0) as exported_quantity
+        from reorder_setting r
+            left join warehouse_product_statistics s
+                on s.warehouse_id = r.warehouse_id
+                and s.product_id = r.product_id
+        `)
+	err = tx.SelectContext(ctx, &positions, query,
+		purchase.OpenStatus, purchase.PartiallyReceivedStatus,
+		now.AddDate(0, 0, -VELOCITY_DAYS))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `delete from replenishment_suggestion`)
+	if err != nil {
+		return err
+	}
+
+	query = `insert into replenishment_suggestion(
+        warehouse_id, product_id,
+        quantity_available, quantity_in_transit, quantity_on_order,
+        daily_velocity, reorder_point, suggested_quantity,
+        computed_at)
+        values (:warehouse_id, :product_id,
+        :quantity_available, :quantity_in_transit, :quantity_on_order,
+        :daily_velocity, :reorder_point, :suggested_quantity,
+        :computed_at)`
+	for _, p := range positions {
+		suggestion, ok := Suggest(p, now)
+		if !ok {
+			continue
+		}
+		_, err = tx.NamedExecContext(ctx, query, suggestion)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (repo *Repo) ViewSuggestion(
+	ctx context.Context,
+	warehouseId uuid.UUID,
+	page, pageSize int) (int, []Suggestion, error) {
+
+	log.Println("ViewSuggestion", warehouseId, page, pageSize)
+
+	var count int
+	result := make([]Suggestion, 0)
+
+	query := repo.db.Rebind(`
+        select count(*) from replenishment_suggestion
+        where warehouse_id = ?
+        `)
+	err := repo.db.GetContext(ctx, &count, query, warehouseId)
+	if err != nil {
+		return count, result, err
+	}
+
+	query = repo.db.Rebind(`
+        select r.warehouse_id, r.product_id, p.name as product_name,
+        r.quantity_available, r.quantity_in_transit, r.quantity_on_order,
+        r.daily_velocity, r.reorder_point, r.suggested_quantity,
+        r.computed_at
+        from replenishment_suggestion r
+            inner join product p on p.id = r.product_id
+        where r.warehouse_id = ?
+        order by r.quantity_available - r.reorder_point, r.product_id
+        offset ? limit ?
+        `)
+	err = repo.db.SelectContext(ctx, &result, query,
+		warehouseId, page*pageSize, pageSize)
+
+	return count, result, err
+}
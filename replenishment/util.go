@@ -0,0 +1,61 @@
+package replenishment
+
+import (
+	"baseweb/basic"
+	"context"
+	"log"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const VELOCITY_DAYS = 30
+
+// Suggest works out whether a product has to be reordered.
+//
+// The daily velocity is what was exported over the last VELOCITY_DAYS.
+// When no reorder point is set, it is the minimum quantity plus what is
+// expected to go out during the lead time. Once the available, in
+// transit and on order quantities fall to the reorder point, the
+// suggestion brings the stock back up to the maximum quantity.
+func Suggest(p Position, now time.Time) (Suggestion, bool) {
+	velocity := p.ExportedQuantity.
+		Div(decimal.NewFromInt(VELOCITY_DAYS)).Round(4)
+
+	reorderPoint := p.MinQuantity.Add(
+		velocity.Mul(decimal.NewFromInt(int64(p.LeadTimeDays))))
+	if p.ReorderPoint.Valid {
+		reorderPoint = p.ReorderPoint.Decimal
+	}
+
+	position := p.QuantityAvailable.Add(p.QuantityInTransit).
+		Add(p.QuantityOnOrder)
+
+	suggestion := Suggestion{
+		WarehouseId:       p.WarehouseId,
+		ProductId:         p.ProductId,
+		QuantityAvailable: p.QuantityAvailable,
+		QuantityInTransit: p.QuantityInTransit,
+		QuantityOnOrder:   p.QuantityOnOrder,
+		DailyVelocity:     velocity,
+		ReorderPoint:      reorderPoint,
+		SuggestedQuantity: p.MaxQuantity.Sub(position).Ceil(),
+		ComputedAt:        now,
+	}
+
+	if position.GreaterThan(reorderPoint) ||
+		!suggestion.SuggestedQuantity.IsPositive() {
+		return suggestion, false
+	}
+	return suggestion, true
+}
+
+// RunJob evaluates the reorder settings every interval until ctx is done.
+func RunJob(ctx context.Context, repo *Repo, interval time.Duration) {
+	basic.RunEvery(ctx, interval, func() {
+		err := repo.Evaluate(ctx, time.Now())
+		if err != nil {
+			log.Println("[ERROR] replenishment job", err)
+		}
+	})
+}
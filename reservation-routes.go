@@ -0,0 +1,13 @@
+package main
+
+func ReservationRoutes(root *Root) {
+	root.GetAuthorized(
+		"/api/reservation/view-reservation-summary",
+		"VIEW_EDIT_ORDER",
+		root.reservation.ViewReservationSummaryHandler)
+
+	root.GetAuthorized(
+		"/api/reservation/view-reservation",
+		"VIEW_EDIT_ORDER",
+		root.reservation.ViewReservationHandler)
+}
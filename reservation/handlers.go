@@ -0,0 +1,108 @@
+package reservation
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+type Root struct {
+	repo *Repo
+}
+
+func InitRoot(repo *Repo) *Root {
+	return &Root{
+		repo: repo,
+	}
+}
+
+func (root *Root) ViewReservationSummaryHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	var warehouseId *uuid.UUID
+	if query.Get("warehouseId") != "" {
+		id, err := uuid.Parse(query.Get("warehouseId"))
+		if err != nil {
+			return err
+		}
+		warehouseId = &id
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	count, summaries, err := root.repo.ViewSummary(ctx,
+		warehouseId, page, pageSize)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		SummaryCount int       `json:"summaryCount"`
+		SummaryList  []Summary `json:"summaryList"`
+	}
+
+	res := Response{
+		SummaryCount: count,
+		SummaryList:  summaries,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewReservationHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	warehouseId, err := uuid.Parse(query.Get("warehouseId"))
+	if err != nil {
+		return err
+	}
+
+	productId, err := strconv.ParseInt(query.Get("productId"), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	count, reservations, err := root.repo.ViewReservation(ctx,
+		warehouseId, productId, page, pageSize)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		ReservationCount int                 `json:"reservationCount"`
+		ReservationList  []ClientReservation `json:"reservationList"`
+	}
+
+	res := Response{
+		ReservationCount: count,
+		ReservationList:  reservations,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
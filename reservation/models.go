@@ -0,0 +1,46 @@
+package reservation
+
+import (
+	"baseweb/basic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ActiveStatus   int16 = 1
+	ConsumedStatus int16 = 2
+	ReleasedStatus int16 = 3
+	ExpiredStatus  int16 = 4
+)
+
+type Reservation struct {
+	SaleOrderId  int64           `json:"saleOrderId" db:"sale_order_id"`
+	SaleOrderSeq int             `json:"saleOrderSeq" db:"sale_order_seq"`
+	WarehouseId  uuid.UUID       `json:"warehouseId" db:"warehouse_id"`
+	ProductId    int64           `json:"productId" db:"product_id"`
+	Quantity     decimal.Decimal `json:"quantity" db:"quantity"`
+}
+
+type ClientReservation struct {
+	Reservation
+	ProductName  string         `json:"productName" db:"product_name"`
+	CustomerName string         `json:"customerName" db:"customer_name"`
+	StatusId     int16          `json:"statusId" db:"reservation_status_id"`
+	ExpiresAt    basic.NullTime `json:"expiresAt" db:"expires_at"`
+	CreatedAt    time.Time      `json:"createdAt" db:"created_at"`
+}
+
+// Summary is what is held back from a product in a warehouse by the
+// active reservations.
+type Summary struct {
+	WarehouseId       uuid.UUID       `json:"warehouseId" db:"warehouse_id"`
+	WarehouseName     string          `json:"warehouseName" db:"warehouse_name"`
+	ProductId         int64           `json:"productId" db:"product_id"`
+	ProductName       string          `json:"productName" db:"product_name"`
+	ReservationCount  int             `json:"reservationCount" db:"reservation_count"`
+	ReservedQuantity  decimal.Decimal `json:"reservedQuantity" db:"reserved_quantity"`
+	QuantityOnHand    decimal.Decimal `json:"quantityOnHand" db:"quantity_on_hand"`
+	QuantityAvailable decimal.Decimal `json:"quantityAvailable" db:"quantity_available"`
+}
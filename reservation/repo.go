@@ -0,0 +1,363 @@
+package reservation
+
+import (
+	"baseweb/ledger"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func InitRepo(db *sqlx.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+var ErrNotStocked = errors.New("product is not stocked in the warehouse")
+
+// Reserve holds back r.Quantity of the product in its warehouse for
+// the order item until it is exported, released or expires.
+func Reserve(
+	ctx context.Context, tx *sqlx.Tx,
+	r Reservation, now time.Time) error {
+
+	log.Println("Reserve", r.SaleOrderId, r.SaleOrderSeq,
+		r.WarehouseId, r.ProductId, r.Quantity)
+
+	query := tx.Rebind(`
+        update warehouse_product_statistics
+        set quantity_available = quantity_available - ?
+        where product_id = ? and warehouse_id = ?
+        `)
+	res, err := tx.ExecContext(ctx, query,
+		r.Quantity, r.ProductId, r.WarehouseId)
+	if err != nil {
+		return err
+	}
+
+	// without statistics for the warehouse nothing was ever received
+	// there, so there is nothing to reserve
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotStocked
+	}
+
+	query = tx.Rebind(`
+        insert into stock_reservation(
+        sale_order_id, sale_order_seq,
+        warehouse_id, product_id, quantity,
+        reservation_status_id, expires_at)
+        values (?, ?, ?, ?, ?, ?, ?)
+        `)
+	_, err = tx.ExecContext(ctx, query,
+		r.SaleOrderId, r.SaleOrderSeq,
+		r.WarehouseId, r.ProductId, r.Quantity,
+		ActiveStatus, now.Add(RESERVATION_TTL))
+	if err != nil {
+		return err
+	}
+
+	return ledger.Record(ctx, tx, ledger.Transaction{
+		TypeId:                ledger.OrderPlacedType,
+		WarehouseId:           r.WarehouseId,
+		ProductId:             r.ProductId,
+		QuantityAvailableDiff: r.Quantity.Neg(),
+		SaleOrderId:           &r.SaleOrderId,
+		SaleOrderSeq:          &r.SaleOrderSeq,
+		OccurredAt:            now,
+	})
+}
+
+// Release gives back everything still reserved by the order, marking
+// the reservations with statusId, either ReleasedStatus or
+// ExpiredStatus.
+func Release(
+	ctx context.Context, tx *sqlx.Tx,
+	saleOrderId int64, statusId int16, now time.Time) error {
+
+	log.Println("Release", saleOrderId, statusId)
+
+	return release(ctx, tx, saleOrderId, nil, statusId, now)
+}
+
+// ReleaseItem gives back what a single order item still reserves.
+func ReleaseItem(
+	ctx context.Context, tx *sqlx.Tx,
+	saleOrderId int64, saleOrderSeq int, now time.Time) error {
+
+	log.Println("ReleaseItem", saleOrderId, saleOrderSeq)
+
+	return release(ctx, tx, saleOrderId, &saleOrderSeq,
+		ReleasedStatus, now)
+}
+
+// release frees the active reservations of the order, or of only the
+// item saleOrderSeq when it is not nil.
+func release(
+	ctx context.Context, tx *sqlx.Tx,
+	saleOrderId int64, saleOrderSeq *int,
+	statusId int16, now time.Time) error {
+
+	reservations := make([]Reservation, 0)
+	query := tx.Rebind(`
+        select sale_order_id, sale_order_seq,
+        warehouse_id, product_id, quantity
+        from stock_reservation
+        where sale_order_id = ? and reservation_status_id = ?
+            and (cast(? as smallint) is null or sale_order_seq = ?)
+        order by sale_order_seq
+        for update
+        `)
+	err := tx.SelectContext(ctx, &reservations, query,
+		saleOrderId, ActiveStatus, saleOrderSeq, saleOrderSeq)
+	if err != nil {
+		return err
+	}
+
+	typeId := ledger.OrderCanceledType
+	if statusId == ExpiredStatus {
+		typeId = ledger.ReservationExpiredType
+	}
+
+	availableQuery := tx.Rebind(`
+        update warehouse_product_statistics
+        set quantity_available = quantity_available + ?
+        where product_id = ? and warehouse_id = ?
+        `)
+
+	for i := range reservations {
+		r := reservations[i]
+		_, err = tx.ExecContext(ctx, availableQuery,
+			r.Quantity, r.ProductId, r.WarehouseId)
+		if err != nil {
+			return err
+		}
+
+		err = ledger.Record(ctx, tx, ledger.Transaction{
+			TypeId:                typeId,
+			WarehouseId:           r.WarehouseId,
+			ProductId:             r.ProductId,
+			QuantityAvailableDiff: r.Quantity,
+			SaleOrderId:           &r.SaleOrderId,
+			SaleOrderSeq:          &r.SaleOrderSeq,
+			OccurredAt:            now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	query = tx.Rebind(`
+        update stock_reservation
+        set reservation_status_id = ?, released_at = ?
+        where sale_order_id = ? and reservation_status_id = ?
+            and (cast(? as smallint) is null or sale_order_seq = ?)
+        `)
+	_, err = tx.ExecContext(ctx, query,
+		statusId, now, saleOrderId, ActiveStatus,
+		saleOrderSeq, saleOrderSeq)
+
+	return err
+}
+
+// Resize changes how much an active reservation holds to quantity,
+// taking the difference from or giving it back to the available
+// quantity.
+func Resize(
+	ctx context.Context, tx *sqlx.Tx,
+	saleOrderId int64, saleOrderSeq int,
+	quantity decimal.Decimal, now time.Time) error {
+
+	log.Println("Resize", saleOrderId, saleOrderSeq, quantity)
+
+	r := Reservation{}
+	query := tx.Rebind(`
+        select sale_order_id, sale_order_seq,
+        warehouse_id, product_id, quantity
+        from stock_reservation
+        where sale_order_id = ? and sale_order_seq = ?
+            and reservation_status_id = ?
+        for update
+        `)
+	err := tx.GetContext(ctx, &r, query,
+		saleOrderId, saleOrderSeq, ActiveStatus)
+	if err != nil {
+		return err
+	}
+
+	diff := quantity.Sub(r.Quantity)
+	if diff.IsZero() {
+		return nil
+	}
+
+	query = tx.Rebind(`
+        update warehouse_product_statistics
+        set quantity_available = quantity_available - ?
+        where product_id = ? and warehouse_id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query,
+		diff, r.ProductId, r.WarehouseId)
+	if err != nil {
+		return err
+	}
+
+	query = tx.Rebind(`
+        update stock_reservation set quantity = ?
+        where sale_order_id = ? and sale_order_seq = ?
+        `)
+	_, err = tx.ExecContext(ctx, query,
+		quantity, saleOrderId, saleOrderSeq)
+	if err != nil {
+		return err
+	}
+
+	return ledger.Record(ctx, tx, ledger.Transaction{
+		TypeId:                ledger.OrderChangedType,
+		WarehouseId:           r.WarehouseId,
+		ProductId:             r.ProductId,
+		QuantityAvailableDiff: diff.Neg(),
+		SaleOrderId:           &r.SaleOrderId,
+		SaleOrderSeq:          &r.SaleOrderSeq,
+		OccurredAt:            now,
+	})
+}
+
+// Consume marks the reservation of an exported order item as used up.
+// The available quantity already went down when it was reserved.
+func Consume(
+	ctx context.Context, tx *sqlx.Tx,
+	saleOrderId int64, saleOrderSeq int, now time.Time) error {
+
+	log.Println("Consume", saleOrderId, saleOrderSeq)
+
+	query := tx.Rebind(`
+        update stock_reservation
+        set reservation_status_id = ?, released_at = ?
+        where sale_order_id = ? and sale_order_seq = ?
+            and reservation_status_id = ?
+        `)
+	_, err := tx.ExecContext(ctx, query,
+		ConsumedStatus, now, saleOrderId, saleOrderSeq, ActiveStatus)
+
+	return err
+}
+
+// Hold stops the reservations of an accepted order from expiring.
+func Hold(ctx context.Context, tx *sqlx.Tx, saleOrderId int64) error {
+	log.Println("Hold", saleOrderId)
+
+	query := tx.Rebind(`
+        update stock_reservation
+        set expires_at = null
+        where sale_order_id = ? and reservation_status_id = ?
+        `)
+	_, err := tx.ExecContext(ctx, query, saleOrderId, ActiveStatus)
+
+	return err
+}
+
+func (repo *Repo) ViewSummary(
+	ctx context.Context,
+	warehouseId *uuid.UUID,
+	page, pageSize int) (int, []Summary, error) {
+
+	log.Println("ViewSummary", warehouseId, page, pageSize)
+
+	var count int
+	result := make([]Summary, 0)
+
+	query := repo.db.Rebind(`
+        select count(*) from (
+            select distinct warehouse_id, product_id
+            from stock_reservation
+            where reservation_status_id = ?
+                and (cast(? as uuid) is null or warehouse_id = ?)
+        ) r
+        `)
+	err := repo.db.GetContext(ctx, &count, query,
+		ActiveStatus, warehouseId, warehouseId)
+	if err != nil {
+		return count, result, err
+	}
+
+	query = repo.db.Rebind(`
+        select r.warehouse_id, f.name as warehouse_name,
+        r.product_id, p.name as product_name,
+        count(*) as reservation_count,
+        sum(r.quantity) as reserved_quantity,
+        coalesce(s.quantity_on_hand, 0) as quantity_on_hand,
+        coalesce(s.quantity_available, 0) as quantity_available
+        from stock_reservation r
+            inner join facility f on f.id = r.warehouse_id
+            inner join product p on p.id = r.product_id
+            left join warehouse_product_statistics s
+                on s.warehouse_id = r.warehouse_id
+                and s.product_id = r.product_id
+        where r.reservation_status_id = ?
+            and (cast(? as uuid) is null or r.warehouse_id = ?)
+        group by r.warehouse_id, f.name, r.product_id, p.name,
+            s.quantity_on_hand, s.quantity_available
+        order by f.name, p.name
+        offset ? limit ?
+        `)
+	err = repo.db.SelectContext(ctx, &result, query,
+		ActiveStatus, warehouseId, warehouseId,
+		page*pageSize, pageSize)
+
+	return count, result, err
+}
+
+func (repo *Repo) ViewReservation(
+	ctx context.Context,
+	warehouseId uuid.UUID, productId int64,
+	page, pageSize int) (int, []ClientReservation, error) {
+
+	log.Println("ViewReservation", warehouseId, productId,
+		page, pageSize)
+
+	var count int
+	result := make([]ClientReservation, 0)
+
+	query := repo.db.Rebind(`
+        select count(*) from stock_reservation
+        where warehouse_id = ? and product_id = ?
+            and reservation_status_id = ?
+        `)
+	err := repo.db.GetContext(ctx, &count, query,
+		warehouseId, productId, ActiveStatus)
+	if err != nil {
+		return count, result, err
+	}
+
+	query = repo.db.Rebind(`
+        select r.sale_order_id, r.sale_order_seq,
+        r.warehouse_id, r.product_id, r.quantity,
+        p.name as product_name, c.name as customer_name,
+        r.reservation_status_id, r.expires_at, r.created_at
+        from stock_reservation r
+            inner join product p on p.id = r.product_id
+            inner join sale_order o on o.id = r.sale_order_id
+            inner join customer c on c.id = o.customer_id
+        where r.warehouse_id = ? and r.product_id = ?
+            and r.reservation_status_id = ?
+        order by r.created_at, r.sale_order_id, r.sale_order_seq
+        offset ? limit ?
+        `)
+	err = repo.db.SelectContext(ctx, &result, query,
+		warehouseId, productId, ActiveStatus,
+		page*pageSize, pageSize)
+
+	return count, result, err
+}
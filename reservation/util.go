@@ -0,0 +1,8 @@
+package reservation
+
+import "time"
+
+// RESERVATION_TTL is how long an order may stay created before what it
+// holds is given back. Accepting the order keeps the reservation until
+// the goods are exported.
+const RESERVATION_TTL = 72 * time.Hour
@@ -0,0 +1,33 @@
+package main
+
+func ReturnsRoutes(root *Root) {
+	root.PostIdempotent(
+		"/api/returns/add-return-order",
+		"VIEW_EDIT_ORDER",
+		root.returns.AddReturnOrderHandler)
+
+	root.GetAuthorized(
+		"/api/returns/view-return-order",
+		"VIEW_EDIT_ORDER",
+		root.returns.ViewReturnOrderHandler)
+
+	root.GetAuthorized(
+		"/api/returns/view-single-return-order",
+		"VIEW_EDIT_ORDER",
+		root.returns.ViewSingleReturnOrderHandler)
+
+	root.PostAuthorized(
+		"/api/returns/receive-return-order",
+		"IMPORT",
+		root.returns.ReceiveReturnOrderHandler)
+
+	root.PostAuthorized(
+		"/api/returns/cancel-return-order",
+		"VIEW_EDIT_ORDER",
+		root.returns.CancelReturnOrderHandler)
+
+	root.GetAuthorized(
+		"/api/returns/view-customer-credit",
+		"VIEW_EDIT_ORDER",
+		root.returns.ViewCustomerCreditHandler)
+}
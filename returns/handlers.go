@@ -0,0 +1,270 @@
+package returns
+
+import (
+	"baseweb/basic"
+	"baseweb/security"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+type Root struct {
+	repo *Repo
+}
+
+func InitRoot(repo *Repo) *Root {
+	return &Root{
+		repo: repo,
+	}
+}
+
+var bodyError = errors.New("body constraints violation")
+
+// conflictErrors are the errors that come from the state of the return
+// or sale order rather than from a failure.
+var conflictErrors = []error{
+	ErrReturnStatus,
+	ErrNotReturnable,
+	ErrOverReturn,
+	ErrOverReceipt,
+	ErrRestore,
+	ErrOverRestore,
+}
+
+func (root *Root) AddReturnOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+	type Request struct {
+		SaleOrderId int64        `json:"saleOrderId"`
+		Reason      string       `json:"reason"`
+		Items       []ClientItem `json:"items"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	if len(req.Items) == 0 {
+		return bodyError
+	}
+	for _, item := range req.Items {
+		if !item.Quantity.IsPositive() {
+			return bodyError
+		}
+	}
+
+	id, err := root.repo.InsertReturnOrder(ctx,
+		req.SaleOrderId, req.Reason, req.Items, userLogin.Id)
+	if err != nil {
+		return basic.ReturnConflict(w, err, conflictErrors...)
+	}
+
+	type Response struct {
+		Id int64 `json:"id"`
+	}
+
+	res := Response{
+		Id: id,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewReturnOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	var customerId *uuid.UUID
+	if query.Get("customerId") != "" {
+		id, err := uuid.Parse(query.Get("customerId"))
+		if err != nil {
+			return err
+		}
+		customerId = &id
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	statusId, err := strconv.Atoi(query.Get("statusId"))
+	if err != nil {
+		statusId = 0
+	}
+
+	sortedBy := "created_at"
+	sortedByQuery := query.Get("sortedBy")
+	if sortedByQuery == "updatedAt" {
+		sortedBy = "updated_at"
+	}
+
+	sortOrder := "desc"
+	sortOrderQuery := query.Get("sortOrder")
+	if sortOrderQuery == "asc" {
+		sortOrder = "asc"
+	}
+
+	count, returnOrders, err := root.repo.ViewReturnOrder(ctx,
+		customerId, int16(statusId), page, pageSize, sortedBy, sortOrder)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		ReturnOrderCount int                 `json:"returnOrderCount"`
+		ReturnOrderList  []ClientReturnOrder `json:"returnOrderList"`
+	}
+
+	res := Response{
+		ReturnOrderCount: count,
+		ReturnOrderList:  returnOrders,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewSingleReturnOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	id, err := strconv.ParseInt(query.Get("id"), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	returnOrder, items, err := root.repo.GetReturnOrder(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		ReturnOrder ClientReturnOrder `json:"returnOrder"`
+		Items       []ReturnOrderItem `json:"items"`
+	}
+
+	res := Response{
+		ReturnOrder: returnOrder,
+		Items:       items,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ReceiveReturnOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+	type Request struct {
+		Id          int64         `json:"id"`
+		WarehouseId uuid.UUID     `json:"warehouseId"`
+		Lines       []ReceiptLine `json:"lines"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	if len(req.Lines) == 0 {
+		return bodyError
+	}
+	for _, line := range req.Lines {
+		if !line.Quantity.IsPositive() {
+			return bodyError
+		}
+	}
+
+	err = root.repo.ReceiveReturnOrder(ctx,
+		req.Id, req.WarehouseId, req.Lines, userLogin.Id)
+	if err != nil {
+		return basic.ReturnConflict(w, err, conflictErrors...)
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) CancelReturnOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Id int64 `json:"id"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.CancelReturnOrder(ctx, req.Id)
+	if err != nil {
+		return basic.ReturnConflict(w, err, conflictErrors...)
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) ViewCustomerCreditHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	customerId, err := uuid.Parse(query.Get("customerId"))
+	if err != nil {
+		return err
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	count, credits, balances, err := root.repo.ViewCustomerCredit(ctx,
+		customerId, page, pageSize)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		CreditCount int              `json:"creditCount"`
+		CreditList  []CustomerCredit `json:"creditList"`
+		BalanceList []CreditBalance  `json:"balanceList"`
+	}
+
+	res := Response{
+		CreditCount: count,
+		CreditList:  credits,
+		BalanceList: balances,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
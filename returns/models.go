@@ -0,0 +1,80 @@
+package returns
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	OpenStatus              int16 = 1
+	PartiallyReceivedStatus int16 = 2
+	ReceivedStatus          int16 = 3
+	CanceledStatus          int16 = 4
+)
+
+type ClientItem struct {
+	SaleOrderSeq int             `json:"saleOrderSeq"`
+	Quantity     decimal.Decimal `json:"quantity"`
+}
+
+type ReturnOrder struct {
+	Id          int64     `json:"id" db:"id"`
+	SaleOrderId int64     `json:"saleOrderId" db:"sale_order_id"`
+	CustomerId  uuid.UUID `json:"customerId" db:"customer_id"`
+	StatusId    int16     `json:"statusId" db:"return_order_status_id"`
+	Reason      string    `json:"reason" db:"reason"`
+	CreatedBy   uuid.UUID `json:"createdBy" db:"created_by_user_login_id"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type ClientReturnOrder struct {
+	Id          int64     `json:"id" db:"id"`
+	SaleOrderId int64     `json:"saleOrderId" db:"sale_order_id"`
+	CustomerId  uuid.UUID `json:"customerId" db:"customer_id"`
+	Customer    string    `json:"customer" db:"customer"`
+	StatusId    int16     `json:"statusId" db:"return_order_status_id"`
+	Reason      string    `json:"reason" db:"reason"`
+	CreatedBy   string    `json:"createdBy" db:"created_by"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type ReturnOrderItem struct {
+	Seq              int16           `json:"seq" db:"return_order_seq"`
+	SaleOrderId      int64           `json:"saleOrderId" db:"sale_order_id"`
+	SaleOrderSeq     int             `json:"saleOrderSeq" db:"sale_order_seq"`
+	ProductId        int64           `json:"productId" db:"product_id"`
+	ProductName      string          `json:"productName" db:"product_name"`
+	Quantity         decimal.Decimal `json:"quantity" db:"quantity"`
+	ReceivedQuantity decimal.Decimal `json:"receivedQuantity" db:"received_quantity"`
+	UnitCredit       decimal.Decimal `json:"unitCredit" db:"unit_credit"`
+	CurrencyUomId    string          `json:"currencyUomId" db:"currency_uom_id"`
+}
+
+// ReceiptLine brings back quantity of a return item. Restore puts it
+// back into the inventory item it was exported from, otherwise it comes
+// in as a new item, into LocationId when given, which may be a
+// quarantine bin.
+type ReceiptLine struct {
+	Seq        int16           `json:"seq"`
+	Quantity   decimal.Decimal `json:"quantity"`
+	Restore    bool            `json:"restore"`
+	LocationId *int            `json:"locationId"`
+}
+
+type CustomerCredit struct {
+	Id            int64           `json:"id" db:"id"`
+	CustomerId    uuid.UUID       `json:"customerId" db:"customer_id"`
+	ReturnOrderId *int64          `json:"returnOrderId" db:"return_order_id"`
+	Amount        decimal.Decimal `json:"amount" db:"amount"`
+	CurrencyUomId string          `json:"currencyUomId" db:"currency_uom_id"`
+	CreatedAt     time.Time       `json:"createdAt" db:"created_at"`
+}
+
+type CreditBalance struct {
+	CurrencyUomId string          `json:"currencyUomId" db:"currency_uom_id"`
+	Amount        decimal.Decimal `json:"amount" db:"amount"`
+}
@@ -0,0 +1,703 @@
+package returns
+
+import (
+	"baseweb/basic"
+	importProduct "baseweb/import"
+	"baseweb/ledger"
+	"baseweb/location"
+	"baseweb/order"
+	"baseweb/tax"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func InitRepo(db *sqlx.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+var ErrReturnStatus = errors.New("return order status transition not allowed")
+
+var ErrNotReturnable = errors.New("sale order has not shipped")
+
+var ErrOverReturn = errors.New("returned quantity exceeds shipped quantity")
+
+var ErrOverReceipt = errors.New("received quantity exceeds returned quantity")
+
+var ErrRestore = errors.New("cannot restore into another warehouse or location")
+
+var ErrOverRestore = errors.New("restored quantity exceeds exported quantity")
+
+var returnStatusTransitions = map[int16][]int16{
+	OpenStatus:              {PartiallyReceivedStatus, ReceivedStatus, CanceledStatus},
+	PartiallyReceivedStatus: {PartiallyReceivedStatus, ReceivedStatus},
+}
+
+// InsertReturnOrder authorizes the return of items of a sale order
+// that shipped, up to what each item shipped less what earlier returns
+// already take back. Each item is credited at what the customer paid
+// for one unit of it.
+func (repo *Repo) InsertReturnOrder(
+	ctx context.Context,
+	saleOrderId int64, reason string,
+	items []ClientItem,
+	userLoginId uuid.UUID) (int64, error) {
+
+	log.Println("InsertReturnOrder", saleOrderId, reason, items)
+
+	var id int64
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return id, err
+	}
+	defer tx.Rollback()
+
+	type SaleOrder struct {
+		CustomerId uuid.UUID `db:"customer_id"`
+		StatusId   int16     `db:"sale_order_status_id"`
+	}
+	saleOrder := SaleOrder{}
+
+	// locking the sale order keeps two returns against it from both
+	// taking the same shipped quantity
+	query := repo.db.Rebind(`
+        select customer_id, sale_order_status_id
+        from sale_order where id = ?
+        for update
+        `)
+	err = tx.GetContext(ctx, &saleOrder, query, saleOrderId)
+	if err != nil {
+		return id, err
+	}
+
+	if saleOrder.StatusId != order.ShippingStatus &&
+		saleOrder.StatusId != order.CompletedStatus {
+		return id, ErrNotReturnable
+	}
+
+	query = repo.db.Rebind(`
+        insert into return_order(
+        sale_order_id, customer_id, return_order_status_id,
+        reason, created_by_user_login_id)
+        values (?, ?, ?, ?, ?) returning id
+        `)
+	err = tx.GetContext(ctx, &id, query,
+		saleOrderId, saleOrder.CustomerId, OpenStatus,
+		reason, userLoginId)
+	if err != nil {
+		return id, err
+	}
+
+	returnableQuery := repo.db.Rebind(`
+        select oi.shipped_quantity - coalesce((
+            select sum(ri.quantity)
+            from return_order_item ri
+                inner join return_order r on r.id = ri.return_order_id
+            where ri.sale_order_id = oi.sale_order_id
+                and ri.sale_order_seq = oi.sale_order_seq
+                and r.return_order_status_id <> ?
+        ), 0)
+        from sale_order_item oi
+        where oi.sale_order_id = ? and oi.sale_order_seq = ?
+            and oi.removed_at is null
+        `)
+
+	insertQuery := repo.db.Rebind(`
+        insert into return_order_item(
+        return_order_id, return_order_seq,
+        sale_order_id, sale_order_seq,
+        quantity, unit_credit, currency_uom_id)
+        values (?, ?, ?, ?, ?, ?, ?)
+        `)
+
+	for index, item := range items {
+		var returnable decimal.Decimal
+		err = tx.GetContext(ctx, &returnable, returnableQuery,
+			CanceledStatus, saleOrderId, item.SaleOrderSeq)
+		if err != nil {
+			return id, err
+		}
+		if item.Quantity.GreaterThan(returnable) {
+			return id, ErrOverReturn
+		}
+
+		unitCredit, currencyUomId, err := getUnitCredit(ctx, tx,
+			saleOrderId, item.SaleOrderSeq)
+		if err != nil {
+			return id, err
+		}
+
+		_, err = tx.ExecContext(ctx, insertQuery, id, index,
+			saleOrderId, item.SaleOrderSeq,
+			item.Quantity, unitCredit, currencyUomId)
+		if err != nil {
+			return id, err
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+// getUnitCredit is what one unit of a sale order item cost the
+// customer after its discount, its share of the order discount and with
+// tax. A backorder line carries no amounts of its own, so it is
+// credited like the line it came from.
+func getUnitCredit(
+	ctx context.Context, tx *sqlx.Tx,
+	saleOrderId int64, saleOrderSeq int) (decimal.Decimal, string, error) {
+
+	type Line struct {
+		Seq            int             `db:"sale_order_seq"`
+		Price          decimal.Decimal `db:"price"`
+		CurrencyUomId  string          `db:"currency_uom_id"`
+		Quantity       decimal.Decimal `db:"quantity"`
+		DiscountAmount decimal.Decimal `db:"discount_amount"`
+		TaxAmount      decimal.Decimal `db:"tax_amount"`
+		BackorderOfSeq *int            `db:"backorder_of_seq"`
+	}
+
+	var orderDiscount decimal.Decimal
+	query := tx.Rebind(`
+        select discount_amount from sale_order where id = ?
+        `)
+	err := tx.GetContext(ctx, &orderDiscount, query, saleOrderId)
+	if err != nil {
+		return decimal.Zero, "", err
+	}
+
+	lines := make([]Line, 0)
+	query = tx.Rebind(`
+        select oi.sale_order_seq, pp.price, pp.currency_uom_id,
+        oi.quantity, oi.discount_amount, oi.tax_amount,
+        oi.backorder_of_seq
+        from sale_order_item oi
+            inner join product_price pp on pp.id = oi.product_price_id
+        where oi.sale_order_id = ? and oi.removed_at is null
+        order by oi.sale_order_seq
+        `)
+	err = tx.SelectContext(ctx, &lines, query, saleOrderId)
+	if err != nil {
+		return decimal.Zero, "", err
+	}
+
+	bySeq := make(map[int]Line)
+	priced := make([]tax.Line, 0, len(lines))
+	for _, line := range lines {
+		bySeq[line.Seq] = line
+		if line.BackorderOfSeq == nil {
+			priced = append(priced, tax.Line{
+				Seq: line.Seq,
+				NetAmount: line.Price.Mul(line.Quantity).
+					Sub(line.DiscountAmount),
+			})
+		}
+	}
+	shares := tax.DiscountShares(priced, orderDiscount)
+
+	line, ok := bySeq[saleOrderSeq]
+	for ok && line.BackorderOfSeq != nil {
+		line, ok = bySeq[*line.BackorderOfSeq]
+	}
+	if !ok {
+		return decimal.Zero, "", sql.ErrNoRows
+	}
+
+	total := line.Price.Mul(line.Quantity).Sub(line.DiscountAmount).
+		Sub(shares[line.Seq]).Add(line.TaxAmount)
+	return total.DivRound(line.Quantity, 4), line.CurrencyUomId, nil
+}
+
+func (repo *Repo) ViewReturnOrder(
+	ctx context.Context,
+	customerId *uuid.UUID,
+	statusId int16,
+	page, pageSize int,
+	sortedBy, sortOrder string) (int, []ClientReturnOrder, error) {
+
+	log.Println("ViewReturnOrder", customerId, statusId,
+		page, pageSize, sortedBy, sortOrder)
+
+	var count int
+	result := make([]ClientReturnOrder, 0)
+
+	query := repo.db.Rebind(`
+        select count(*) from return_order
+        where (cast(? as uuid) is null or customer_id = ?)
+            and (? = 0 or return_order_status_id = ?)
+        `)
+	err := repo.db.GetContext(ctx, &count, query,
+		customerId, customerId, statusId, statusId)
+	if err != nil {
+		return count, result, err
+	}
+
+	query = `select r.id, r.sale_order_id,
+        r.customer_id, c.name as customer,
+        r.return_order_status_id, r.reason,
+        u.username as created_by,
+        r.created_at, r.updated_at
+        from return_order r
+            inner join customer c on c.id = r.customer_id
+            inner join user_login u on u.id = r.created_by_user_login_id
+        where (cast(? as uuid) is null or r.customer_id = ?)
+            and (? = 0 or r.return_order_status_id = ?)
+        order by r.%s %s
+        offset ? limit ?`
+	query = fmt.Sprintf(query, sortedBy, sortOrder)
+	query = repo.db.Rebind(query)
+	err = repo.db.SelectContext(ctx, &result, query,
+		customerId, customerId, statusId, statusId,
+		page*pageSize, pageSize)
+
+	return count, result, err
+}
+
+func (repo *Repo) GetReturnOrder(
+	ctx context.Context, id int64) (
+	ClientReturnOrder, []ReturnOrderItem, error) {
+
+	log.Println("GetReturnOrder", id)
+
+	returnOrder := ClientReturnOrder{}
+	items := make([]ReturnOrderItem, 0)
+
+	query := repo.db.Rebind(`
+        select r.id, r.sale_order_id,
+        r.customer_id, c.name as customer,
+        r.return_order_status_id, r.reason,
+        u.username as created_by,
+        r.created_at, r.updated_at
+        from return_order r
+            inner join customer c on c.id = r.customer_id
+            inner join user_login u on u.id = r.created_by_user_login_id
+        where r.id = ?
+        `)
+	err := repo.db.GetContext(ctx, &returnOrder, query, id)
+	if err != nil {
+		return returnOrder, items, err
+	}
+
+	query = repo.db.Rebind(`
+        select ri.return_order_seq, ri.sale_order_id, ri.sale_order_seq,
+        pp.product_id, p.name as product_name,
+        ri.quantity, ri.received_quantity,
+        ri.unit_credit, ri.currency_uom_id
+        from return_order_item ri
+            inner join sale_order_item oi
+                on oi.sale_order_id = ri.sale_order_id
+                    and oi.sale_order_seq = ri.sale_order_seq
+            inner join product_price pp on pp.id = oi.product_price_id
+            inner join product p on p.id = pp.product_id
+        where ri.return_order_id = ?
+        order by ri.return_order_seq
+        `)
+	err = repo.db.SelectContext(ctx, &items, query, id)
+
+	return returnOrder, items, err
+}
+
+func lockReturnOrder(
+	ctx context.Context, tx *sqlx.Tx,
+	id int64) (ReturnOrder, error) {
+
+	returnOrder := ReturnOrder{}
+	query := tx.Rebind(`
+        select id, sale_order_id, customer_id, return_order_status_id,
+        reason, created_by_user_login_id, created_at, updated_at
+        from return_order where id = ?
+        for update
+        `)
+	err := tx.GetContext(ctx, &returnOrder, query, id)
+
+	return returnOrder, err
+}
+
+func checkReturnStatus(statusId, nextStatusId int16) error {
+	for _, next := range returnStatusTransitions[statusId] {
+		if next == nextStatusId {
+			return nil
+		}
+	}
+	return ErrReturnStatus
+}
+
+// exportedItem is an inventory item a sale order item was exported
+// from, which returned goods are traced back to. Quantity is what can
+// still be restored into it.
+type exportedItem struct {
+	Id             int64            `db:"id"`
+	ProductId      int64            `db:"product_id"`
+	WarehouseId    uuid.UUID        `db:"warehouse_id"`
+	LocationId     *int             `db:"location_id"`
+	UnitCost       decimal.Decimal  `db:"unit_cost"`
+	CurrencyUomId  string           `db:"currency_uom_id"`
+	LotNumber      basic.NullString `db:"lot_number"`
+	ExpiryDate     basic.NullTime   `db:"expiry_date"`
+	ReturnOrderSeq int16            `db:"return_order_seq"`
+	Quantity       decimal.Decimal  `db:"quantity"`
+}
+
+// ReceiveReturnOrder takes returned goods back into warehouseId and
+// credits the customer with what they were sold for, one credit per
+// currency.
+func (repo *Repo) ReceiveReturnOrder(
+	ctx context.Context, id int64,
+	warehouseId uuid.UUID, lines []ReceiptLine,
+	userLoginId uuid.UUID) error {
+
+	log.Println("ReceiveReturnOrder", id, warehouseId, lines)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	returnOrder, err := lockReturnOrder(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	// both receiving states accept a partial receipt, so this only
+	// rules out returns that are already received or canceled
+	err = checkReturnStatus(returnOrder.StatusId, PartiallyReceivedStatus)
+	if err != nil {
+		return err
+	}
+
+	itemQuery := repo.db.Rebind(`
+        select return_order_seq, sale_order_id, sale_order_seq,
+        quantity, received_quantity, unit_credit, currency_uom_id
+        from return_order_item
+        where return_order_id = ? and return_order_seq = ?
+        for update
+        `)
+
+	exportedQuery := repo.db.Rebind(`
+        select i.id, i.product_id, i.warehouse_id, i.location_id,
+        i.unit_cost, i.currency_uom_id, i.lot_number, i.expiry_date
+        from inventory_item_detail d
+            inner join inventory_item i on i.id = d.inventory_item_id
+        where d.sale_order_id = ? and d.sale_order_seq = ?
+        order by d.effective_from desc, d.id desc
+        limit 1
+        `)
+
+	// what was restored into an item for the line already is taken off
+	// what was exported from it
+	restorableQuery := repo.db.Rebind(`
+        select i.id, i.product_id, i.warehouse_id, i.location_id,
+        i.unit_cost, i.currency_uom_id, i.lot_number, i.expiry_date,
+        sum(d.exported_quantity) - coalesce((
+            select sum(t.quantity_on_hand_diff)
+            from inventory_transaction t
+            where t.inventory_transaction_type_id = ?
+                and t.inventory_item_id = i.id
+                and t.sale_order_id = d.sale_order_id
+                and t.sale_order_seq = d.sale_order_seq
+        ), 0) as quantity
+        from inventory_item_detail d
+            inner join inventory_item i on i.id = d.inventory_item_id
+        where d.sale_order_id = ? and d.sale_order_seq = ?
+        group by i.id, d.sale_order_id, d.sale_order_seq
+        order by max(d.effective_from) desc, i.id desc
+        `)
+
+	updateQuery := repo.db.Rebind(`
+        update return_order_item set received_quantity = ?
+        where return_order_id = ? and return_order_seq = ?
+        `)
+
+	credits := make(map[string]decimal.Decimal)
+
+	for _, line := range lines {
+		item := ReturnOrderItem{}
+		err = tx.GetContext(ctx, &item, itemQuery, id, line.Seq)
+		if err != nil {
+			return err
+		}
+
+		received := item.ReceivedQuantity.Add(line.Quantity)
+		if received.GreaterThan(item.Quantity) {
+			return ErrOverReceipt
+		}
+
+		if line.Restore {
+			if line.LocationId != nil {
+				return ErrRestore
+			}
+			sources := make([]exportedItem, 0)
+			err = tx.SelectContext(ctx, &sources, restorableQuery,
+				ledger.ReturnRestoredType,
+				item.SaleOrderId, item.SaleOrderSeq)
+			if err != nil {
+				return err
+			}
+			err = restoreLine(ctx, tx, id, item, warehouseId,
+				sources, line.Quantity)
+		} else {
+			source := exportedItem{}
+			err = tx.GetContext(ctx, &source, exportedQuery,
+				item.SaleOrderId, item.SaleOrderSeq)
+			if err != nil {
+				return err
+			}
+			source.ReturnOrderSeq = line.Seq
+
+			_, err = importProduct.ReceiveInventoryItem(ctx, tx,
+				importProduct.InventoryItem{
+					ProductId:      source.ProductId,
+					WarehouseId:    warehouseId,
+					LocationId:     line.LocationId,
+					Quantity:       line.Quantity,
+					UnitCost:       source.UnitCost,
+					CurrencyUomId:  source.CurrencyUomId,
+					LotNumber:      source.LotNumber,
+					ExpiryDate:     source.ExpiryDate,
+					ReturnOrderId:  &id,
+					ReturnOrderSeq: &source.ReturnOrderSeq,
+				})
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, updateQuery, received, id, line.Seq)
+		if err != nil {
+			return err
+		}
+
+		credits[item.CurrencyUomId] = credits[item.CurrencyUomId].
+			Add(item.UnitCredit.Mul(line.Quantity))
+	}
+
+	currencies := make([]string, 0, len(credits))
+	for currencyUomId := range credits {
+		currencies = append(currencies, currencyUomId)
+	}
+	sort.Strings(currencies)
+
+	query := repo.db.Rebind(`
+        insert into customer_credit(
+        customer_id, return_order_id, amount, currency_uom_id,
+        created_by_user_login_id)
+        values (?, ?, ?, ?, ?)
+        `)
+	for _, currencyUomId := range currencies {
+		amount := credits[currencyUomId].Round(2)
+		if amount.IsZero() {
+			continue
+		}
+		_, err = tx.ExecContext(ctx, query, returnOrder.CustomerId,
+			id, amount, currencyUomId, userLoginId)
+		if err != nil {
+			return err
+		}
+	}
+
+	var openCount int
+	query = repo.db.Rebind(`
+        select count(*) from return_order_item
+        where return_order_id = ? and received_quantity < quantity
+        `)
+	err = tx.GetContext(ctx, &openCount, query, id)
+	if err != nil {
+		return err
+	}
+
+	statusId := PartiallyReceivedStatus
+	if openCount == 0 {
+		statusId = ReceivedStatus
+	}
+
+	query = repo.db.Rebind(`
+        update return_order
+        set return_order_status_id = ?
+        where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, statusId, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// restoreLine puts quantity of a return item back into the inventory
+// items its sale order item was exported from, latest export first, no
+// item taking back more than was exported from it.
+func restoreLine(
+	ctx context.Context, tx *sqlx.Tx,
+	returnOrderId int64, item ReturnOrderItem,
+	warehouseId uuid.UUID, sources []exportedItem,
+	quantity decimal.Decimal) error {
+
+	remaining := quantity
+	for _, source := range sources {
+		if !remaining.IsPositive() {
+			break
+		}
+		if !source.Quantity.IsPositive() {
+			continue
+		}
+		if source.WarehouseId != warehouseId {
+			return ErrRestore
+		}
+
+		restored := decimal.Min(remaining, source.Quantity)
+		err := restoreItem(ctx, tx, returnOrderId, item, source, restored)
+		if err != nil {
+			return err
+		}
+		remaining = remaining.Sub(restored)
+	}
+
+	if remaining.IsPositive() {
+		return ErrOverRestore
+	}
+	return nil
+}
+
+// restoreItem puts returned goods back on hand in the inventory item
+// they were exported from, where they count as available again unless
+// the item sits in quarantine.
+func restoreItem(
+	ctx context.Context, tx *sqlx.Tx,
+	returnOrderId int64, item ReturnOrderItem,
+	source exportedItem, quantity decimal.Decimal) error {
+
+	log.Println("restoreItem", returnOrderId, source.Id, quantity)
+
+	quarantine, err := location.IsQuarantine(ctx, tx, source.LocationId)
+	if err != nil {
+		return err
+	}
+	available := quantity
+	if quarantine {
+		available = decimal.Zero
+	}
+
+	query := tx.Rebind(`
+        update inventory_item
+        set quantity_on_hand = quantity_on_hand + ?
+        where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, quantity, source.Id)
+	if err != nil {
+		return err
+	}
+
+	query = tx.Rebind(`
+        update warehouse_product_statistics
+        set quantity_on_hand = quantity_on_hand + ?,
+            quantity_available = quantity_available + ?
+        where warehouse_id = ? and product_id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, quantity, available,
+		source.WarehouseId, source.ProductId)
+	if err != nil {
+		return err
+	}
+
+	return ledger.Record(ctx, tx, ledger.Transaction{
+		TypeId:                ledger.ReturnRestoredType,
+		WarehouseId:           source.WarehouseId,
+		ProductId:             source.ProductId,
+		InventoryItemId:       &source.Id,
+		QuantityOnHandDiff:    quantity,
+		QuantityAvailableDiff: available,
+		SaleOrderId:           &item.SaleOrderId,
+		SaleOrderSeq:          &item.SaleOrderSeq,
+	})
+}
+
+func (repo *Repo) CancelReturnOrder(ctx context.Context, id int64) error {
+	log.Println("CancelReturnOrder", id)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	returnOrder, err := lockReturnOrder(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	err = checkReturnStatus(returnOrder.StatusId, CanceledStatus)
+	if err != nil {
+		return err
+	}
+
+	query := repo.db.Rebind(`
+        update return_order
+        set return_order_status_id = ?
+        where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, CanceledStatus, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *Repo) ViewCustomerCredit(
+	ctx context.Context,
+	customerId uuid.UUID,
+	page, pageSize int) (int, []CustomerCredit, []CreditBalance, error) {
+
+	log.Println("ViewCustomerCredit", customerId, page, pageSize)
+
+	var count int
+	result := make([]CustomerCredit, 0)
+	balances := make([]CreditBalance, 0)
+
+	query := repo.db.Rebind(`
+        select count(*) from customer_credit where customer_id = ?
+        `)
+	err := repo.db.GetContext(ctx, &count, query, customerId)
+	if err != nil {
+		return count, result, balances, err
+	}
+
+	query = repo.db.Rebind(`
+        select id, customer_id, return_order_id,
+        amount, currency_uom_id, created_at
+        from customer_credit
+        where customer_id = ?
+        order by created_at desc, id desc
+        offset ? limit ?
+        `)
+	err = repo.db.SelectContext(ctx, &result, query,
+		customerId, page*pageSize, pageSize)
+	if err != nil {
+		return count, result, balances, err
+	}
+
+	query = repo.db.Rebind(`
+        select currency_uom_id, sum(amount) as amount
+        from customer_credit
+        where customer_id = ?
+        group by currency_uom_id
+        order by currency_uom_id
+        `)
+	err = repo.db.SelectContext(ctx, &balances, query, customerId)
+
+	return count, result, balances, err
+}
@@ -11,7 +11,7 @@ func SalesmanRoutes(root *Root) {
 		"SALESMAN_CHECKIN",
 		root.salesman.ViewCheckinHistoryHandler)
 
-	root.PostAuthorized(
+	root.PostIdempotent(
 		"/api/salesman/add-checkin",
 		"SALESMAN_CHECKIN",
 		root.salesman.AddCheckinHandler)
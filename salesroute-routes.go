@@ -1,12 +1,12 @@
 package main
 
 func SalesrouteRoutes(root *Root) {
-	root.PostAuthorized(
+	root.PostIdempotent(
 		"/api/sales-route/add-salesman",
 		"VIEW_EDIT_SALESMAN",
 		root.salesroute.AddSalesmanHandler)
 
-	root.PostAuthorized(
+	root.PostIdempotent(
 		"/api/sales-route/add-planning-period",
 		"VIEW_EDIT_SALESMAN",
 		root.salesroute.AddPlanningPeriodHandler)
@@ -41,7 +41,7 @@ func SalesrouteRoutes(root *Root) {
 		"VIEW_EDIT_SALESMAN",
 		root.salesroute.GetPlanningPeriodHandler)
 
-	root.PostAuthorized(
+	root.PostIdempotent(
 		"/api/sales-route/add-salesroute-config",
 		"VIEW_EDIT_SALESMAN",
 		root.salesroute.AddConfigHandler)
@@ -56,7 +56,7 @@ func SalesrouteRoutes(root *Root) {
 		"VIEW_EDIT_SALESMAN",
 		root.salesroute.DeleteConfigHandler)
 
-	root.PostAuthorized(
+	root.PostIdempotent(
 		"/api/schedule/add-schedule",
 		"VIEW_EDIT_SALESMAN",
 		root.salesroute.AddScheduleHandler)
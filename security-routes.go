@@ -13,7 +13,7 @@ func SecurityRoutes(root *Root) {
 		"VIEW_EDIT_SECURITY_PERMISSION",
 		root.security.SaveGroupPermissonsHandler)
 
-	root.PostAuthorized(
+	root.PostIdempotent(
 		"/api/security/add-security-group",
 		"VIEW_EDIT_SECURITY_GROUP",
 		root.security.AddSecurityGroupHandler)
@@ -0,0 +1,33 @@
+package main
+
+func StandingorderRoutes(root *Root) {
+	root.PostIdempotent(
+		"/api/standing-order/add-standing-order",
+		"VIEW_EDIT_ORDER",
+		root.standingorder.AddStandingOrderHandler)
+
+	root.GetAuthorized(
+		"/api/standing-order/view-standing-order",
+		"VIEW_EDIT_ORDER",
+		root.standingorder.ViewStandingOrderHandler)
+
+	root.GetAuthorized(
+		"/api/standing-order/view-single-standing-order",
+		"VIEW_EDIT_ORDER",
+		root.standingorder.ViewSingleStandingOrderHandler)
+
+	root.PostAuthorized(
+		"/api/standing-order/update-standing-order-enabled",
+		"VIEW_EDIT_ORDER",
+		root.standingorder.UpdateStandingOrderEnabledHandler)
+
+	root.GetAuthorized(
+		"/api/standing-order/view-upcoming-order",
+		"VIEW_EDIT_ORDER",
+		root.standingorder.ViewUpcomingOrderHandler)
+
+	root.PostAuthorized(
+		"/api/standing-order/skip-occurrence",
+		"VIEW_EDIT_ORDER",
+		root.standingorder.SkipOccurrenceHandler)
+}
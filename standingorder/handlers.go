@@ -0,0 +1,310 @@
+package standingorder
+
+import (
+	"baseweb/basic"
+	"baseweb/security"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Root struct {
+	repo *Repo
+}
+
+func InitRoot(repo *Repo) *Root {
+	return &Root{
+		repo: repo,
+	}
+}
+
+var bodyError = errors.New("body constraints violation")
+
+var queryError = errors.New("query constraints violation")
+
+// conflictErrors are the errors that come from the state of the
+// standing order rather than from a failure.
+var conflictErrors = []error{
+	ErrNotOccurrence,
+	ErrPastOccurrence,
+	ErrOccurrenceTaken,
+}
+
+func parseCustomerId(value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (root *Root) AddStandingOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+	type Request struct {
+		CustomerId      uuid.UUID       `json:"customerId"`
+		WarehouseId     uuid.UUID       `json:"warehouseId"`
+		Address         string          `json:"address"`
+		CustomerStoreId *uuid.UUID      `json:"customerStoreId"`
+		RepeatWeek      int             `json:"repeatWeek"`
+		DayList         []int16         `json:"dayList"`
+		StartDate       string          `json:"startDate"`
+		EndDate         string          `json:"endDate"`
+		Products        []ClientProduct `json:"products"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	if req.RepeatWeek < 1 || len(req.DayList) == 0 || len(req.Products) == 0 {
+		return bodyError
+	}
+	seen := make(map[int16]bool)
+	for _, d := range req.DayList {
+		if d < 0 || d > 6 || seen[d] {
+			return bodyError
+		}
+		seen[d] = true
+	}
+	for _, p := range req.Products {
+		if !p.Quantity.IsPositive() {
+			return bodyError
+		}
+	}
+
+	startDate, err := parseDate(req.StartDate, day(time.Now()))
+	if err != nil {
+		return err
+	}
+
+	var endDate *time.Time
+	if req.EndDate != "" {
+		d, err := parseDate(req.EndDate, startDate)
+		if err != nil {
+			return err
+		}
+		if d.Before(startDate) {
+			return bodyError
+		}
+		endDate = &d
+	}
+
+	id, err := root.repo.InsertStandingOrder(ctx,
+		req.CustomerId, req.WarehouseId, req.Address, req.CustomerStoreId,
+		req.RepeatWeek, req.DayList, startDate, endDate,
+		req.Products, userLogin.Id)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		Id int `json:"id"`
+	}
+
+	res := Response{
+		Id: id,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewStandingOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	customerId, err := parseCustomerId(query.Get("customerId"))
+	if err != nil {
+		return err
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	count, standingOrders, err := root.repo.ViewStandingOrder(ctx,
+		customerId, page, pageSize)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		StandingOrderCount int                   `json:"standingOrderCount"`
+		StandingOrderList  []ClientStandingOrder `json:"standingOrderList"`
+	}
+
+	res := Response{
+		StandingOrderCount: count,
+		StandingOrderList:  standingOrders,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewSingleStandingOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	id, err := strconv.Atoi(query.Get("id"))
+	if err != nil {
+		return err
+	}
+
+	standingOrder, dayList, items, occurrenceList, err :=
+		root.repo.GetStandingOrder(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		StandingOrder  ClientStandingOrder `json:"standingOrder"`
+		DayList        []int16             `json:"dayList"`
+		Items          []StandingOrderItem `json:"items"`
+		OccurrenceList []Occurrence        `json:"occurrenceList"`
+	}
+
+	res := Response{
+		StandingOrder:  standingOrder,
+		DayList:        dayList,
+		Items:          items,
+		OccurrenceList: occurrenceList,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) UpdateStandingOrderEnabledHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Id      int  `json:"id"`
+		Enabled bool `json:"enabled"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.UpdateStandingOrderEnabled(ctx,
+		req.Id, req.Enabled, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) ViewUpcomingOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	var standingOrderId *int
+	if query.Get("standingOrderId") != "" {
+		id, err := strconv.Atoi(query.Get("standingOrderId"))
+		if err != nil {
+			return err
+		}
+		standingOrderId = &id
+	}
+
+	customerId, err := parseCustomerId(query.Get("customerId"))
+	if err != nil {
+		return err
+	}
+
+	fromDate, err := parseDate(query.Get("fromDate"), day(time.Now()))
+	if err != nil {
+		return err
+	}
+
+	thruDate, err := parseDate(query.Get("thruDate"),
+		fromDate.AddDate(0, 0, PREVIEW_DAYS))
+	if err != nil {
+		return err
+	}
+	if thruDate.Before(fromDate) {
+		return queryError
+	}
+
+	occurrenceList, err := root.repo.PreviewOccurrence(ctx,
+		standingOrderId, customerId, fromDate, thruDate)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		FromDate       time.Time    `json:"fromDate"`
+		ThruDate       time.Time    `json:"thruDate"`
+		OccurrenceList []Occurrence `json:"occurrenceList"`
+	}
+
+	res := Response{
+		FromDate:       fromDate,
+		ThruDate:       thruDate,
+		OccurrenceList: occurrenceList,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) SkipOccurrenceHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+	type Request struct {
+		Id   int    `json:"id"`
+		Date string `json:"date"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	if req.Date == "" {
+		return bodyError
+	}
+	date, err := parseDate(req.Date, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.SkipOccurrence(ctx, req.Id, date,
+		userLogin.Id, time.Now())
+	if err != nil {
+		return basic.ReturnConflict(w, err, conflictErrors...)
+	}
+
+	return basic.ReturnOk(w)
+}
@@ -0,0 +1,70 @@
+package standingorder
+
+import (
+	"baseweb/basic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type StandingOrder struct {
+	Id               int            `json:"id" db:"id"`
+	CustomerId       uuid.UUID      `json:"customerId" db:"customer_id"`
+	WarehouseId      uuid.UUID      `json:"warehouseId" db:"warehouse_id"`
+	Address          string         `json:"address" db:"ship_to_address"`
+	CustomerStoreId  *uuid.UUID     `json:"customerStoreId" db:"ship_to_facility_customer_id"`
+	RepeatWeek       int            `json:"repeatWeek" db:"repeat_week"`
+	StartDate        time.Time      `json:"startDate" db:"start_date"`
+	EndDate          basic.NullTime `json:"endDate" db:"end_date"`
+	Enabled          bool           `json:"enabled" db:"is_enabled"`
+	GeneratedThrough basic.NullTime `json:"generatedThrough" db:"generated_through"`
+	CreatedBy        uuid.UUID      `json:"createdBy" db:"created_by_user_login_id"`
+	CreatedAt        time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt        time.Time      `json:"updatedAt" db:"updated_at"`
+}
+
+type ClientStandingOrder struct {
+	Id               int            `json:"id" db:"id"`
+	CustomerId       uuid.UUID      `json:"customerId" db:"customer_id"`
+	Customer         string         `json:"customer" db:"customer"`
+	WarehouseId      uuid.UUID      `json:"warehouseId" db:"warehouse_id"`
+	Warehouse        string         `json:"warehouse" db:"warehouse"`
+	Address          string         `json:"address" db:"ship_to_address"`
+	CustomerStoreId  *uuid.UUID     `json:"customerStoreId" db:"ship_to_facility_customer_id"`
+	CustomerStore    string         `json:"customerStore" db:"customer_store"`
+	RepeatWeek       int            `json:"repeatWeek" db:"repeat_week"`
+	StartDate        time.Time      `json:"startDate" db:"start_date"`
+	EndDate          basic.NullTime `json:"endDate" db:"end_date"`
+	Enabled          bool           `json:"enabled" db:"is_enabled"`
+	GeneratedThrough basic.NullTime `json:"generatedThrough" db:"generated_through"`
+	CreatedBy        string         `json:"createdBy" db:"created_by"`
+	CreatedAt        time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt        time.Time      `json:"updatedAt" db:"updated_at"`
+}
+
+type ClientProduct struct {
+	Id       int64           `json:"id"`
+	Quantity decimal.Decimal `json:"quantity"`
+}
+
+type StandingOrderItem struct {
+	Seq         int16           `json:"seq" db:"standing_order_seq"`
+	ProductId   int64           `json:"productId" db:"product_id"`
+	ProductName string          `json:"productName" db:"product_name"`
+	Quantity    decimal.Decimal `json:"quantity" db:"quantity"`
+}
+
+// Occurrence is one day a standing order places an order. It is only
+// stored once it was generated, failed or skipped, a planned one has
+// none of those set.
+type Occurrence struct {
+	StandingOrderId int              `json:"standingOrderId" db:"standing_order_id"`
+	Customer        string           `json:"customer" db:"-"`
+	CustomerStore   string           `json:"customerStore" db:"-"`
+	Date            time.Time        `json:"date" db:"occurrence_date"`
+	SaleOrderId     *int64           `json:"saleOrderId" db:"sale_order_id"`
+	Failure         basic.NullString `json:"failure" db:"failure"`
+	SkippedAt       basic.NullTime   `json:"skippedAt" db:"skipped_at"`
+	CreatedAt       basic.NullTime   `json:"createdAt" db:"created_at"`
+}
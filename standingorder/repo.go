@@ -0,0 +1,557 @@
+package standingorder
+
+import (
+	"baseweb/order"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repo struct {
+	db        *sqlx.DB
+	orderRepo *order.Repo
+}
+
+func InitRepo(db *sqlx.DB) *Repo {
+	return &Repo{
+		db:        db,
+		orderRepo: order.InitRepo(db),
+	}
+}
+
+var ErrNotOccurrence = errors.New("standing order places no order on this date")
+
+var ErrPastOccurrence = errors.New("occurrence is in the past")
+
+var ErrOccurrenceTaken = errors.New("occurrence was already generated")
+
+const standingOrderQuery = `select so.id,
+        so.customer_id, c.name as customer,
+        so.warehouse_id, f.name as warehouse,
+        so.ship_to_address, so.ship_to_facility_customer_id,
+        coalesce(fc.name, '') as customer_store,
+        so.repeat_week, so.start_date, so.end_date,
+        so.is_enabled, so.generated_through,
+        u.username as created_by,
+        so.created_at, so.updated_at
+        from standing_order so
+            inner join customer c on c.id = so.customer_id
+            inner join facility f on f.id = so.warehouse_id
+            left join facility fc on fc.id = so.ship_to_facility_customer_id
+            inner join user_login u on u.id = so.created_by_user_login_id`
+
+func (repo *Repo) InsertStandingOrder(
+	ctx context.Context,
+	customerId, warehouseId uuid.UUID,
+	address string, customerStoreId *uuid.UUID,
+	repeatWeek int, dayList []int16,
+	startDate time.Time, endDate *time.Time,
+	products []ClientProduct,
+	userLoginId uuid.UUID) (int, error) {
+
+	log.Println("InsertStandingOrder", customerId, warehouseId,
+		address, customerStoreId, repeatWeek, dayList,
+		startDate, endDate, products)
+
+	var id int
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return id, err
+	}
+	defer tx.Rollback()
+
+	query := repo.db.Rebind(`
+        insert into standing_order(
+        customer_id, warehouse_id,
+        ship_to_address, ship_to_facility_customer_id,
+        repeat_week, start_date, end_date,
+        created_by_user_login_id)
+        values (?, ?, ?, ?, ?, ?, ?, ?) returning id
+        `)
+	err = tx.GetContext(ctx, &id, query,
+		customerId, warehouseId, address, customerStoreId,
+		repeatWeek, startDate, endDate, userLoginId)
+	if err != nil {
+		return id, err
+	}
+
+	query = repo.db.Rebind(`
+        insert into standing_order_day(standing_order_id, day)
+        values (?, ?)
+        `)
+	for _, d := range dayList {
+		_, err = tx.ExecContext(ctx, query, id, d)
+		if err != nil {
+			return id, err
+		}
+	}
+
+	query = repo.db.Rebind(`
+        insert into standing_order_item(
+        standing_order_id, standing_order_seq, product_id, quantity)
+        values (?, ?, ?, ?)
+        `)
+	for index, p := range products {
+		_, err = tx.ExecContext(ctx, query, id, index, p.Id, p.Quantity)
+		if err != nil {
+			return id, err
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+func (repo *Repo) ViewStandingOrder(
+	ctx context.Context,
+	customerId *uuid.UUID,
+	page, pageSize int) (int, []ClientStandingOrder, error) {
+
+	log.Println("ViewStandingOrder", customerId, page, pageSize)
+
+	var count int
+	result := make([]ClientStandingOrder, 0)
+
+	query := repo.db.Rebind(`
+        select count(*) from standing_order
+        where (cast(? as uuid) is null or customer_id = ?)
+        `)
+	err := repo.db.GetContext(ctx, &count, query, customerId, customerId)
+	if err != nil {
+		return count, result, err
+	}
+
+	query = repo.db.Rebind(standingOrderQuery + `
+        where (cast(? as uuid) is null or so.customer_id = ?)
+        order by so.created_at desc
+        offset ? limit ?`)
+	err = repo.db.SelectContext(ctx, &result, query,
+		customerId, customerId, page*pageSize, pageSize)
+
+	return count, result, err
+}
+
+func (repo *Repo) selectDayList(
+	ctx context.Context, id int) ([]int16, error) {
+
+	dayList := make([]int16, 0)
+	query := repo.db.Rebind(`
+        select day from standing_order_day
+        where standing_order_id = ?
+        order by day
+        `)
+	err := repo.db.SelectContext(ctx, &dayList, query, id)
+
+	return dayList, err
+}
+
+func (repo *Repo) selectItem(
+	ctx context.Context, id int) ([]StandingOrderItem, error) {
+
+	items := make([]StandingOrderItem, 0)
+	query := repo.db.Rebind(`
+        select i.standing_order_seq, i.product_id,
+        p.name as product_name, i.quantity
+        from standing_order_item i
+            inner join product p on p.id = i.product_id
+        where i.standing_order_id = ?
+        order by i.standing_order_seq
+        `)
+	err := repo.db.SelectContext(ctx, &items, query, id)
+
+	return items, err
+}
+
+func (repo *Repo) selectOccurrence(
+	ctx context.Context, id int,
+	from, thru time.Time) (map[time.Time]Occurrence, error) {
+
+	rows := make([]Occurrence, 0)
+	query := repo.db.Rebind(`
+        select standing_order_id, occurrence_date,
+        sale_order_id, failure, skipped_at, created_at
+        from standing_order_occurrence
+        where standing_order_id = ?
+            and occurrence_date >= ? and occurrence_date <= ?
+        `)
+	err := repo.db.SelectContext(ctx, &rows, query, id, from, thru)
+
+	result := make(map[time.Time]Occurrence)
+	for _, o := range rows {
+		result[day(o.Date)] = o
+	}
+
+	return result, err
+}
+
+func (repo *Repo) GetStandingOrder(
+	ctx context.Context, id int) (
+	ClientStandingOrder, []int16, []StandingOrderItem, []Occurrence, error) {
+
+	log.Println("GetStandingOrder", id)
+
+	standingOrder := ClientStandingOrder{}
+	occurrenceList := make([]Occurrence, 0)
+
+	query := repo.db.Rebind(standingOrderQuery + `
+        where so.id = ?`)
+	err := repo.db.GetContext(ctx, &standingOrder, query, id)
+	if err != nil {
+		return standingOrder, nil, nil, occurrenceList, err
+	}
+
+	dayList, err := repo.selectDayList(ctx, id)
+	if err != nil {
+		return standingOrder, dayList, nil, occurrenceList, err
+	}
+
+	items, err := repo.selectItem(ctx, id)
+	if err != nil {
+		return standingOrder, dayList, items, occurrenceList, err
+	}
+
+	query = repo.db.Rebind(`
+        select standing_order_id, occurrence_date,
+        sale_order_id, failure, skipped_at, created_at
+        from standing_order_occurrence
+        where standing_order_id = ?
+        order by occurrence_date desc
+        limit ?
+        `)
+	err = repo.db.SelectContext(ctx, &occurrenceList, query,
+		id, RECENT_OCCURRENCE_COUNT)
+
+	return standingOrder, dayList, items, occurrenceList, err
+}
+
+// UpdateStandingOrderEnabled turns a standing order on or off. A standing
+// order turned back on picks up from today, the occurrences it missed
+// while off are not generated after the fact.
+func (repo *Repo) UpdateStandingOrderEnabled(
+	ctx context.Context, id int, enabled bool, now time.Time) error {
+
+	log.Println("UpdateStandingOrderEnabled", id, enabled)
+
+	if !enabled {
+		query := repo.db.Rebind(`
+            update standing_order set is_enabled = FALSE
+            where id = ?
+            `)
+		_, err := repo.db.ExecContext(ctx, query, id)
+		return err
+	}
+
+	query := repo.db.Rebind(`
+        update standing_order
+        set is_enabled = TRUE,
+            generated_through = greatest(generated_through, ?)
+        where id = ? and not is_enabled
+        `)
+	_, err := repo.db.ExecContext(ctx, query,
+		day(now).AddDate(0, 0, -1), id)
+
+	return err
+}
+
+// PreviewOccurrence lists the orders enabled standing orders place from
+// from through thru, with what became of those already generated or
+// skipped.
+func (repo *Repo) PreviewOccurrence(
+	ctx context.Context,
+	standingOrderId *int, customerId *uuid.UUID,
+	from, thru time.Time) ([]Occurrence, error) {
+
+	log.Println("PreviewOccurrence", standingOrderId, customerId, from, thru)
+
+	result := make([]Occurrence, 0)
+
+	standingOrders := make([]ClientStandingOrder, 0)
+	query := repo.db.Rebind(standingOrderQuery + `
+        where so.is_enabled
+            and (cast(? as integer) is null or so.id = ?)
+            and (cast(? as uuid) is null or so.customer_id = ?)
+        order by so.id`)
+	err := repo.db.SelectContext(ctx, &standingOrders, query,
+		standingOrderId, standingOrderId, customerId, customerId)
+	if err != nil {
+		return result, err
+	}
+
+	for _, s := range standingOrders {
+		dayList, err := repo.selectDayList(ctx, s.Id)
+		if err != nil {
+			return result, err
+		}
+
+		recorded, err := repo.selectOccurrence(ctx, s.Id, day(from), day(thru))
+		if err != nil {
+			return result, err
+		}
+
+		dates := occurrences(s.StartDate, s.EndDate, s.RepeatWeek,
+			dayList, from, thru)
+		for _, d := range dates {
+			o, ok := recorded[d]
+			if !ok {
+				o = Occurrence{
+					StandingOrderId: s.Id,
+					Date:            d,
+				}
+			}
+			o.Customer = s.Customer
+			o.CustomerStore = s.CustomerStore
+			result = append(result, o)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return day(result[i].Date).Before(day(result[j].Date))
+	})
+
+	return result, nil
+}
+
+// SkipOccurrence keeps a standing order from placing its order on date,
+// which must be one of its upcoming occurrences.
+func (repo *Repo) SkipOccurrence(
+	ctx context.Context, id int, date time.Time,
+	userLoginId uuid.UUID, now time.Time) error {
+
+	log.Println("SkipOccurrence", id, date)
+
+	date = day(date)
+	if date.Before(day(now)) {
+		return ErrPastOccurrence
+	}
+
+	standingOrder := StandingOrder{}
+	query := repo.db.Rebind(`
+        select id, customer_id, warehouse_id,
+        ship_to_address, ship_to_facility_customer_id,
+        repeat_week, start_date, end_date,
+        is_enabled, generated_through,
+        created_by_user_login_id, created_at, updated_at
+        from standing_order where id = ?
+        `)
+	err := repo.db.GetContext(ctx, &standingOrder, query, id)
+	if err != nil {
+		return err
+	}
+
+	dayList, err := repo.selectDayList(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	dates := occurrences(standingOrder.StartDate, standingOrder.EndDate,
+		standingOrder.RepeatWeek, dayList, date, date)
+	if len(dates) == 0 {
+		return ErrNotOccurrence
+	}
+
+	query = repo.db.Rebind(`
+        insert into standing_order_occurrence(
+        standing_order_id, occurrence_date,
+        skipped_at, skipped_by_user_login_id)
+        values (?, ?, ?, ?)
+        on conflict do nothing
+        `)
+	res, err := repo.db.ExecContext(ctx, query, id, date, now, userLoginId)
+	if err != nil {
+		return err
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if inserted > 0 {
+		return nil
+	}
+
+	// skipping twice is fine, skipping what was already generated is not
+	var skippedAt sql.NullTime
+	query = repo.db.Rebind(`
+        select skipped_at from standing_order_occurrence
+        where standing_order_id = ? and occurrence_date = ?
+        `)
+	err = repo.db.GetContext(ctx, &skippedAt, query, id, date)
+	if err != nil {
+		return err
+	}
+	if !skippedAt.Valid {
+		return ErrOccurrenceTaken
+	}
+	return nil
+}
+
+// GenerateOrder places the orders of every enabled standing order that
+// are due by now and were not generated or skipped yet, returning how
+// many it placed. An occurrence whose order cannot be placed is kept
+// with the reason instead, so it is not tried again on every run.
+func (repo *Repo) GenerateOrder(ctx context.Context, now time.Time) (int, error) {
+	log.Println("GenerateOrder", now)
+
+	count := 0
+	today := day(now)
+
+	standingOrders := make([]StandingOrder, 0)
+	query := repo.db.Rebind(`
+        select id, customer_id, warehouse_id,
+        ship_to_address, ship_to_facility_customer_id,
+        repeat_week, start_date, end_date,
+        is_enabled, generated_through,
+        created_by_user_login_id, created_at, updated_at
+        from standing_order
+        where is_enabled and start_date <= ?
+            and (generated_through is null or generated_through < ?)
+            and (end_date is null or generated_through is null
+                or generated_through < end_date)
+        order by id
+        `)
+	err := repo.db.SelectContext(ctx, &standingOrders, query, today, today)
+	if err != nil {
+		return count, err
+	}
+
+	updateQuery := repo.db.Rebind(`
+        update standing_order set generated_through = ?
+        where id = ?
+        `)
+
+	for _, s := range standingOrders {
+		// a standing order is not placed for the days before it was
+		// created, even when it starts earlier
+		from := day(s.CreatedAt.In(time.Local))
+		if s.GeneratedThrough.Valid {
+			from = day(s.GeneratedThrough.Time).AddDate(0, 0, 1)
+		}
+
+		dayList, err := repo.selectDayList(ctx, s.Id)
+		if err != nil {
+			return count, err
+		}
+
+		items, err := repo.selectItem(ctx, s.Id)
+		if err != nil {
+			return count, err
+		}
+
+		dates := occurrences(s.StartDate, s.EndDate, s.RepeatWeek,
+			dayList, from, today)
+		retry := false
+		for _, d := range dates {
+			placed, err := repo.generateOccurrence(ctx, s, items, d, now)
+			if err != nil {
+				if ctx.Err() != nil {
+					return count, err
+				}
+				// generated_through stays where it is, so the days
+				// from this one on are tried again next run
+				log.Println("[ERROR] GenerateOrder", s.Id, d, err)
+				retry = true
+				break
+			}
+			if placed {
+				count++
+			}
+		}
+		if retry {
+			continue
+		}
+
+		_, err = repo.db.ExecContext(ctx, updateQuery, today, s.Id)
+		if err != nil {
+			return count, err
+		}
+	}
+
+	return count, nil
+}
+
+// generateOccurrence places the order of s for date. The occurrence is
+// stored in the same transaction as the order, so it is placed once
+// however many times it is run.
+func (repo *Repo) generateOccurrence(
+	ctx context.Context, s StandingOrder,
+	items []StandingOrderItem, date, now time.Time) (bool, error) {
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query := repo.db.Rebind(`
+        insert into standing_order_occurrence(
+        standing_order_id, occurrence_date)
+        values (?, ?)
+        on conflict do nothing
+        `)
+	res, err := tx.ExecContext(ctx, query, s.Id, date)
+	if err != nil {
+		return false, err
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if inserted == 0 {
+		return false, nil
+	}
+
+	products := make([]order.ClientProduct, len(items))
+	for i, item := range items {
+		products[i] = order.ClientProduct{
+			Id:       int(item.ProductId),
+			Quantity: item.Quantity,
+		}
+	}
+
+	saleOrderId, err := repo.orderRepo.InsertOrder(ctx, tx,
+		s.CustomerId, s.WarehouseId, products,
+		s.Address, s.CustomerStoreId, s.CreatedBy, now)
+	if err != nil {
+		// anything else, a lost connection or a serialization
+		// failure, is left for the next run to retry
+		if !isFailure(err) {
+			return false, err
+		}
+		tx.Rollback()
+		return false, repo.recordFailure(ctx, s.Id, date, err)
+	}
+
+	query = repo.db.Rebind(`
+        update standing_order_occurrence set sale_order_id = ?
+        where standing_order_id = ? and occurrence_date = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, saleOrderId, s.Id, date)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (repo *Repo) recordFailure(
+	ctx context.Context, id int, date time.Time, failure error) error {
+
+	log.Println("recordFailure", id, date, failure)
+
+	query := repo.db.Rebind(`
+        insert into standing_order_occurrence(
+        standing_order_id, occurrence_date, failure)
+        values (?, ?, ?)
+        on conflict do nothing
+        `)
+	_, err := repo.db.ExecContext(ctx, query, id, date, failure.Error())
+
+	return err
+}
@@ -0,0 +1,105 @@
+package standingorder
+
+import (
+	"baseweb/basic"
+	"baseweb/order"
+	"baseweb/product"
+	"baseweb/reservation"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PREVIEW_DAYS is how far ahead upcoming orders are shown when no end
+// is given.
+const PREVIEW_DAYS = 28
+
+// RECENT_OCCURRENCE_COUNT is how many past occurrences are shown with a
+// standing order.
+const RECENT_OCCURRENCE_COUNT = 20
+
+// day is the calendar date of t, kept in UTC so that stepping through
+// days is not thrown off by daylight saving.
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// occurrences lists the days from from through thru on which a standing
+// order running from start to end places an order. Like sales route
+// configs, it repeats every repeatWeek weeks on the days of dayList,
+// counting weeks from the one it starts in.
+func occurrences(
+	start time.Time, end basic.NullTime, repeatWeek int,
+	dayList []int16, from, thru time.Time) []time.Time {
+
+	result := make([]time.Time, 0)
+
+	start = day(start)
+	weekStart := start.AddDate(0, 0, -int(start.Weekday()))
+
+	from = day(from)
+	if from.Before(start) {
+		from = start
+	}
+	thru = day(thru)
+	if end.Valid && day(end.Time).Before(thru) {
+		thru = day(end.Time)
+	}
+
+	onDay := make(map[time.Weekday]bool)
+	for _, d := range dayList {
+		onDay[time.Weekday(d)] = true
+	}
+
+	for d := from; !d.After(thru); d = d.AddDate(0, 0, 1) {
+		if !onDay[d.Weekday()] {
+			continue
+		}
+		week := int(d.Sub(weekStart).Hours()/24) / 7
+		if week%repeatWeek == 0 {
+			result = append(result, d)
+		}
+	}
+
+	return result
+}
+
+// isFailure tells whether placing an order failed for good, because of
+// the products or the stock rather than the database, so the
+// occurrence is recorded as failed instead of being tried again.
+func isFailure(err error) bool {
+	if errors.Is(err, order.ErrProductNotActive) ||
+		errors.Is(err, product.ErrNoPrice) ||
+		errors.Is(err, reservation.ErrNotStocked) {
+		return true
+	}
+	// class 23 is integrity constraint violation, which is how running
+	// out of available stock shows up
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.Class() == "23"
+}
+
+// parseDate reads a plain date, an empty value gives def.
+func parseDate(value string, def time.Time) (time.Time, error) {
+	if value == "" {
+		return def, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// RunJob turns due occurrences of standing orders into sale orders
+// every interval until ctx is done.
+func RunJob(ctx context.Context, repo *Repo, interval time.Duration) {
+	basic.RunEvery(ctx, interval, func() {
+		count, err := repo.GenerateOrder(ctx, time.Now())
+		if err != nil {
+			log.Println("[ERROR] standing order job", err)
+		} else if count > 0 {
+			log.Println("standing order job generated", count, "orders")
+		}
+	})
+}
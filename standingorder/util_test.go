@@ -0,0 +1,190 @@
+package standingorder
+
+import (
+	"baseweb/basic"
+	"baseweb/order"
+	"baseweb/product"
+	"baseweb/reservation"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func date(value string) time.Time {
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func endDate(value string) basic.NullTime {
+	end := basic.NullTime{}
+	end.Valid = true
+	end.Time = date(value)
+	return end
+}
+
+func TestOccurrences(t *testing.T) {
+	tests := []struct {
+		name       string
+		start      string
+		end        basic.NullTime
+		repeatWeek int
+		dayList    []int16
+		from, thru string
+		want       []string
+	}{
+		{
+			name:       "weekly",
+			start:      "2026-10-14",
+			repeatWeek: 1,
+			dayList:    []int16{1, 3},
+			from:       "2026-10-01",
+			thru:       "2026-10-28",
+			want: []string{"2026-10-14", "2026-10-19", "2026-10-21",
+				"2026-10-26", "2026-10-28"},
+		},
+		{
+			name:       "every other week counts from the start week",
+			start:      "2026-10-14",
+			repeatWeek: 2,
+			dayList:    []int16{1, 3},
+			from:       "2026-10-14",
+			thru:       "2026-11-07",
+			want:       []string{"2026-10-14", "2026-10-26", "2026-10-28"},
+		},
+		{
+			name:       "weeks start on sunday",
+			start:      "2026-10-17",
+			repeatWeek: 2,
+			dayList:    []int16{0, 6},
+			from:       "2026-10-17",
+			thru:       "2026-11-01",
+			want:       []string{"2026-10-17", "2026-10-25", "2026-10-31"},
+		},
+		{
+			name:       "every third week from a later window",
+			start:      "2026-10-05",
+			repeatWeek: 3,
+			dayList:    []int16{1},
+			from:       "2026-10-20",
+			thru:       "2026-12-31",
+			want:       []string{"2026-10-26", "2026-11-16", "2026-12-07", "2026-12-28"},
+		},
+		{
+			name:       "end date cuts the window",
+			start:      "2026-10-14",
+			end:        endDate("2026-10-21"),
+			repeatWeek: 1,
+			dayList:    []int16{1, 3},
+			from:       "2026-10-14",
+			thru:       "2026-10-28",
+			want:       []string{"2026-10-14", "2026-10-19", "2026-10-21"},
+		},
+		{
+			name:       "window before the start",
+			start:      "2026-10-14",
+			repeatWeek: 1,
+			dayList:    []int16{1, 3},
+			from:       "2026-10-01",
+			thru:       "2026-10-13",
+			want:       []string{},
+		},
+		{
+			name:       "window after the end",
+			start:      "2026-10-14",
+			end:        endDate("2026-10-21"),
+			repeatWeek: 1,
+			dayList:    []int16{1, 3},
+			from:       "2026-10-22",
+			thru:       "2026-10-31",
+			want:       []string{},
+		},
+		{
+			name:       "across the new year",
+			start:      "2026-12-23",
+			repeatWeek: 2,
+			dayList:    []int16{3},
+			from:       "2026-12-23",
+			thru:       "2027-01-31",
+			want:       []string{"2026-12-23", "2027-01-06", "2027-01-20"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dates := occurrences(date(tt.start), tt.end, tt.repeatWeek,
+				tt.dayList, date(tt.from), date(tt.thru))
+
+			got := make([]string, 0)
+			for _, d := range dates {
+				got = append(got, d.Format("2006-01-02"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOccurrencesLocalTime(t *testing.T) {
+	location := time.FixedZone("ICT", 7*60*60)
+
+	// late evening local time is still the local calendar day
+	start := time.Date(2026, 10, 14, 23, 30, 0, 0, location)
+	from := time.Date(2026, 10, 19, 22, 0, 0, 0, location)
+
+	dates := occurrences(start, basic.NullTime{}, 1, []int16{1},
+		from, from)
+	if len(dates) != 1 || !dates[0].Equal(date("2026-10-19")) {
+		t.Errorf("got %v", dates)
+	}
+}
+
+func TestIsFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"inactive product", order.ErrProductNotActive, true},
+		{"no price", fmt.Errorf("line 1: %w", product.ErrNoPrice), true},
+		{"not stocked", reservation.ErrNotStocked, true},
+		{"check violation", &pq.Error{Code: "23514"}, true},
+		{"foreign key violation", &pq.Error{Code: "23503"}, true},
+		{"serialization failure", &pq.Error{Code: "40001"}, false},
+		{"connection lost", errors.New("driver: bad connection"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isFailure(tt.err); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	def := date("2026-10-19")
+
+	got, err := parseDate("", def)
+	if err != nil || !got.Equal(def) {
+		t.Errorf("empty value gave %v, %v", got, err)
+	}
+
+	got, err = parseDate("2026-11-02", def)
+	if err != nil || !got.Equal(date("2026-11-02")) {
+		t.Errorf("got %v, %v", got, err)
+	}
+
+	_, err = parseDate("02/11/2026", def)
+	if err == nil {
+		t.Error("expected an error")
+	}
+}
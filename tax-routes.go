@@ -0,0 +1,18 @@
+package main
+
+func TaxRoutes(root *Root) {
+	root.GetAuthorized(
+		"/api/tax/view-tax-category",
+		"VIEW_EDIT_PRODUCT",
+		root.tax.ViewTaxCategoryHandler)
+
+	root.GetAuthorized(
+		"/api/tax/view-tax-rate",
+		"VIEW_EDIT_PRODUCT",
+		root.tax.ViewTaxRateHandler)
+
+	root.PostIdempotent(
+		"/api/tax/add-tax-rate",
+		"VIEW_EDIT_PRODUCT",
+		root.tax.AddTaxRateHandler)
+}
@@ -0,0 +1,126 @@
+package tax
+
+import (
+	"baseweb/basic"
+	"baseweb/security"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type Root struct {
+	repo *Repo
+}
+
+func InitRoot(repo *Repo) *Root {
+	return &Root{
+		repo: repo,
+	}
+}
+
+var bodyError = errors.New("body constraints violation")
+
+func (root *Root) ViewTaxCategoryHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	categories, err := root.repo.SelectTaxCategory(ctx)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		TaxCategoryList []Category `json:"taxCategoryList"`
+	}
+
+	res := Response{
+		TaxCategoryList: categories,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewTaxRateHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	categoryId, err := strconv.Atoi(query.Get("taxCategoryId"))
+	if err != nil {
+		return err
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	sortedBy := "effective_from"
+	sortedByQuery := query.Get("sortedBy")
+	if sortedByQuery == "createdAt" {
+		sortedBy = "created_at"
+	} else if sortedByQuery == "expiredAt" {
+		sortedBy = "expired_at"
+	}
+
+	sortOrder := "desc"
+	sortOrderQuery := query.Get("sortOrder")
+	if sortOrderQuery == "asc" {
+		sortOrder = "asc"
+	}
+
+	count, rates, err := root.repo.ViewTaxRate(ctx, int16(categoryId),
+		page, pageSize, sortedBy, sortOrder)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		RateCount int    `json:"rateCount"`
+		RateList  []Rate `json:"rateList"`
+	}
+
+	res := Response{
+		RateCount: count,
+		RateList:  rates,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) AddTaxRateHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+	rate := InsertionRate{}
+	err := json.NewDecoder(r.Body).Decode(&rate)
+	if err != nil {
+		return err
+	}
+	rate.CreatedBy = userLogin.Id
+	if rate.EffectiveFrom.IsZero() {
+		rate.EffectiveFrom = time.Now()
+	}
+
+	if rate.Rate.IsNegative() || rate.Rate.GreaterThan(hundred) {
+		return bodyError
+	}
+
+	err = root.repo.InsertTaxRate(ctx, rate)
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
@@ -0,0 +1,46 @@
+package tax
+
+import (
+	"baseweb/basic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type Category struct {
+	Id        int16     `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+type Rate struct {
+	Id            int             `json:"id" db:"id"`
+	CategoryId    int16           `json:"taxCategoryId" db:"tax_category_id"`
+	Rate          decimal.Decimal `json:"rate" db:"rate"`
+	CreatedBy     string          `json:"createdBy" db:"created_by"`
+	EffectiveFrom time.Time       `json:"effectiveFrom" db:"effective_from"`
+	ExpiredAt     basic.NullTime  `json:"expiredAt" db:"expired_at"`
+	CreatedAt     time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time       `json:"updatedAt" db:"updated_at"`
+}
+
+type InsertionRate struct {
+	CategoryId    int16           `json:"taxCategoryId" db:"tax_category_id"`
+	Rate          decimal.Decimal `json:"rate" db:"rate"`
+	CreatedBy     uuid.UUID       `json:"createdBy" db:"created_by_user_login_id"`
+	EffectiveFrom time.Time       `json:"effectiveFrom" db:"effective_from"`
+}
+
+type Line struct {
+	Seq       int
+	NetAmount decimal.Decimal
+	Rate      decimal.Decimal
+}
+
+type Totals struct {
+	LineTaxes   map[int]decimal.Decimal
+	NetAmount   decimal.Decimal
+	TaxAmount   decimal.Decimal
+	GrossAmount decimal.Decimal
+}
@@ -0,0 +1,143 @@
+package tax
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func InitRepo(db *sqlx.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+func (repo *Repo) SelectTaxCategory(
+	ctx context.Context) ([]Category, error) {
+
+	log.Println("SelectTaxCategory")
+
+	result := make([]Category, 0)
+	query := `select id, name, created_at from tax_category order by id`
+	err := repo.db.SelectContext(ctx, &result, query)
+	return result, err
+}
+
+func (repo *Repo) ViewTaxRate(
+	ctx context.Context, categoryId int16,
+	page, pageSize int,
+	sortedBy, sortOrder string) (int, []Rate, error) {
+
+	log.Println("ViewTaxRate", categoryId,
+		page, pageSize, sortedBy, sortOrder)
+
+	var count int
+	result := make([]Rate, 0)
+
+	query := repo.db.Rebind(
+		`select count(*) from tax_rate where tax_category_id = ?`)
+	err := repo.db.GetContext(ctx, &count, query, categoryId)
+	if err != nil {
+		return count, result, err
+	}
+
+	query = `select r.id, r.tax_category_id, r.rate,
+        u.username as created_by,
+        r.effective_from, r.expired_at,
+        r.created_at, r.updated_at
+        from tax_rate r
+            inner join user_login u on u.id = r.created_by_user_login_id
+        where r.tax_category_id = ?
+        order by r.%s %s
+        limit ? offset ?`
+	query = fmt.Sprintf(query, sortedBy, sortOrder)
+	query = repo.db.Rebind(query)
+	err = repo.db.SelectContext(ctx, &result, query,
+		categoryId, pageSize, page*pageSize)
+
+	return count, result, err
+}
+
+func (repo *Repo) InsertTaxRate(
+	ctx context.Context, rate InsertionRate) error {
+
+	log.Println("InsertTaxRate", rate.CategoryId, rate.Rate,
+		rate.EffectiveFrom, rate.CreatedBy)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `update tax_rate
+        set expired_at = ?
+        where (expired_at is null or ? < expired_at)
+            and tax_category_id = ?`
+	query = repo.db.Rebind(query)
+	_, err = tx.ExecContext(ctx, query, rate.EffectiveFrom,
+		rate.EffectiveFrom, rate.CategoryId)
+	if err != nil {
+		return err
+	}
+
+	query = `insert into tax_rate(
+        tax_category_id, rate,
+        created_by_user_login_id, effective_from)
+        values (:tax_category_id, :rate,
+            :created_by_user_login_id, :effective_from)`
+	_, err = tx.NamedExecContext(ctx, query, rate)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func SelectProductTaxRate(
+	ctx context.Context, tx *sqlx.Tx,
+	productIdList []int64,
+	now time.Time) (map[int64]decimal.Decimal, error) {
+
+	log.Println("SelectProductTaxRate", productIdList)
+
+	result := make(map[int64]decimal.Decimal)
+	if len(productIdList) == 0 {
+		return result, nil
+	}
+
+	type ProductRate struct {
+		ProductId int64           `db:"product_id"`
+		Rate      decimal.Decimal `db:"rate"`
+	}
+	rates := make([]ProductRate, 0)
+
+	query := `select p.id as product_id, coalesce(r.rate, 0) as rate
+        from product p
+            left join tax_rate r on r.tax_category_id = p.tax_category_id
+                and r.effective_from <= ?
+                and (r.expired_at is null or ? < r.expired_at)
+        where p.id in (?)`
+	query, args, err := sqlx.In(query, now, now, productIdList)
+	if err != nil {
+		return result, err
+	}
+
+	err = tx.SelectContext(ctx, &rates, tx.Rebind(query), args...)
+	if err != nil {
+		return result, err
+	}
+
+	for _, r := range rates {
+		result[r.ProductId] = r.Rate
+	}
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package tax
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// DiscountShares spreads the order level discount over the lines in
+// proportion to their net amount. Shares are rounded to cents and the
+// last line takes what rounding leaves, so they add up to the discount.
+func DiscountShares(
+	lines []Line, orderDiscount decimal.Decimal) map[int]decimal.Decimal {
+
+	shares := make(map[int]decimal.Decimal)
+
+	subtotal := decimal.Zero
+	for _, line := range lines {
+		subtotal = subtotal.Add(line.NetAmount)
+	}
+
+	remaining := orderDiscount
+	for i, line := range lines {
+		share := decimal.Zero
+		if i == len(lines)-1 {
+			share = remaining
+		} else if subtotal.IsPositive() {
+			share = orderDiscount.Mul(line.NetAmount).Div(subtotal).Round(2)
+		}
+		remaining = remaining.Sub(share)
+		shares[line.Seq] = share
+	}
+
+	return shares
+}
+
+// Compute taxes every line on its net amount less its share of the
+// order level discount, so it is taxed on what is really charged.
+func Compute(lines []Line, orderDiscount decimal.Decimal) Totals {
+	totals := Totals{
+		LineTaxes:   make(map[int]decimal.Decimal),
+		NetAmount:   decimal.Zero,
+		TaxAmount:   decimal.Zero,
+		GrossAmount: decimal.Zero,
+	}
+
+	shares := DiscountShares(lines, orderDiscount)
+
+	subtotal := decimal.Zero
+	for _, line := range lines {
+		subtotal = subtotal.Add(line.NetAmount)
+
+		taxable := line.NetAmount.Sub(shares[line.Seq])
+		lineTax := taxable.Mul(line.Rate).Div(hundred).Round(2)
+
+		totals.LineTaxes[line.Seq] = lineTax
+		totals.TaxAmount = totals.TaxAmount.Add(lineTax)
+	}
+
+	totals.NetAmount = subtotal.Sub(orderDiscount)
+	totals.GrossAmount = totals.NetAmount.Add(totals.TaxAmount)
+
+	return totals
+}
@@ -0,0 +1,121 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func dec(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name          string
+		lines         []Line
+		orderDiscount string
+		lineTaxes     map[int]string
+		net           string
+		tax           string
+		gross         string
+	}{
+		{
+			name:          "no lines",
+			orderDiscount: "0",
+			lineTaxes:     map[int]string{},
+			net:           "0",
+			tax:           "0",
+			gross:         "0",
+		},
+		{
+			name: "rates per line without discount",
+			lines: []Line{
+				{Seq: 1, NetAmount: dec("100"), Rate: dec("10")},
+				{Seq: 2, NetAmount: dec("200"), Rate: dec("5")},
+				{Seq: 3, NetAmount: dec("50"), Rate: dec("0")},
+			},
+			orderDiscount: "0",
+			lineTaxes:     map[int]string{1: "10", 2: "10", 3: "0"},
+			net:           "350",
+			tax:           "20",
+			gross:         "370",
+		},
+		{
+			name: "discount spread by net amount",
+			lines: []Line{
+				{Seq: 1, NetAmount: dec("100"), Rate: dec("10")},
+				{Seq: 2, NetAmount: dec("300"), Rate: dec("10")},
+			},
+			orderDiscount: "40",
+			lineTaxes:     map[int]string{1: "9", 2: "27"},
+			net:           "360",
+			tax:           "36",
+			gross:         "396",
+		},
+		{
+			name: "last line takes the rounding remainder",
+			lines: []Line{
+				{Seq: 1, NetAmount: dec("100"), Rate: dec("10")},
+				{Seq: 2, NetAmount: dec("100"), Rate: dec("10")},
+				{Seq: 3, NetAmount: dec("100"), Rate: dec("0")},
+			},
+			orderDiscount: "10",
+			// shares are 3.33, 3.33 and 3.34
+			lineTaxes: map[int]string{1: "9.67", 2: "9.67", 3: "0"},
+			net:       "290",
+			tax:       "19.34",
+			gross:     "309.34",
+		},
+		{
+			name: "zero subtotal",
+			lines: []Line{
+				{Seq: 1, NetAmount: dec("0"), Rate: dec("10")},
+				{Seq: 2, NetAmount: dec("0"), Rate: dec("10")},
+			},
+			orderDiscount: "0",
+			lineTaxes:     map[int]string{1: "0", 2: "0"},
+			net:           "0",
+			tax:           "0",
+			gross:         "0",
+		},
+		{
+			name: "line tax is rounded to cents",
+			lines: []Line{
+				{Seq: 1, NetAmount: dec("33.33"), Rate: dec("8")},
+			},
+			orderDiscount: "0",
+			lineTaxes:     map[int]string{1: "2.67"},
+			net:           "33.33",
+			tax:           "2.67",
+			gross:         "36",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			totals := Compute(tt.lines, dec(tt.orderDiscount))
+
+			if len(totals.LineTaxes) != len(tt.lineTaxes) {
+				t.Errorf("line taxes %v, want %v",
+					totals.LineTaxes, tt.lineTaxes)
+			}
+			for seq, want := range tt.lineTaxes {
+				got := totals.LineTaxes[seq]
+				if !got.Equal(dec(want)) {
+					t.Errorf("line %d tax %v, want %v", seq, got, want)
+				}
+			}
+
+			if !totals.NetAmount.Equal(dec(tt.net)) {
+				t.Errorf("net %v, want %v", totals.NetAmount, tt.net)
+			}
+			if !totals.TaxAmount.Equal(dec(tt.tax)) {
+				t.Errorf("tax %v, want %v", totals.TaxAmount, tt.tax)
+			}
+			if !totals.GrossAmount.Equal(dec(tt.gross)) {
+				t.Errorf("gross %v, want %v", totals.GrossAmount, tt.gross)
+			}
+		})
+	}
+}
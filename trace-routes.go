@@ -0,0 +1,13 @@
+package main
+
+func TraceRoutes(root *Root) {
+	root.GetAuthorized(
+		"/api/trace/trace-forward",
+		"VIEW_EDIT_ORDER",
+		root.trace.TraceForwardHandler)
+
+	root.GetAuthorized(
+		"/api/trace/trace-backward",
+		"VIEW_EDIT_ORDER",
+		root.trace.TraceBackwardHandler)
+}
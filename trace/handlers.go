@@ -0,0 +1,116 @@
+package trace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+type Root struct {
+	repo *Repo
+}
+
+func InitRoot(repo *Repo) *Root {
+	return &Root{
+		repo: repo,
+	}
+}
+
+var queryError = errors.New("query constraints violation")
+
+func (root *Root) TraceForwardHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	var inventoryItemId *int64
+	var productId *int64
+
+	if query.Get("inventoryItemId") != "" {
+		id, err := strconv.ParseInt(query.Get("inventoryItemId"), 10, 64)
+		if err != nil {
+			return err
+		}
+		inventoryItemId = &id
+	}
+
+	lotNumber := query.Get("lotNumber")
+	if lotNumber != "" {
+		id, err := strconv.ParseInt(query.Get("productId"), 10, 64)
+		if err != nil {
+			return err
+		}
+		productId = &id
+	}
+
+	if inventoryItemId == nil && productId == nil {
+		return queryError
+	}
+
+	shipments, err := root.repo.TraceForward(ctx,
+		inventoryItemId, productId, lotNumber)
+	if err != nil {
+		return err
+	}
+
+	if query.Get("format") == "csv" {
+		filename := "trace-forward.csv"
+		if lotNumber != "" {
+			filename = fmt.Sprintf("trace-forward-lot-%s.csv", lotNumber)
+		} else if inventoryItemId != nil {
+			filename = fmt.Sprintf("trace-forward-item-%d.csv", *inventoryItemId)
+		}
+		return writeShipmentCsv(w, filename, shipments)
+	}
+
+	type Response struct {
+		ShipmentList []Shipment `json:"shipmentList"`
+	}
+
+	res := Response{
+		ShipmentList: shipments,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) TraceBackwardHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	saleOrderId, err := strconv.ParseInt(query.Get("saleOrderId"), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	saleOrderSeq, err := strconv.Atoi(query.Get("saleOrderSeq"))
+	if err != nil {
+		return err
+	}
+
+	sources, err := root.repo.TraceBackward(ctx, saleOrderId, saleOrderSeq)
+	if err != nil {
+		return err
+	}
+
+	if query.Get("format") == "csv" {
+		filename := fmt.Sprintf("trace-backward-%d-%d.csv",
+			saleOrderId, saleOrderSeq)
+		return writeSourceCsv(w, filename, sources)
+	}
+
+	type Response struct {
+		SourceList []Source `json:"sourceList"`
+	}
+
+	res := Response{
+		SourceList: sources,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,47 @@
+package trace
+
+import (
+	"baseweb/basic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Shipment is one quantity of an inventory item that left
+// the warehouse on a sale order line.
+type Shipment struct {
+	InventoryItemId  int64            `json:"inventoryItemId" db:"inventory_item_id"`
+	ProductId        int64            `json:"productId" db:"product_id"`
+	ProductName      string           `json:"productName" db:"product_name"`
+	LotNumber        basic.NullString `json:"lotNumber" db:"lot_number"`
+	WarehouseId      uuid.UUID        `json:"warehouseId" db:"warehouse_id"`
+	WarehouseName    string           `json:"warehouseName" db:"warehouse_name"`
+	SaleOrderId      int64            `json:"saleOrderId" db:"sale_order_id"`
+	SaleOrderSeq     int              `json:"saleOrderSeq" db:"sale_order_seq"`
+	CustomerId       uuid.UUID        `json:"customerId" db:"customer_id"`
+	CustomerName     string           `json:"customerName" db:"customer_name"`
+	CustomerStoreId  *uuid.UUID       `json:"customerStoreId" db:"customer_store_id"`
+	CustomerStore    basic.NullString `json:"customerStoreName" db:"customer_store_name"`
+	ShipToAddress    string           `json:"shipToAddress" db:"ship_to_address"`
+	ExportedQuantity decimal.Decimal  `json:"exportedQuantity" db:"exported_quantity"`
+	EffectiveFrom    time.Time        `json:"effectiveFrom" db:"effective_from"`
+}
+
+// Source is one inventory item a sale order line was made of, along with
+// the item it was first received as when it came through transfers.
+type Source struct {
+	InventoryItemId       int64            `json:"inventoryItemId" db:"inventory_item_id"`
+	OriginInventoryItemId int64            `json:"originInventoryItemId" db:"origin_inventory_item_id"`
+	OriginWarehouseId     uuid.UUID        `json:"originWarehouseId" db:"origin_warehouse_id"`
+	OriginReceivedAt      time.Time        `json:"originReceivedAt" db:"origin_received_at"`
+	ProductId             int64            `json:"productId" db:"product_id"`
+	ProductName           string           `json:"productName" db:"product_name"`
+	LotNumber             basic.NullString `json:"lotNumber" db:"lot_number"`
+	ExpiryDate            basic.NullTime   `json:"expiryDate" db:"expiry_date"`
+	WarehouseId           uuid.UUID        `json:"warehouseId" db:"warehouse_id"`
+	UnitCost              decimal.Decimal  `json:"unitCost" db:"unit_cost"`
+	CurrencyUomId         string           `json:"currencyUomId" db:"currency_uom_id"`
+	ExportedQuantity      decimal.Decimal  `json:"exportedQuantity" db:"exported_quantity"`
+	EffectiveFrom         time.Time        `json:"effectiveFrom" db:"effective_from"`
+}
@@ -0,0 +1,112 @@
+package trace
+
+import (
+	"context"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func InitRepo(db *sqlx.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+// TraceForward follows an inventory item, or every item of a lot,
+// through the transfers it went on and returns where it was shipped to.
+func (repo *Repo) TraceForward(
+	ctx context.Context,
+	inventoryItemId *int64,
+	productId *int64, lotNumber string) ([]Shipment, error) {
+
+	log.Println("TraceForward", inventoryItemId, productId, lotNumber)
+
+	result := make([]Shipment, 0)
+
+	query := repo.db.Rebind(`
+        with recursive chain(id) as (
+            select id from inventory_item
+            where id = cast(? as bigint)
+                or (product_id = cast(? as integer) and lot_number = ?)
+            union
+            select d.received_inventory_item_id
+            from transfer_order_item_detail d
+                inner join chain on d.inventory_item_id = chain.id
+            where d.received_inventory_item_id is not null
+        )
+        select d.inventory_item_id,
+        i.product_id, p.name as product_name, i.lot_number,
+        i.warehouse_id, w.name as warehouse_name,
+        d.sale_order_id, d.sale_order_seq,
+        o.customer_id, c.name as customer_name,
+        o.ship_to_facility_customer_id as customer_store_id,
+        s.name as customer_store_name,
+        o.ship_to_address,
+        d.exported_quantity, d.effective_from
+        from chain
+            inner join inventory_item_detail d
+                on d.inventory_item_id = chain.id
+            inner join inventory_item i on i.id = d.inventory_item_id
+            inner join product p on p.id = i.product_id
+            inner join facility w on w.id = i.warehouse_id
+            inner join sale_order o on o.id = d.sale_order_id
+            inner join customer c on c.id = o.customer_id
+            left join facility s on s.id = o.ship_to_facility_customer_id
+        order by d.effective_from, d.id
+        `)
+	err := repo.db.SelectContext(ctx, &result, query,
+		inventoryItemId, productId, lotNumber)
+
+	return result, err
+}
+
+// TraceBackward returns the inventory items a sale order line was
+// exported from, each traced back through transfers to its receipt.
+func (repo *Repo) TraceBackward(
+	ctx context.Context,
+	saleOrderId int64, saleOrderSeq int) ([]Source, error) {
+
+	log.Println("TraceBackward", saleOrderId, saleOrderSeq)
+
+	result := make([]Source, 0)
+
+	query := repo.db.Rebind(`
+        with recursive origin(item_id, origin_id) as (
+            select distinct inventory_item_id, inventory_item_id
+            from inventory_item_detail
+            where sale_order_id = ? and sale_order_seq = ?
+            union
+            select o.item_id, t.inventory_item_id
+            from origin o
+                inner join transfer_order_item_detail t
+                    on t.received_inventory_item_id = o.origin_id
+        )
+        select d.inventory_item_id,
+        o.origin_id as origin_inventory_item_id,
+        oi.warehouse_id as origin_warehouse_id,
+        oi.created_at as origin_received_at,
+        i.product_id, p.name as product_name,
+        i.lot_number, i.expiry_date, i.warehouse_id,
+        i.unit_cost, i.currency_uom_id,
+        d.exported_quantity, d.effective_from
+        from inventory_item_detail d
+            inner join origin o on o.item_id = d.inventory_item_id
+            inner join inventory_item i on i.id = d.inventory_item_id
+            inner join inventory_item oi on oi.id = o.origin_id
+            inner join product p on p.id = i.product_id
+        where d.sale_order_id = ? and d.sale_order_seq = ?
+            and not exists (
+                select 1 from transfer_order_item_detail t
+                where t.received_inventory_item_id = o.origin_id)
+        order by d.id
+        `)
+	err := repo.db.SelectContext(ctx, &result, query,
+		saleOrderId, saleOrderSeq, saleOrderId, saleOrderSeq)
+
+	return result, err
+}
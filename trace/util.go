@@ -0,0 +1,120 @@
+package trace
+
+import (
+	"baseweb/basic"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func nullString(v basic.NullString) string {
+	if v.Valid {
+		return v.String
+	}
+	return ""
+}
+
+func nullTime(v basic.NullTime) string {
+	if v.Valid {
+		return v.Time.Format("2006-01-02")
+	}
+	return ""
+}
+
+func nullUuid(v *uuid.UUID) string {
+	if v != nil {
+		return v.String()
+	}
+	return ""
+}
+
+func writeCsv(
+	w http.ResponseWriter, filename string,
+	header []string, rows [][]string) error {
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=%s", strconv.Quote(filename)))
+
+	writer := csv.NewWriter(w)
+	err := writer.Write(header)
+	if err != nil {
+		return err
+	}
+	err = writer.WriteAll(rows)
+	if err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+func writeShipmentCsv(
+	w http.ResponseWriter, filename string, shipments []Shipment) error {
+
+	header := []string{
+		"inventory_item_id", "product_id", "product_name", "lot_number",
+		"warehouse", "sale_order_id", "sale_order_seq",
+		"customer_id", "customer_name",
+		"customer_store_id", "customer_store_name", "ship_to_address",
+		"exported_quantity", "effective_from",
+	}
+
+	rows := make([][]string, 0, len(shipments))
+	for _, s := range shipments {
+		rows = append(rows, []string{
+			strconv.FormatInt(s.InventoryItemId, 10),
+			strconv.FormatInt(s.ProductId, 10),
+			s.ProductName,
+			nullString(s.LotNumber),
+			s.WarehouseName,
+			strconv.FormatInt(s.SaleOrderId, 10),
+			strconv.Itoa(s.SaleOrderSeq),
+			s.CustomerId.String(),
+			s.CustomerName,
+			nullUuid(s.CustomerStoreId),
+			nullString(s.CustomerStore),
+			s.ShipToAddress,
+			s.ExportedQuantity.String(),
+			s.EffectiveFrom.Format(time.RFC3339),
+		})
+	}
+
+	return writeCsv(w, filename, header, rows)
+}
+
+func writeSourceCsv(
+	w http.ResponseWriter, filename string, sources []Source) error {
+
+	header := []string{
+		"inventory_item_id", "origin_inventory_item_id",
+		"origin_warehouse_id", "origin_received_at",
+		"product_id", "product_name", "lot_number", "expiry_date",
+		"warehouse_id", "unit_cost", "currency_uom_id",
+		"exported_quantity", "effective_from",
+	}
+
+	rows := make([][]string, 0, len(sources))
+	for _, s := range sources {
+		rows = append(rows, []string{
+			strconv.FormatInt(s.InventoryItemId, 10),
+			strconv.FormatInt(s.OriginInventoryItemId, 10),
+			s.OriginWarehouseId.String(),
+			s.OriginReceivedAt.Format(time.RFC3339),
+			strconv.FormatInt(s.ProductId, 10),
+			s.ProductName,
+			nullString(s.LotNumber),
+			nullTime(s.ExpiryDate),
+			s.WarehouseId.String(),
+			s.UnitCost.String(),
+			s.CurrencyUomId,
+			s.ExportedQuantity.String(),
+			s.EffectiveFrom.Format(time.RFC3339),
+		})
+	}
+
+	return writeCsv(w, filename, header, rows)
+}
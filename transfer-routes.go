@@ -0,0 +1,38 @@
+package main
+
+func TransferRoutes(root *Root) {
+	root.PostIdempotent(
+		"/api/transfer/add-transfer-order",
+		"VIEW_EDIT_FACILITY",
+		root.transfer.AddTransferOrderHandler)
+
+	root.GetAuthorized(
+		"/api/transfer/view-transfer-order",
+		"VIEW_EDIT_FACILITY",
+		root.transfer.ViewTransferOrderHandler)
+
+	root.GetAuthorized(
+		"/api/transfer/view-single-transfer-order",
+		"VIEW_EDIT_FACILITY",
+		root.transfer.ViewSingleTransferOrderHandler)
+
+	root.PostAuthorized(
+		"/api/transfer/ship-transfer-order",
+		"EXPORT",
+		root.transfer.ShipTransferOrderHandler)
+
+	root.PostAuthorized(
+		"/api/transfer/mark-in-transit",
+		"EXPORT",
+		root.transfer.MarkInTransitHandler)
+
+	root.PostAuthorized(
+		"/api/transfer/receive-transfer-order",
+		"IMPORT",
+		root.transfer.ReceiveTransferOrderHandler)
+
+	root.PostAuthorized(
+		"/api/transfer/cancel-transfer-order",
+		"VIEW_EDIT_FACILITY",
+		root.transfer.CancelTransferOrderHandler)
+}
@@ -0,0 +1,244 @@
+package transfer
+
+import (
+	"baseweb/basic"
+	"baseweb/security"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Root struct {
+	repo *Repo
+}
+
+func InitRoot(repo *Repo) *Root {
+	return &Root{
+		repo: repo,
+	}
+}
+
+var bodyError = errors.New("body constraints violation")
+
+func (root *Root) AddTransferOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	userLogin := ctx.Value("userLogin").(security.UserLogin)
+
+	type Request struct {
+		FromWarehouseId uuid.UUID       `json:"fromWarehouseId"`
+		ToWarehouseId   uuid.UUID       `json:"toWarehouseId"`
+		Products        []ClientProduct `json:"products"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	if req.FromWarehouseId == req.ToWarehouseId || len(req.Products) == 0 {
+		return bodyError
+	}
+	for _, p := range req.Products {
+		if !p.Quantity.IsPositive() {
+			return bodyError
+		}
+	}
+
+	err = root.repo.InsertTransferOrder(ctx,
+		req.FromWarehouseId, req.ToWarehouseId,
+		req.Products, userLogin.Id)
+	if err != nil {
+		return err
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) ViewTransferOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil {
+		pageSize = 10
+	}
+
+	statusId, err := strconv.Atoi(query.Get("statusId"))
+	if err != nil {
+		statusId = 0
+	}
+
+	sortedBy := "created_at"
+	sortedByQuery := query.Get("sortedBy")
+	if sortedByQuery == "updatedAt" {
+		sortedBy = "updated_at"
+	} else if sortedByQuery == "shippedAt" {
+		sortedBy = "shipped_at"
+	} else if sortedByQuery == "receivedAt" {
+		sortedBy = "received_at"
+	}
+
+	sortOrder := "desc"
+	sortOrderQuery := query.Get("sortOrder")
+	if sortOrderQuery == "asc" {
+		sortOrder = "asc"
+	}
+
+	count, orders, err := root.repo.ViewTransferOrder(ctx,
+		int16(statusId), page, pageSize, sortedBy, sortOrder)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		TransferOrderCount int                   `json:"transferOrderCount"`
+		TransferOrderList  []ClientTransferOrder `json:"transferOrderList"`
+	}
+
+	res := Response{
+		TransferOrderCount: count,
+		TransferOrderList:  orders,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewSingleTransferOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	id, err := strconv.ParseInt(query.Get("transferOrderId"), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	order, items, details, err := root.repo.GetTransferOrder(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		TransferOrder ClientTransferOrder       `json:"transferOrder"`
+		Items         []TransferOrderItem       `json:"items"`
+		Details       []TransferOrderItemDetail `json:"details"`
+	}
+
+	res := Response{
+		TransferOrder: order,
+		Items:         items,
+		Details:       details,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ShipTransferOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Id int64 `json:"id"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.ShipTransferOrder(ctx, req.Id, time.Now())
+	if err != nil {
+		return basic.ReturnConflict(w, err,
+			ErrTransferStatus, ErrQuantityOnHand)
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) MarkInTransitHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Id int64 `json:"id"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.MarkInTransit(ctx, req.Id)
+	if err != nil {
+		return basic.ReturnConflict(w, err, ErrTransferStatus)
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) ReceiveTransferOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Id         int64 `json:"id"`
+		LocationId *int  `json:"locationId"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.ReceiveTransferOrder(ctx,
+		req.Id, req.LocationId, time.Now())
+	if err != nil {
+		return basic.ReturnConflict(w, err, ErrTransferStatus)
+	}
+
+	return basic.ReturnOk(w)
+}
+
+func (root *Root) CancelTransferOrderHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+
+	type Request struct {
+		Id int64 `json:"id"`
+	}
+
+	req := Request{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	err = root.repo.CancelTransferOrder(ctx, req.Id)
+	if err != nil {
+		return basic.ReturnConflict(w, err, ErrTransferStatus)
+	}
+
+	return basic.ReturnOk(w)
+}
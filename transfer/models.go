@@ -0,0 +1,65 @@
+package transfer
+
+import (
+	"baseweb/basic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DraftStatus     int16 = 1
+	ShippedStatus   int16 = 2
+	InTransitStatus int16 = 3
+	ReceivedStatus  int16 = 4
+	CanceledStatus  int16 = 5
+)
+
+type TransferOrder struct {
+	Id              int64          `json:"id" db:"id"`
+	FromWarehouseId uuid.UUID      `json:"fromWarehouseId" db:"from_warehouse_id"`
+	ToWarehouseId   uuid.UUID      `json:"toWarehouseId" db:"to_warehouse_id"`
+	CreatedBy       uuid.UUID      `json:"createdBy" db:"created_by_user_login_id"`
+	StatusId        int16          `json:"statusId" db:"transfer_order_status_id"`
+	ShippedAt       basic.NullTime `json:"shippedAt" db:"shipped_at"`
+	ReceivedAt      basic.NullTime `json:"receivedAt" db:"received_at"`
+	CreatedAt       time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt       time.Time      `json:"updatedAt" db:"updated_at"`
+}
+
+type ClientTransferOrder struct {
+	Id                int64          `json:"id" db:"id"`
+	FromWarehouseId   uuid.UUID      `json:"fromWarehouseId" db:"from_warehouse_id"`
+	FromWarehouseName string         `json:"fromWarehouseName" db:"from_warehouse_name"`
+	ToWarehouseId     uuid.UUID      `json:"toWarehouseId" db:"to_warehouse_id"`
+	ToWarehouseName   string         `json:"toWarehouseName" db:"to_warehouse_name"`
+	CreatedBy         string         `json:"createdBy" db:"created_by"`
+	StatusId          int16          `json:"statusId" db:"transfer_order_status_id"`
+	ShippedAt         basic.NullTime `json:"shippedAt" db:"shipped_at"`
+	ReceivedAt        basic.NullTime `json:"receivedAt" db:"received_at"`
+	CreatedAt         time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt         time.Time      `json:"updatedAt" db:"updated_at"`
+}
+
+type ClientProduct struct {
+	Id       int64           `json:"id"`
+	Quantity decimal.Decimal `json:"quantity"`
+}
+
+type TransferOrderItem struct {
+	Seq         int             `json:"seq" db:"transfer_order_seq"`
+	ProductId   int64           `json:"productId" db:"product_id"`
+	ProductName string          `json:"productName" db:"product_name"`
+	Quantity    decimal.Decimal `json:"quantity" db:"quantity"`
+}
+
+type TransferOrderItemDetail struct {
+	Id                      int64           `json:"id" db:"id"`
+	Seq                     int             `json:"seq" db:"transfer_order_seq"`
+	InventoryItemId         int64           `json:"inventoryItemId" db:"inventory_item_id"`
+	Quantity                decimal.Decimal `json:"quantity" db:"quantity"`
+	UnitCost                decimal.Decimal `json:"unitCost" db:"unit_cost"`
+	CurrencyUomId           string          `json:"currencyUomId" db:"currency_uom_id"`
+	ReceivedInventoryItemId *int64          `json:"receivedInventoryItemId" db:"received_inventory_item_id"`
+}
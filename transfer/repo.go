@@ -0,0 +1,521 @@
+package transfer
+
+import (
+	"baseweb/allocation"
+	"baseweb/basic"
+	importProduct "baseweb/import"
+	"baseweb/ledger"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func InitRepo(db *sqlx.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+var ErrTransferStatus = errors.New("transfer order status transition not allowed")
+
+var ErrQuantityOnHand = errors.New("quantity on hand exceeded")
+
+var transferStatusTransitions = map[int16][]int16{
+	DraftStatus:     {ShippedStatus, CanceledStatus},
+	ShippedStatus:   {InTransitStatus, ReceivedStatus},
+	InTransitStatus: {ReceivedStatus},
+}
+
+func (repo *Repo) InsertTransferOrder(
+	ctx context.Context,
+	fromWarehouseId, toWarehouseId uuid.UUID,
+	products []ClientProduct,
+	userLoginId uuid.UUID) error {
+
+	log.Println("InsertTransferOrder", fromWarehouseId, toWarehouseId,
+		products)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := repo.db.Rebind(`
+        insert into transfer_order(
+        from_warehouse_id, to_warehouse_id,
+        created_by_user_login_id, transfer_order_status_id)
+        values (?, ?, ?, ?) returning id
+        `)
+
+	var id int64
+	err = tx.GetContext(ctx, &id, query,
+		fromWarehouseId, toWarehouseId, userLoginId, DraftStatus)
+	if err != nil {
+		return err
+	}
+
+	query = repo.db.Rebind(`
+        insert into transfer_order_item(
+        transfer_order_id, transfer_order_seq,
+        product_id, quantity)
+        values (?, ?, ?, ?)
+        `)
+	for index, p := range products {
+		_, err = tx.ExecContext(ctx, query, id, index, p.Id, p.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (repo *Repo) ViewTransferOrder(
+	ctx context.Context,
+	statusId int16,
+	page, pageSize int,
+	sortedBy, sortOrder string) (int, []ClientTransferOrder, error) {
+
+	log.Println("ViewTransferOrder", statusId,
+		page, pageSize, sortedBy, sortOrder)
+
+	var count int
+	result := make([]ClientTransferOrder, 0)
+
+	query := repo.db.Rebind(`
+        select count(*) from transfer_order
+        where ? = 0 or transfer_order_status_id = ?
+        `)
+	err := repo.db.GetContext(ctx, &count, query, statusId, statusId)
+	if err != nil {
+		return count, result, err
+	}
+
+	query = `select t.id,
+        t.from_warehouse_id, fw.name as from_warehouse_name,
+        t.to_warehouse_id, tw.name as to_warehouse_name,
+        u.username as created_by,
+        t.transfer_order_status_id,
+        t.shipped_at, t.received_at,
+        t.created_at, t.updated_at
+        from transfer_order t
+            inner join facility fw on fw.id = t.from_warehouse_id
+            inner join facility tw on tw.id = t.to_warehouse_id
+            inner join user_login u on u.id = t.created_by_user_login_id
+        where ? = 0 or t.transfer_order_status_id = ?
+        order by t.%s %s
+        offset ? limit ?`
+	query = fmt.Sprintf(query, sortedBy, sortOrder)
+	query = repo.db.Rebind(query)
+	err = repo.db.SelectContext(ctx, &result, query,
+		statusId, statusId, page*pageSize, pageSize)
+
+	return count, result, err
+}
+
+func (repo *Repo) GetTransferOrder(
+	ctx context.Context, id int64) (
+	ClientTransferOrder, []TransferOrderItem,
+	[]TransferOrderItemDetail, error) {
+
+	log.Println("GetTransferOrder", id)
+
+	order := ClientTransferOrder{}
+	items := make([]TransferOrderItem, 0)
+	details := make([]TransferOrderItemDetail, 0)
+
+	query := repo.db.Rebind(`
+        select t.id,
+        t.from_warehouse_id, fw.name as from_warehouse_name,
+        t.to_warehouse_id, tw.name as to_warehouse_name,
+        u.username as created_by,
+        t.transfer_order_status_id,
+        t.shipped_at, t.received_at,
+        t.created_at, t.updated_at
+        from transfer_order t
+            inner join facility fw on fw.id = t.from_warehouse_id
+            inner join facility tw on tw.id = t.to_warehouse_id
+            inner join user_login u on u.id = t.created_by_user_login_id
+        where t.id = ?
+        `)
+	err := repo.db.GetContext(ctx, &order, query, id)
+	if err != nil {
+		return order, items, details, err
+	}
+
+	query = repo.db.Rebind(`
+        select ti.transfer_order_seq, ti.product_id,
+        p.name as product_name, ti.quantity
+        from transfer_order_item ti
+            inner join product p on p.id = ti.product_id
+        where ti.transfer_order_id = ?
+        order by ti.transfer_order_seq
+        `)
+	err = repo.db.SelectContext(ctx, &items, query, id)
+	if err != nil {
+		return order, items, details, err
+	}
+
+	query = repo.db.Rebind(`
+        select id, transfer_order_seq, inventory_item_id,
+        quantity, unit_cost, currency_uom_id,
+        received_inventory_item_id
+        from transfer_order_item_detail
+        where transfer_order_id = ?
+        order by transfer_order_seq, id
+        `)
+	err = repo.db.SelectContext(ctx, &details, query, id)
+
+	return order, items, details, err
+}
+
+func lockTransferOrder(
+	ctx context.Context, tx *sqlx.Tx,
+	id int64, statusId int16) (TransferOrder, error) {
+
+	order := TransferOrder{}
+	query := tx.Rebind(`
+        select id, from_warehouse_id, to_warehouse_id,
+        created_by_user_login_id, transfer_order_status_id,
+        shipped_at, received_at, created_at, updated_at
+        from transfer_order where id = ?
+        for update
+        `)
+	err := tx.GetContext(ctx, &order, query, id)
+	if err != nil {
+		return order, err
+	}
+
+	for _, next := range transferStatusTransitions[order.StatusId] {
+		if next == statusId {
+			return order, nil
+		}
+	}
+	return order, ErrTransferStatus
+}
+
+func (repo *Repo) ShipTransferOrder(
+	ctx context.Context, id int64, now time.Time) error {
+
+	log.Println("ShipTransferOrder", id)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	order, err := lockTransferOrder(ctx, tx, id, ShippedStatus)
+	if err != nil {
+		return err
+	}
+
+	items := make([]TransferOrderItem, 0)
+	query := repo.db.Rebind(`
+        select transfer_order_seq, product_id, quantity
+        from transfer_order_item
+        where transfer_order_id = ?
+        order by transfer_order_seq
+        `)
+	err = tx.SelectContext(ctx, &items, query, id)
+	if err != nil {
+		return err
+	}
+
+	detailQuery := repo.db.Rebind(`
+        insert into transfer_order_item_detail(
+        transfer_order_id, transfer_order_seq,
+        inventory_item_id, quantity,
+        unit_cost, currency_uom_id)
+        values (?, ?, ?, ?, ?, ?)
+        `)
+
+	updateQuery := repo.db.Rebind(`
+        update inventory_item
+        set quantity_on_hand = quantity_on_hand - ?
+        where id = ?
+        `)
+
+	sourceQuery := repo.db.Rebind(`
+        update warehouse_product_statistics
+        set quantity_on_hand = quantity_on_hand - ?,
+            quantity_available = quantity_available - ?
+        where warehouse_id = ? and product_id = ?
+        `)
+
+	transitQuery := repo.db.Rebind(`
+        insert into warehouse_product_statistics(
+        warehouse_id, product_id, inventory_item_count,
+        quantity_total, quantity_on_hand,
+        quantity_available, quantity_in_transit)
+        values (?, ?, 0, 0, 0, 0, ?)
+        on conflict (warehouse_id, product_id) do update
+        set quantity_in_transit =
+            warehouse_product_statistics.quantity_in_transit +
+            excluded.quantity_in_transit
+        `)
+
+	availableQuery := repo.db.Rebind(`
+        select quantity_available from warehouse_product_statistics
+        where warehouse_id = ? and product_id = ?
+        for update
+        `)
+
+	for _, item := range items {
+		// stock reserved for sale orders stays in the warehouse
+		var available decimal.Decimal
+		err = tx.GetContext(ctx, &available, availableQuery,
+			order.FromWarehouseId, item.ProductId)
+		if err == sql.ErrNoRows {
+			return ErrQuantityOnHand
+		}
+		if err != nil {
+			return err
+		}
+		if available.LessThan(item.Quantity) {
+			return ErrQuantityOnHand
+		}
+
+		_, allocations, shortage, err := allocation.AllocateProduct(ctx, tx,
+			order.FromWarehouseId, item.ProductId, item.Quantity, true)
+		if err != nil {
+			return err
+		}
+		if shortage.IsPositive() {
+			return ErrQuantityOnHand
+		}
+
+		for _, a := range allocations {
+			_, err = tx.ExecContext(ctx, detailQuery,
+				id, item.Seq, a.InventoryItemId, a.Quantity,
+				a.UnitCost, a.CurrencyUomId)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, updateQuery,
+				a.Quantity, a.InventoryItemId)
+			if err != nil {
+				return err
+			}
+
+			inventoryItemId := a.InventoryItemId
+			err = ledger.Record(ctx, tx, ledger.Transaction{
+				TypeId:                ledger.TransferShippedType,
+				WarehouseId:           order.FromWarehouseId,
+				ProductId:             item.ProductId,
+				InventoryItemId:       &inventoryItemId,
+				QuantityOnHandDiff:    a.Quantity.Neg(),
+				QuantityAvailableDiff: a.Quantity.Neg(),
+				TransferOrderId:       &id,
+				OccurredAt:            now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, sourceQuery,
+			item.Quantity, item.Quantity,
+			order.FromWarehouseId, item.ProductId)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, transitQuery,
+			order.ToWarehouseId, item.ProductId, item.Quantity)
+		if err != nil {
+			return err
+		}
+
+		err = ledger.Record(ctx, tx, ledger.Transaction{
+			TypeId:                ledger.TransferInTransitType,
+			WarehouseId:           order.ToWarehouseId,
+			ProductId:             item.ProductId,
+			QuantityInTransitDiff: item.Quantity,
+			TransferOrderId:       &id,
+			OccurredAt:            now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	query = repo.db.Rebind(`
+        update transfer_order
+        set transfer_order_status_id = ?, shipped_at = ?
+        where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, ShippedStatus, now, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *Repo) MarkInTransit(ctx context.Context, id int64) error {
+	log.Println("MarkInTransit", id)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = lockTransferOrder(ctx, tx, id, InTransitStatus)
+	if err != nil {
+		return err
+	}
+
+	query := repo.db.Rebind(`
+        update transfer_order
+        set transfer_order_status_id = ?
+        where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, InTransitStatus, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *Repo) ReceiveTransferOrder(
+	ctx context.Context, id int64,
+	locationId *int, now time.Time) error {
+
+	log.Println("ReceiveTransferOrder", id, locationId)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	order, err := lockTransferOrder(ctx, tx, id, ReceivedStatus)
+	if err != nil {
+		return err
+	}
+
+	type Detail struct {
+		Id            int64            `db:"id"`
+		ProductId     int64            `db:"product_id"`
+		Quantity      decimal.Decimal  `db:"quantity"`
+		UnitCost      decimal.Decimal  `db:"unit_cost"`
+		CurrencyUomId string           `db:"currency_uom_id"`
+		LotNumber     basic.NullString `db:"lot_number"`
+		ExpiryDate    basic.NullTime   `db:"expiry_date"`
+	}
+	details := make([]Detail, 0)
+
+	query := repo.db.Rebind(`
+        select d.id, ti.product_id, d.quantity,
+        d.unit_cost, d.currency_uom_id, i.lot_number, i.expiry_date
+        from transfer_order_item_detail d
+            inner join inventory_item i on i.id = d.inventory_item_id
+            inner join transfer_order_item ti
+                on ti.transfer_order_id = d.transfer_order_id
+                and ti.transfer_order_seq = d.transfer_order_seq
+        where d.transfer_order_id = ?
+        order by d.transfer_order_seq, d.id
+        `)
+	err = tx.SelectContext(ctx, &details, query, id)
+	if err != nil {
+		return err
+	}
+
+	detailQuery := repo.db.Rebind(`
+        update transfer_order_item_detail
+        set received_inventory_item_id = ?
+        where id = ?
+        `)
+
+	transitQuery := repo.db.Rebind(`
+        update warehouse_product_statistics
+        set quantity_in_transit = quantity_in_transit - ?
+        where warehouse_id = ? and product_id = ?
+        `)
+
+	for _, detail := range details {
+		// the unit cost, lot and expiry travel with the goods,
+		// so the destination values them like the source did
+		itemId, err := importProduct.ReceiveInventoryItem(ctx, tx,
+			importProduct.InventoryItem{
+				ProductId:       detail.ProductId,
+				WarehouseId:     order.ToWarehouseId,
+				LocationId:      locationId,
+				Quantity:        detail.Quantity,
+				UnitCost:        detail.UnitCost,
+				CurrencyUomId:   detail.CurrencyUomId,
+				LotNumber:       detail.LotNumber,
+				ExpiryDate:      detail.ExpiryDate,
+				TransferOrderId: &id,
+			})
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, detailQuery, itemId, detail.Id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, transitQuery,
+			detail.Quantity, order.ToWarehouseId, detail.ProductId)
+		if err != nil {
+			return err
+		}
+	}
+
+	query = repo.db.Rebind(`
+        update transfer_order
+        set transfer_order_status_id = ?, received_at = ?
+        where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, ReceivedStatus, now, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *Repo) CancelTransferOrder(ctx context.Context, id int64) error {
+	log.Println("CancelTransferOrder", id)
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = lockTransferOrder(ctx, tx, id, CanceledStatus)
+	if err != nil {
+		return err
+	}
+
+	query := repo.db.Rebind(`
+        update transfer_order
+        set transfer_order_status_id = ?
+        where id = ?
+        `)
+	_, err = tx.ExecContext(ctx, query, CanceledStatus, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
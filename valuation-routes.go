@@ -0,0 +1,18 @@
+package main
+
+func ValuationRoutes(root *Root) {
+	root.GetAuthorized(
+		"/api/valuation/view-valuation",
+		"VIEW_EDIT_FACILITY",
+		root.valuation.ViewValuationHandler)
+
+	root.GetAuthorized(
+		"/api/valuation/view-cogs",
+		"VIEW_EDIT_FACILITY",
+		root.valuation.ViewCogsHandler)
+
+	root.GetAuthorized(
+		"/api/valuation/view-order-margin",
+		"VIEW_EDIT_ORDER",
+		root.valuation.ViewOrderMarginHandler)
+}
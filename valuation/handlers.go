@@ -0,0 +1,142 @@
+package valuation
+
+import (
+	"baseweb/basic"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Root struct {
+	repo *Repo
+}
+
+func InitRoot(repo *Repo) *Root {
+	return &Root{
+		repo: repo,
+	}
+}
+
+var queryError = errors.New("query constraints violation")
+
+func parseWarehouseId(value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (root *Root) ViewValuationHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	warehouseId, err := parseWarehouseId(query.Get("warehouseId"))
+	if err != nil {
+		return err
+	}
+
+	var productId *int64
+	if query.Get("productId") != "" {
+		id, err := strconv.ParseInt(query.Get("productId"), 10, 64)
+		if err != nil {
+			return err
+		}
+		productId = &id
+	}
+
+	asOf, err := basic.ParseAsOf(query.Get("asOf"), time.Now())
+	if err != nil {
+		return err
+	}
+
+	layers, err := root.repo.SelectLayer(ctx, warehouseId, productId, asOf)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		AsOf          time.Time   `json:"asOf"`
+		ValuationList []Valuation `json:"valuationList"`
+	}
+
+	res := Response{
+		AsOf:          asOf,
+		ValuationList: Value(layers),
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewCogsHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	warehouseId, err := parseWarehouseId(query.Get("warehouseId"))
+	if err != nil {
+		return err
+	}
+
+	from, err := basic.ParseTime(query.Get("from"), time.Time{})
+	if err != nil {
+		return err
+	}
+
+	to, err := basic.ParseAsOf(query.Get("to"), time.Now())
+	if err != nil {
+		return err
+	}
+
+	if !from.Before(to) {
+		return queryError
+	}
+
+	cogs, err := root.repo.SelectCogs(ctx, warehouseId, from, to)
+	if err != nil {
+		return err
+	}
+
+	type Response struct {
+		From     time.Time `json:"from"`
+		To       time.Time `json:"to"`
+		CogsList []Cogs    `json:"cogsList"`
+	}
+
+	res := Response{
+		From:     from,
+		To:       to,
+		CogsList: cogs,
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+func (root *Root) ViewOrderMarginHandler(
+	w http.ResponseWriter, r *http.Request) error {
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	saleOrderId, err := strconv.ParseInt(query.Get("saleOrderId"), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	margin, err := root.repo.GetOrderMargin(ctx, saleOrderId)
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(margin)
+}
@@ -0,0 +1,68 @@
+package valuation
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Layer is an inventory item as it stood on the valuation date.
+type Layer struct {
+	WarehouseId    uuid.UUID       `db:"warehouse_id"`
+	WarehouseName  string          `db:"warehouse_name"`
+	ProductId      int64           `db:"product_id"`
+	ProductName    string          `db:"product_name"`
+	CurrencyUomId  string          `db:"currency_uom_id"`
+	Quantity       decimal.Decimal `db:"quantity"`
+	QuantityOnHand decimal.Decimal `db:"quantity_on_hand"`
+	UnitCost       decimal.Decimal `db:"unit_cost"`
+	CreatedAt      time.Time       `db:"created_at"`
+}
+
+type Valuation struct {
+	WarehouseId    uuid.UUID       `json:"warehouseId"`
+	WarehouseName  string          `json:"warehouseName"`
+	ProductId      int64           `json:"productId"`
+	ProductName    string          `json:"productName"`
+	CurrencyUomId  string          `json:"currencyUomId"`
+	QuantityOnHand decimal.Decimal `json:"quantityOnHand"`
+	AverageCost    decimal.Decimal `json:"averageCost"`
+	AverageValue   decimal.Decimal `json:"averageValue"`
+	FifoValue      decimal.Decimal `json:"fifoValue"`
+}
+
+type Cogs struct {
+	ProductId     int64           `json:"productId" db:"product_id"`
+	ProductName   string          `json:"productName" db:"product_name"`
+	CurrencyUomId string          `json:"currencyUomId" db:"currency_uom_id"`
+	Quantity      decimal.Decimal `json:"quantity" db:"quantity"`
+	Cost          decimal.Decimal `json:"cost" db:"cost"`
+}
+
+type MarginLine struct {
+	SaleOrderSeq   int             `json:"saleOrderSeq" db:"sale_order_seq"`
+	ProductId      int64           `json:"productId" db:"product_id"`
+	ProductName    string          `json:"productName" db:"product_name"`
+	Quantity       decimal.Decimal `json:"quantity" db:"quantity"`
+	Shipped        decimal.Decimal `json:"shippedQuantity" db:"shipped_quantity"`
+	Price          decimal.Decimal `json:"price" db:"price"`
+	CurrencyUomId  string          `json:"currencyUomId" db:"currency_uom_id"`
+	DiscountAmount decimal.Decimal `json:"discountAmount" db:"discount_amount"`
+	BackorderOfSeq *int            `json:"backorderOfSeq" db:"backorder_of_seq"`
+	Exported       bool            `json:"exported" db:"exported"`
+	Revenue        decimal.Decimal `json:"revenue" db:"-"`
+	Cost           decimal.Decimal `json:"cost" db:"cost"`
+	Margin         decimal.Decimal `json:"margin" db:"-"`
+}
+
+type OrderMargin struct {
+	SaleOrderId   int64           `json:"saleOrderId" db:"id"`
+	DiscountTotal decimal.Decimal `json:"discountAmount" db:"discount_amount"`
+	NetAmount     decimal.Decimal `json:"netAmount" db:"net_amount"`
+	Revenue       decimal.Decimal `json:"revenue" db:"-"`
+	Cost          decimal.Decimal `json:"cost" db:"-"`
+	Margin        decimal.Decimal `json:"margin" db:"-"`
+	MarginPercent decimal.Decimal `json:"marginPercent" db:"-"`
+	Lines         []MarginLine    `json:"lines" db:"-"`
+}
@@ -0,0 +1,183 @@
+package valuation
+
+import (
+	"baseweb/ledger"
+	"baseweb/tax"
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repo struct {
+	db *sqlx.DB
+}
+
+func InitRepo(db *sqlx.DB) *Repo {
+	return &Repo{
+		db: db,
+	}
+}
+
+// SelectLayer rebuilds the quantity on hand of every inventory item at
+// asOf from what was exported, shipped on transfers, adjusted and
+// restored from returns since.
+func (repo *Repo) SelectLayer(
+	ctx context.Context,
+	warehouseId *uuid.UUID, productId *int64,
+	asOf time.Time) ([]Layer, error) {
+
+	log.Println("SelectLayer", warehouseId, productId, asOf)
+
+	result := make([]Layer, 0)
+
+	query := repo.db.Rebind(`
+        select i.warehouse_id, w.name as warehouse_name,
+        i.product_id, p.name as product_name,
+        i.currency_uom_id, i.quantity, i.unit_cost, i.created_at,
+        i.quantity
+        - coalesce((
+            select sum(d.exported_quantity) from inventory_item_detail d
+            where d.inventory_item_id = i.id and d.effective_from <= ?
+        ), 0)
+        - coalesce((
+            select sum(t.quantity) from transfer_order_item_detail t
+                inner join transfer_order o on o.id = t.transfer_order_id
+            where t.inventory_item_id = i.id and o.shipped_at <= ?
+        ), 0)
+        + coalesce((
+            select sum(a.quantity) from inventory_adjustment a
+            where a.inventory_item_id = i.id and a.created_at <= ?
+        ), 0)
+        + coalesce((
+            select sum(t.quantity_on_hand_diff) from inventory_transaction t
+            where t.inventory_item_id = i.id
+                and t.inventory_transaction_type_id = ?
+                and t.occurred_at <= ?
+        ), 0) as quantity_on_hand
+        from inventory_item i
+            inner join facility w on w.id = i.warehouse_id
+            inner join product p on p.id = i.product_id
+        where i.created_at <= ?
+            and (cast(? as uuid) is null or i.warehouse_id = ?)
+            and (cast(? as integer) is null or i.product_id = ?)
+        order by i.warehouse_id, i.product_id, i.currency_uom_id,
+            i.created_at, i.id
+        `)
+	err := repo.db.SelectContext(ctx, &result, query,
+		asOf, asOf, asOf, ledger.ReturnRestoredType, asOf, asOf,
+		warehouseId, warehouseId, productId, productId)
+
+	return result, err
+}
+
+func (repo *Repo) SelectCogs(
+	ctx context.Context,
+	warehouseId *uuid.UUID,
+	from, to time.Time) ([]Cogs, error) {
+
+	log.Println("SelectCogs", warehouseId, from, to)
+
+	result := make([]Cogs, 0)
+
+	query := repo.db.Rebind(`
+        select i.product_id, p.name as product_name, i.currency_uom_id,
+        sum(d.exported_quantity) as quantity,
+        sum(d.exported_quantity * i.unit_cost) as cost
+        from inventory_item_detail d
+            inner join inventory_item i on i.id = d.inventory_item_id
+            inner join product p on p.id = i.product_id
+        where d.effective_from >= ? and d.effective_from < ?
+            and (cast(? as uuid) is null or i.warehouse_id = ?)
+        group by i.product_id, p.name, i.currency_uom_id
+        order by i.product_id, i.currency_uom_id
+        `)
+	err := repo.db.SelectContext(ctx, &result, query,
+		from, to, warehouseId, warehouseId)
+
+	return result, err
+}
+
+// GetOrderMargin compares what each line of a sale order has shipped
+// for, at the product_price it was ordered with, against the cost of
+// the inventory items it was exported from. Discounts, the order level
+// share included, are taken in the part shipped, the way the invoice
+// takes them, so a backorder line is priced like the line it came
+// from.
+func (repo *Repo) GetOrderMargin(
+	ctx context.Context, saleOrderId int64) (OrderMargin, error) {
+
+	log.Println("GetOrderMargin", saleOrderId)
+
+	result := OrderMargin{}
+
+	query := repo.db.Rebind(`
+        select id, discount_amount, net_amount
+        from sale_order where id = ?
+        `)
+	err := repo.db.GetContext(ctx, &result, query, saleOrderId)
+	if err != nil {
+		return result, err
+	}
+
+	result.Lines = make([]MarginLine, 0)
+	query = repo.db.Rebind(`
+        select oi.sale_order_seq, pp.product_id, p.name as product_name,
+        oi.quantity, oi.shipped_quantity, pp.price, pp.currency_uom_id,
+        oi.discount_amount, oi.backorder_of_seq, oi.exported,
+        coalesce((
+            select sum(d.exported_quantity * i.unit_cost)
+            from inventory_item_detail d
+                inner join inventory_item i on i.id = d.inventory_item_id
+            where d.sale_order_id = oi.sale_order_id
+                and d.sale_order_seq = oi.sale_order_seq
+        ), 0) as cost
+        from sale_order_item oi
+            inner join product_price pp on pp.id = oi.product_price_id
+            inner join product p on p.id = pp.product_id
+        where oi.sale_order_id = ? and oi.removed_at is null
+        order by oi.sale_order_seq
+        `)
+	err = repo.db.SelectContext(ctx, &result.Lines, query, saleOrderId)
+	if err != nil {
+		return result, err
+	}
+
+	bySeq := make(map[int]MarginLine)
+	priced := make([]tax.Line, 0, len(result.Lines))
+	for _, line := range result.Lines {
+		bySeq[line.SaleOrderSeq] = line
+		if line.BackorderOfSeq == nil {
+			priced = append(priced, tax.Line{
+				Seq: line.SaleOrderSeq,
+				NetAmount: line.Price.Mul(line.Quantity).
+					Sub(line.DiscountAmount),
+			})
+		}
+	}
+	shares := tax.DiscountShares(priced, result.DiscountTotal)
+
+	for i := range result.Lines {
+		line := &result.Lines[i]
+
+		origin := *line
+		for origin.BackorderOfSeq != nil {
+			origin = bySeq[*origin.BackorderOfSeq]
+		}
+		part := line.Shipped.Div(origin.Quantity)
+		discount := origin.DiscountAmount.Add(shares[origin.SaleOrderSeq]).
+			Mul(part).Round(2)
+
+		line.Revenue = line.Price.Mul(line.Shipped).Round(2).Sub(discount)
+		line.Margin = line.Revenue.Sub(line.Cost)
+		result.Revenue = result.Revenue.Add(line.Revenue)
+		result.Cost = result.Cost.Add(line.Cost)
+	}
+
+	result.Margin = result.Revenue.Sub(result.Cost)
+	result.MarginPercent = marginPercent(result.Margin, result.Revenue)
+
+	return result, nil
+}
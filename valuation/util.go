@@ -0,0 +1,75 @@
+package valuation
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+func sameKey(a, b Layer) bool {
+	return a.WarehouseId == b.WarehouseId &&
+		a.ProductId == b.ProductId &&
+		a.CurrencyUomId == b.CurrencyUomId
+}
+
+// value computes both cost methods for the layers of one warehouse,
+// product and currency, ordered by receipt time.
+//
+// Weighted average spreads the cost of everything received up to the
+// valuation date over the quantity on hand. FIFO assumes what is left is
+// what came in last, so it walks the receipts from the newest one.
+func value(layers []Layer) Valuation {
+	first := layers[0]
+	result := Valuation{
+		WarehouseId:   first.WarehouseId,
+		WarehouseName: first.WarehouseName,
+		ProductId:     first.ProductId,
+		ProductName:   first.ProductName,
+		CurrencyUomId: first.CurrencyUomId,
+	}
+
+	receivedQuantity := decimal.Zero
+	receivedCost := decimal.Zero
+	for _, l := range layers {
+		result.QuantityOnHand = result.QuantityOnHand.Add(l.QuantityOnHand)
+		receivedQuantity = receivedQuantity.Add(l.Quantity)
+		receivedCost = receivedCost.Add(l.Quantity.Mul(l.UnitCost))
+	}
+
+	if receivedQuantity.IsPositive() {
+		result.AverageCost = receivedCost.Div(receivedQuantity).Round(4)
+		result.AverageValue = receivedCost.Mul(result.QuantityOnHand).
+			Div(receivedQuantity).Round(2)
+	}
+
+	left := result.QuantityOnHand
+	for i := len(layers) - 1; i >= 0 && left.IsPositive(); i-- {
+		taken := decimal.Min(layers[i].Quantity, left)
+		result.FifoValue = result.FifoValue.Add(taken.Mul(layers[i].UnitCost))
+		left = left.Sub(taken)
+	}
+	result.FifoValue = result.FifoValue.Round(2)
+
+	return result
+}
+
+// Value groups the layers by warehouse, product and currency
+// and values every group.
+func Value(layers []Layer) []Valuation {
+	result := make([]Valuation, 0)
+	start := 0
+	for i := 1; i <= len(layers); i++ {
+		if i == len(layers) || !sameKey(layers[start], layers[i]) {
+			result = append(result, value(layers[start:i]))
+			start = i
+		}
+	}
+	return result
+}
+
+func marginPercent(margin, revenue decimal.Decimal) decimal.Decimal {
+	if !revenue.IsPositive() {
+		return decimal.Zero
+	}
+	return margin.Mul(hundred).Div(revenue).Round(2)
+}